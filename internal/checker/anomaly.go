@@ -0,0 +1,145 @@
+package checker
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// A baseline is started over when no check succeeded for anomalyResetIntervals
+// intervals, and at least anomalyResetAfter: after an outage or a night
+// asleep, the route and the server may well have changed.
+const (
+	anomalyResetAfter     = 30 * time.Minute
+	anomalyResetIntervals = 10
+)
+
+// latencyBaseline is the mean and standard deviation of a monitor's recent
+// successful response times, kept as running sums over the latest samples.
+type latencyBaseline struct {
+	samples []int64 // oldest first
+	sum     int64
+	sumSq   int64
+	last    time.Time
+
+	// recent says whether each of the last checks was anomalous, oldest
+	// first, for the anomaly alert; alerted is set while one is raised.
+	recent  []bool
+	alerted bool
+}
+
+func (b *latencyBaseline) add(ms int64, size int) {
+	b.samples = append(b.samples, ms)
+	b.sum += ms
+	b.sumSq += ms * ms
+	for len(b.samples) > size {
+		old := b.samples[0]
+		b.sum -= old
+		b.sumSq -= old * old
+		b.samples = b.samples[1:]
+	}
+}
+
+// stats returns the baseline's mean and standard deviation. The deviation
+// is at least 5% of the mean and 1ms, so a monitor whose response times
+// barely vary isn't flagged for a few milliseconds.
+func (b *latencyBaseline) stats() (mean, stddev float64) {
+	n := float64(len(b.samples))
+	mean = float64(b.sum) / n
+	variance := float64(b.sumSq)/n - mean*mean
+	stddev = math.Sqrt(max(variance, 0))
+	return mean, max(stddev, mean*0.05, 1)
+}
+
+// remember records whether the latest check was anomalous and returns how
+// many of the last window were.
+func (b *latencyBaseline) remember(anomalous bool, window int) int {
+	b.recent = append(b.recent, anomalous)
+	if len(b.recent) > window {
+		b.recent = b.recent[len(b.recent)-window:]
+	}
+	n := 0
+	for _, a := range b.recent {
+		if a {
+			n++
+		}
+	}
+	return n
+}
+
+// anomalySettings reads the anomaly settings, falling back to the defaults
+// for values that don't parse.
+func (c *Checker) anomalySettings() (sigma float64, size int, alert storage.AnomalyAlert) {
+	sigma, err := storage.ParseAnomalySigma(c.db.GetStringSetting(storage.SettingAnomalySigma, ""))
+	if err != nil {
+		sigma = storage.DefaultAnomalySigma
+	}
+	size, err = storage.ParseAnomalyBaseline(c.db.GetStringSetting(storage.SettingAnomalyBaseline, ""))
+	if err != nil {
+		size = storage.DefaultAnomalyBaseline
+	}
+	alert, _ = storage.ParseAnomalyAlert(c.db.GetStringSetting(storage.SettingNotifyAnomaly, ""))
+	return sigma, size, alert
+}
+
+// evaluateAnomaly compares a successful check's response time with m's
+// baseline, then adds it to the baseline. It reports whether the check is
+// anomalous, which needs a baseline of at least MinAnomalyBaseline checks.
+// Only the monitor's own goroutine keeps a baseline, so a configuration
+// change, which restarts it, starts a new one.
+func (c *Checker) evaluateAnomaly(m *storage.Monitor, now time.Time, responseTime int64) bool {
+	ms := c.ownState(m)
+	if ms == nil {
+		return false
+	}
+	sigma, size, alert := c.anomalySettings()
+	if sigma == 0 {
+		ms.baseline = nil
+		return false
+	}
+
+	b := ms.baseline
+	resetAfter := max(anomalyResetAfter, anomalyResetIntervals*monitorInterval(m))
+	if b == nil || now.Sub(b.last) > resetAfter {
+		if b != nil {
+			slog.Debug("latency baseline reset after gap", "monitor", m.Name, "id", m.ID, "gap", now.Sub(b.last).Round(time.Second))
+		}
+		b = &latencyBaseline{}
+		ms.baseline = b
+	}
+
+	anomalous := false
+	var detail string
+	if len(b.samples) >= storage.MinAnomalyBaseline {
+		mean, stddev := b.stats()
+		if float64(responseTime) > mean+sigma*stddev {
+			anomalous = true
+			detail = fmt.Sprintf("%dms, baseline %.0fms ± %.0fms", responseTime, mean, stddev)
+		}
+	}
+	b.add(responseTime, size)
+	b.last = now
+
+	if anomalous {
+		slog.Info("latency anomaly", "monitor", m.Name, "id", m.ID, "detail", detail)
+	}
+	if !alert.Enabled() {
+		b.recent, b.alerted = nil, false
+		return anomalous
+	}
+	switch n := b.remember(anomalous, alert.Window); {
+	case n >= alert.Count && !b.alerted:
+		b.alerted = true
+		summary := fmt.Sprintf("%d of the last %d checks were anomalous", n, len(b.recent))
+		if detail != "" {
+			summary += ", latest " + detail
+		}
+		c.notifyAnomaly(m, summary)
+	case n == 0:
+		b.alerted = false
+	}
+	return anomalous
+}
@@ -0,0 +1,84 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+func TestEvaluateAnomaly(t *testing.T) {
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, "http://127.0.0.1:1/")
+	c := New(db, nil)
+	ms := &monitorState{monitor: m}
+	c.monitors[m.ID] = ms
+
+	now := time.Now()
+	check := func(responseTime int64) bool {
+		now = now.Add(time.Minute)
+		return c.evaluateAnomaly(m, now, responseTime)
+	}
+
+	// Too few checks for a baseline: nothing is anomalous yet.
+	for i := range storage.MinAnomalyBaseline {
+		rt := int64(95)
+		if i%2 == 1 {
+			rt = 105
+		}
+		if check(rt) {
+			t.Fatalf("check %d flagged before the baseline had %d checks", i+1, storage.MinAnomalyBaseline)
+		}
+	}
+	if !check(1000) {
+		t.Error("1000ms against a 100ms ± 5ms baseline wasn't flagged")
+	}
+
+	// The outlier joined the baseline, so start again from a steady one.
+	ms.baseline = nil
+	for i := range storage.MinAnomalyBaseline {
+		check(95 + 10*int64(i%2))
+	}
+	if check(110) {
+		t.Error("110ms, within 3 deviations of 100ms ± 5ms, was flagged")
+	}
+	if !check(120) {
+		t.Error("120ms, beyond 3 deviations of 100ms ± 5ms, wasn't flagged")
+	}
+
+	// After a long gap the baseline starts over.
+	now = now.Add(anomalyResetAfter)
+	if check(1000) {
+		t.Error("first check after a long gap was judged by the old baseline")
+	}
+	if n := len(ms.baseline.samples); n != 1 {
+		t.Errorf("baseline has %d samples after the gap, want 1", n)
+	}
+
+	// A sigma of 0 turns detection off and drops the baseline.
+	if err := db.SetSetting(storage.SettingAnomalySigma, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if check(5000) || ms.baseline != nil {
+		t.Error("anomaly detection still running with sigma 0")
+	}
+
+	// Another goroutine's copy of the monitor keeps no baseline.
+	if other := *m; c.evaluateAnomaly(&other, now, 5000) {
+		t.Error("a monitor not owned by its state was evaluated")
+	}
+}
+
+func TestBaselineRemember(t *testing.T) {
+	var b latencyBaseline
+	for i, tc := range []struct {
+		anomalous bool
+		want      int
+	}{
+		{true, 1}, {false, 1}, {true, 2}, {true, 3}, {false, 2}, {false, 2}, {false, 1},
+	} {
+		if got := b.remember(tc.anomalous, 4); got != tc.want {
+			t.Errorf("after check %d: %d anomalous of the last 4, want %d", i+1, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// maxBannerBytes caps how much is read while waiting for the banner's line
+// break, in case the server sends something that isn't line-based.
+const maxBannerBytes = 1024
+
+// probeBanner connects to a banner monitor's host:port and reads the first
+// line the server sends. The response time covers connecting and the
+// banner. Proxy settings don't apply, since there is no HTTP request.
+func (c *Checker) probeBanner(m *storage.Monitor, opts transportOptions) probeResult {
+	p := probeResult{conn: &connInfo{}}
+
+	timeout := time.Duration(m.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = time.Duration(config.DefaultTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
+	network := opts.network
+	if network == "" {
+		network = "tcp"
+	}
+
+	dialCtx := ctx
+	if connect := connectTimeout(m); connect > 0 {
+		var cancelDial context.CancelFunc
+		dialCtx, cancelDial = context.WithTimeout(ctx, connect)
+		defer cancelDial()
+	}
+
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(dialCtx, network, m.URL)
+	if err != nil {
+		p.err = err
+		p.aborted = c.stopped()
+		return p
+	}
+	defer conn.Close()
+	p.conn.connect = time.Since(start)
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		p.conn.resolvedIP = addr.IP.String()
+	}
+
+	// Unblock the read when the timeout passes or the checker stops.
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	line, err := bufio.NewReader(io.LimitReader(conn, maxBannerBytes)).ReadString('\n')
+	p.responseTime = time.Since(start).Milliseconds()
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" && err != nil {
+		p.aborted = c.stopped()
+		switch {
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			p.err = fmt.Errorf("no banner received within %s", timeout)
+		case errors.Is(err, io.EOF):
+			p.err = fmt.Errorf("connection closed without a banner")
+		default:
+			p.err = fmt.Errorf("failed to read banner: %w", err)
+		}
+		return p
+	}
+
+	p.body = []byte(line)
+	sum := sha256.Sum256(p.body)
+	p.bodySHA256 = hex.EncodeToString(sum[:])
+
+	if m.ExpectedBanner != "" {
+		pattern, err := storage.ParseBannerPattern(m.ExpectedBanner)
+		if err != nil {
+			p.err = err
+		} else if !pattern.Match(line) {
+			p.err = fmt.Errorf("unexpected banner %q, expected %s", line, m.ExpectedBanner)
+		}
+	}
+	return p
+}
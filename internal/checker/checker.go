@@ -2,85 +2,285 @@ package checker
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"regexp"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ankityadav/statping/internal/config"
 	"github.com/ankityadav/statping/internal/notifier"
 	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+var (
+	checksTotal     = telemetry.NewCounter("statping_checks_total", "Checks performed, including heartbeat deadline checks.")
+	checksSkipped   = telemetry.NewCounter("statping_checks_skipped_total", "Immediate check requests dropped because one was already pending for the monitor.")
+	checkDuration   = telemetry.NewHistogram("statping_check_duration_seconds", "Time taken by a check, from request to recording the result.", 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30)
+	monitorsRunning = telemetry.NewGauge("statping_monitors_running", "Monitors the checker is running.")
 )
 
 type Checker struct {
 	db       *storage.Database
 	notifier *notifier.Notifier
-	client   *http.Client
+	clients  *clientPool
+	schemas  *schemaCache
 	stopChan chan struct{}
+	stopOnce sync.Once
 	wg       sync.WaitGroup
 	mu       sync.RWMutex
 	monitors map[uint]*monitorState
+	handlers []ResultHandler
+
+	// heartbeatMu serializes pings with the overdue check so neither saves
+	// a stale copy of the monitor over the other's update.
+	heartbeatMu sync.Mutex
+
+	// proxyNotified is when each proxy was last reported unreachable,
+	// guarded by mu.
+	proxyNotified map[string]time.Time
+
+	// tokens caches OAuth2 access tokens, and authNotified is when each
+	// monitor's token endpoint was last reported failing, guarded by mu.
+	tokens       *tokenCache
+	authNotified map[uint]time.Time
+
+	// pending holds check results that could not be saved yet, oldest
+	// first; saveResult retries them before the next result.
+	pendingMu sync.Mutex
+	pending   []*storage.CheckResult
+
+	// notifications are the per-monitor queues of notify, guarded by
+	// notifyMu.
+	notifyMu      sync.Mutex
+	notifications map[uint]chan func()
+
+	// filter limits which monitors are checked; see SetFilter.
+	filter storage.MonitorFilter
+
+	// started is when this checker was created. A monitor whose last check
+	// predates it is seeing its first check since a restart.
+	started time.Time
+
+	// ctx is the parent of every check's request context. Stop cancels it
+	// so in-flight requests are aborted instead of running to their timeout.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type monitorState struct {
 	monitor      *storage.Monitor
 	ticker       *time.Ticker
 	stopChan     chan struct{}
+	checkNow     chan struct{}
 	lastNotified time.Time
+	nextCheck    time.Time
+	delay        time.Duration
+
+	// status and lastCheck copy the monitor's status and last check time
+	// under c.mu after each check, for readers outside its goroutine,
+	// which goes on updating monitor without the lock.
+	status    string
+	lastCheck *time.Time
+
+	// latency and baseline are only touched by the monitor's own
+	// goroutine; they are nil until the first successful check.
+	latency  *latencyWindow
+	baseline *latencyBaseline
+}
+
+// MonitorStatus is the checker's view of a running monitor. Delay is the
+// current check interval, which is longer than configured while the
+// monitor is backing off.
+type MonitorStatus struct {
+	Status    string
+	NextCheck time.Time
+	Delay     time.Duration
 }
 
+// ResultHandler is called after every completed check with a snapshot of the
+// monitor (including its updated status) and the recorded result.
+type ResultHandler func(m storage.Monitor, result storage.CheckResult)
+
 func New(db *storage.Database, n *notifier.Notifier) *Checker {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Checker{
-		db:       db,
-		notifier: n,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		stopChan: make(chan struct{}),
-		monitors: make(map[uint]*monitorState),
+		db:            db,
+		notifier:      n,
+		clients:       newClientPool(),
+		schemas:       newSchemaCache(),
+		stopChan:      make(chan struct{}),
+		monitors:      make(map[uint]*monitorState),
+		proxyNotified: make(map[string]time.Time),
+		tokens:        newTokenCache(),
+		authNotified:  make(map[uint]time.Time),
+		notifications: make(map[uint]chan func()),
+		started:       time.Now(),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
+// SetFilter limits the checker to the monitors f matches, as when a
+// dashboard scoped to a few of them runs its own checker. It must be called
+// before Start.
+func (c *Checker) SetFilter(f storage.MonitorFilter) {
+	c.filter = f
+}
+
 func (c *Checker) Start(ctx context.Context) error {
+	if c.db.GetBoolSetting(storage.SettingInternalMetrics, false) {
+		telemetry.Enable()
+	}
+
+	if _, err := c.db.ResumeExpiredPauses(time.Now()); err != nil {
+		slog.Error("failed to resume expired pauses", "error", err)
+	}
+
+	// Before the first checks, which would hide a gap.
+	c.beat()
+
 	monitors, err := c.db.ListEnabledMonitors()
 	if err != nil {
 		return fmt.Errorf("failed to load monitors: %w", err)
 	}
 
-	for _, m := range monitors {
+	for _, m := range c.filter.Apply(monitors) {
 		monitor := m
-		c.startMonitor(&monitor)
+		c.startMonitor(&monitor, time.Now())
 	}
 
 	go func() {
-		<-ctx.Done()
-		c.Stop()
+		select {
+		case <-ctx.Done():
+			c.Stop()
+		case <-c.stopChan:
+		}
 	}()
 
+	c.wg.Add(2)
+	go c.expirePauses()
+	go c.heartbeat()
+
 	return nil
 }
 
-func (c *Checker) Stop() {
-	close(c.stopChan)
+// heartbeat records that the checker is alive every
+// CheckerHeartbeatInterval, so the UIs can tell a quiet period from a dead
+// daemon.
+func (c *Checker) heartbeat() {
+	defer c.wg.Done()
 
-	c.mu.Lock()
-	for _, ms := range c.monitors {
-		if ms.ticker != nil {
-			ms.ticker.Stop()
+	ticker := time.NewTicker(storage.CheckerHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			c.beat()
 		}
-		close(ms.stopChan)
 	}
-	c.mu.Unlock()
+}
+
+// beat records a heartbeat. If monitoring had stalled before it, because
+// no checker was running or the machine slept, it logs the gap and, when
+// enabled, notifies about it.
+func (c *Checker) beat() {
+	now := time.Now()
+	activity, err := c.db.GetMonitoringActivity()
+	if err != nil {
+		slog.Error("failed to load monitoring activity", "error", err)
+	} else if activity.Stalled(now) {
+		gap := activity.Silence(now).Round(time.Second)
+		slog.Warn("monitoring resumed after gap", "gap", gap, "last_activity", activity.Last)
+		if c.db.GetBoolSetting(storage.SettingNotifyResumed, false) {
+			c.notify(noMonitor, func() { c.notifier.NotifyMonitoringResumed(gap) })
+		}
+	}
+	if err := c.db.RecordCheckerHeartbeat(now); err != nil {
+		slog.Error("failed to record checker heartbeat", "error", err)
+	}
+}
+
+// pauseExpiryInterval is how often timed pauses are checked for expiry.
+const pauseExpiryInterval = 30 * time.Second
+
+// expirePauses resumes monitors whose timed pause has ended, so a pause
+// from a deploy pipeline ends even if the resume call never comes.
+func (c *Checker) expirePauses() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(pauseExpiryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+			ids, err := c.db.ResumeExpiredPauses(time.Now())
+			if err != nil {
+				slog.Error("failed to resume expired pauses", "error", err)
+				continue
+			}
+			if len(ids) == 0 {
+				continue
+			}
+			slog.Info("timed pause expired", "monitors", ids)
+			if err := c.Reload(); err != nil {
+				slog.Error("failed to reload monitors", "error", err)
+			}
+		}
+	}
+}
+
+// Stop aborts in-flight checks, stops all monitors and waits for their
+// goroutines to exit. It is safe to call more than once.
+func (c *Checker) Stop() {
+	c.stopOnce.Do(func() {
+		c.cancel()
+		close(c.stopChan)
+
+		c.mu.Lock()
+		for _, ms := range c.monitors {
+			if ms.ticker != nil {
+				ms.ticker.Stop()
+			}
+			close(ms.stopChan)
+		}
+		c.monitors = make(map[uint]*monitorState)
+		monitorsRunning.Set(0)
+		c.mu.Unlock()
+
+		c.clients.closeIdle()
+	})
 
 	c.wg.Wait()
+	c.flushPending()
 }
 
-func (c *Checker) startMonitor(m *storage.Monitor) {
+// stopped reports whether Stop has been called.
+func (c *Checker) stopped() bool {
+	return c.ctx.Err() != nil
+}
+
+// startMonitor runs m, first checking it at due or straight away if due
+// has passed.
+func (c *Checker) startMonitor(m *storage.Monitor, due time.Time) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.stopped() {
+		return
+	}
+
 	if ms, exists := c.monitors[m.ID]; exists {
 		if ms.ticker != nil {
 			ms.ticker.Stop()
@@ -88,17 +288,26 @@ func (c *Checker) startMonitor(m *storage.Monitor) {
 		close(ms.stopChan)
 	}
 
-	interval := time.Duration(m.CheckInterval) * time.Second
-	if interval < time.Second {
-		interval = time.Duration(config.DefaultCheckInterval) * time.Second
+	now := time.Now()
+	delay := scheduleDelay(m)
+	first := delay
+	if due.After(now) {
+		first = due.Sub(now)
+	} else {
+		due = now
 	}
-
 	ms := &monitorState{
-		monitor:  m,
-		ticker:   time.NewTicker(interval),
-		stopChan: make(chan struct{}),
+		monitor:   m,
+		ticker:    time.NewTicker(first),
+		stopChan:  make(chan struct{}),
+		checkNow:  make(chan struct{}, 1),
+		nextCheck: due,
+		delay:     delay,
+		status:    m.DisplayStatus(),
+		lastCheck: m.LastCheckAt,
 	}
 	c.monitors[m.ID] = ms
+	monitorsRunning.Set(int64(len(c.monitors)))
 
 	c.wg.Add(1)
 	go c.runMonitor(ms)
@@ -107,12 +316,22 @@ func (c *Checker) startMonitor(m *storage.Monitor) {
 func (c *Checker) runMonitor(ms *monitorState) {
 	defer c.wg.Done()
 
-	c.performCheck(ms.monitor)
+	c.mu.RLock()
+	waiting := time.Now().Before(ms.nextCheck)
+	c.mu.RUnlock()
+	if !waiting {
+		c.performCheck(ms.monitor)
+		c.reschedule(ms)
+	}
 
 	for {
 		select {
 		case <-ms.ticker.C:
 			c.performCheck(ms.monitor)
+			c.reschedule(ms)
+		case <-ms.checkNow:
+			c.performCheck(ms.monitor)
+			c.reschedule(ms)
 		case <-ms.stopChan:
 			return
 		case <-c.stopChan:
@@ -121,39 +340,347 @@ func (c *Checker) runMonitor(ms *monitorState) {
 	}
 }
 
+// reschedule sets the monitor's ticker to the delay its latest result
+// calls for.
+func (c *Checker) reschedule(ms *monitorState) {
+	delay := scheduleDelay(ms.monitor)
+	// Outside active hours, sleep until they start.
+	if sched := ms.monitor.ActiveSchedule(); sched != nil && !sched.Active(time.Now()) {
+		if next := sched.NextStart(time.Now()); !next.IsZero() {
+			delay = max(time.Until(next), time.Second)
+		}
+	}
+	ms.ticker.Reset(delay)
+
+	c.mu.Lock()
+	ms.nextCheck = time.Now().Add(delay)
+	ms.delay = delay
+	ms.status = ms.monitor.DisplayStatus()
+	ms.lastCheck = ms.monitor.LastCheckAt
+	c.mu.Unlock()
+}
+
+// restartDue is when a running monitor should first be checked after its
+// configuration changes to m. Edits that don't affect scheduling keep the
+// pending check, a new interval or timeout counts from the last check, and
+// a new target is checked straight away. ms must be read under c.mu.
+func restartDue(ms *monitorState, m *storage.Monitor, now time.Time) time.Time {
+	old := ms.monitor
+	switch {
+	case old.URL != m.URL || old.Type != m.Type:
+		return now
+	case sameSchedule(old, m):
+		return ms.nextCheck
+	}
+
+	// The running monitor has the latest check; m may have been loaded
+	// before it.
+	last := ms.lastCheck
+	if last == nil || m.LastCheckAt != nil && m.LastCheckAt.After(*last) {
+		last = m.LastCheckAt
+	}
+	if last == nil {
+		return now
+	}
+	return last.Add(scheduleDelay(m))
+}
+
+func sameSchedule(a, b *storage.Monitor) bool {
+	return a.CheckInterval == b.CheckInterval &&
+		a.Timeout == b.Timeout &&
+		a.Backoff == b.Backoff &&
+		a.ActiveHours == b.ActiveHours
+}
+
+// nextCheckAt is when m is next due, as shown to users: the next probe for
+// an HTTP monitor, the ping deadline for a heartbeat.
+func nextCheckAt(m *storage.Monitor, now time.Time) time.Time {
+	if m.IsHeartbeat() {
+		return heartbeatDeadline(m)
+	}
+	return now.Add(backoffInterval(m))
+}
+
+// scheduleDelay is how long to wait before checking m again.
+func scheduleDelay(m *storage.Monitor) time.Duration {
+	if m.IsHeartbeat() {
+		return min(monitorInterval(m), heartbeatPoll)
+	}
+	return backoffInterval(m)
+}
+
+// backoffInterval is m's check interval, doubled for every failure from
+// the BackoffThreshold-th on when backoff is enabled, and capped at
+// MaxBackoffFactor times the configured interval.
+func backoffInterval(m *storage.Monitor) time.Duration {
+	interval := monitorInterval(m)
+	if !m.Backoff || m.ConsecutiveFails < config.BackoffThreshold {
+		return interval
+	}
+
+	factor := 1
+	for i := config.BackoffThreshold; i <= m.ConsecutiveFails && factor < config.MaxBackoffFactor; i++ {
+		factor *= 2
+	}
+	return interval * time.Duration(min(factor, config.MaxBackoffFactor))
+}
+
+// detectGap records a MonitoringGap when m went more than twice its
+// expected interval without a check, not counting time outside its active
+// hours. It must run before m.LastCheckAt and m.ConsecutiveFails are
+// updated for the current check.
+func (c *Checker) detectGap(m *storage.Monitor, now time.Time) {
+	if m.IsHeartbeat() || m.LastCheckAt == nil {
+		return
+	}
+	expected := backoffInterval(m)
+	elapsed := now.Sub(*m.LastCheckAt)
+	if sched := m.ActiveSchedule(); sched != nil {
+		elapsed = sched.ActiveDuration(*m.LastCheckAt, now)
+	}
+	if elapsed <= 2*expected {
+		return
+	}
+
+	gap := &storage.MonitoringGap{
+		MonitorID: m.ID,
+		StartedAt: m.LastCheckAt.Add(expected),
+		EndedAt:   now,
+	}
+	slog.Info("monitoring gap", "monitor", m.Name, "id", m.ID, "from", gap.StartedAt, "duration", now.Sub(gap.StartedAt).Round(time.Second))
+	if err := c.db.CreateMonitoringGap(gap); err != nil {
+		slog.Error("failed to save monitoring gap", "monitor", m.Name, "id", m.ID, "error", err)
+	}
+}
+
+func monitorInterval(m *storage.Monitor) time.Duration {
+	interval := time.Duration(m.CheckInterval) * time.Second
+	if interval < time.Second {
+		interval = time.Duration(config.DefaultCheckInterval) * time.Second
+	}
+	return interval
+}
+
 func (c *Checker) performCheck(m *storage.Monitor) {
-	startTime := time.Now()
+	checksTotal.Inc()
+	defer checkDuration.Since(time.Now())
+
+	if sched := m.ActiveSchedule(); sched != nil && !sched.Active(time.Now()) {
+		c.outOfSchedule(m, sched)
+		return
+	}
+
+	if m.IsHeartbeat() {
+		c.checkHeartbeat(m)
+		return
+	}
+
+	p := c.probe(m)
+	switch {
+	case p.aborted:
+		// A check aborted by Stop says nothing about the site.
+		return
+	case p.proxy != "":
+		c.recordProxyFailure(m, p.proxy, p.err)
+		return
+	case p.authFailed:
+		c.recordAuthFailure(m, p.err)
+		return
+	case p.err != nil:
+		c.recordFailure(m, p.statusCode, p.conn, &responseSnapshot{body: p.body, header: p.header}, p.err)
+		return
+	}
+
+	var contentHash string
+	if m.WatchContent {
+		contentHash = c.watchContent(m, p.body)
+	}
+
+	c.recordSuccess(m, p.statusCode, p.responseTime, contentHash, p.conn)
+}
+
+// outOfSchedule marks m as outside its active hours. Any open incident is
+// closed, since nothing is watching the monitor until the hours start
+// again, and the failure count starts over then.
+func (c *Checker) outOfSchedule(m *storage.Monitor, sched *storage.Schedule) {
+	if m.CurrentStatus == storage.StatusOutOfSchedule {
+		return
+	}
+	now := time.Now()
+
+	for _, typ := range []string{storage.IncidentAvailability, storage.IncidentPerformance} {
+		if incident, err := c.db.GetActiveIncidentOfType(m.ID, typ); err == nil && incident != nil {
+			if err := c.db.ResolveIncident(incident, now, "active hours ended"); err != nil {
+				slog.Error("failed to resolve incident", "monitor", m.Name, "incident", incident.ID, "error", err)
+			}
+		}
+	}
+	if ms := c.ownState(m); ms != nil {
+		ms.latency = nil
+	}
+
+	c.setStatus(m, storage.StatusOutOfSchedule, nil, now)
+	m.ConsecutiveFails = 0
+	m.NextCheckAt = nil
+	if next := sched.NextStart(now); !next.IsZero() {
+		m.NextCheckAt = &next
+	}
+	slog.Info("outside active hours", "monitor", m.Name, "id", m.ID, "schedule", sched.String(), "next_check", m.NextCheckAt)
+	c.saveMonitor(m)
+}
+
+// probeResult is the outcome of one request to a monitor's URL. err is nil
+// when the status code and every keyword matched.
+type probeResult struct {
+	statusCode   int
+	responseTime int64
+	body         []byte
+	bodySHA256   string
+	header       http.Header
+	redirects    []string
+	conn         *connInfo
+	keywords     []KeywordMatch
+	err          error
+
+	// proxy names the proxy when err is a proxy failure.
+	proxy string
+	// authFailed is set when err is a failure to get an OAuth2 token.
+	authFailed bool
+	// aborted is set when Stop cancelled the request.
+	aborted bool
+}
+
+// KeywordMatch reports whether a monitor's keyword was found in its target
+// as often as required, and how often it was.
+type KeywordMatch struct {
+	Keyword string
+	Found   bool
+	Count   int
+}
+
+// probe requests m's URL and evaluates the response without recording
+// anything. A monitor checking both address families is probed over each
+// in turn and fails if either does.
+func (c *Checker) probe(m *storage.Monitor) probeResult {
+	opts := c.transportOptions(m)
+	if m.AddressFamily != storage.AddressFamilyBoth {
+		return c.probeOver(m, opts)
+	}
+
+	opts.network = "tcp4"
+	v4 := c.probeOver(m, opts)
+	if v4.aborted {
+		return v4
+	}
+	opts.network = "tcp6"
+	v6 := c.probeOver(m, opts)
+	if v6.aborted {
+		return v6
+	}
+
+	// Report the failing family; when both fail, IPv4's result stands for
+	// both since the cause is usually the same.
+	p := v4
+	switch {
+	case v4.err != nil && v6.err != nil:
+		p.err = fmt.Errorf("IPv4 and IPv6: %w", v4.err)
+	case v4.err != nil:
+		p.err = fmt.Errorf("IPv4: %w", v4.err)
+	case v6.err != nil:
+		p = v6
+		p.err = fmt.Errorf("IPv6: %w", v6.err)
+	default:
+		p.responseTime = max(v4.responseTime, v6.responseTime)
+	}
+	if v4.err == nil {
+		p.conn.ipv4Time = v4.responseTime
+	}
+	if v6.err == nil {
+		p.conn.ipv6Time = v6.responseTime
+	}
+	return p
+}
+
+// probeOver makes a single request to m's URL with opts.
+func (c *Checker) probeOver(m *storage.Monitor, opts transportOptions) probeResult {
+	if m.IsBanner() {
+		return c.probeBanner(m, opts)
+	}
+	if m.IsTCP() {
+		return c.probeTCP(m, opts)
+	}
+
+	p := probeResult{conn: &connInfo{}}
 
 	timeout := time.Duration(m.Timeout) * time.Second
 	if timeout == 0 {
 		timeout = time.Duration(config.DefaultTimeout) * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// The token is fetched before the clock starts, so its request isn't
+	// counted in the response time or phase timings.
+	var bearer string
+	if m.HasOAuth2() {
+		token, err := c.oauth2Token(m, opts, timeout)
+		if err != nil {
+			p.err = err
+			p.authFailed = true
+			p.aborted = c.stopped()
+			return p
+		}
+		bearer = token
+	}
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
 	defer cancel()
+	ctx = withDialLimits(ctx, connectTimeout(m))
+	ctx = httptrace.WithClientTrace(ctx, p.conn.trace())
+	ctx = withRedirectChain(ctx, &p.redirects)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", m.URL, nil)
 	if err != nil {
-		c.recordFailure(m, 0, err)
-		return
+		p.err = err
+		return p
 	}
 
-	req.Header.Set("User-Agent", "Statping/1.0")
+	req.Header.Set("User-Agent", c.userAgent(m))
+	if m.CheckHeader {
+		req.Header.Set("X-Statping-Check", strconv.FormatUint(uint64(m.ID), 10))
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.clients.get(opts).Do(req)
 	if err != nil {
-		c.recordFailure(m, 0, err)
-		return
+		p.err = err
+		p.aborted = c.stopped()
+		if isProxyError(err) {
+			p.proxy = proxyLabel(opts, req)
+		}
+		return p
 	}
 	defer resp.Body.Close()
+	p.conn.setTLS(resp.TLS)
+	p.conn.setProtocol(resp.Proto)
+	p.statusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusUnauthorized && bearer != "" {
+		// The token was revoked or rejected; get a new one next time.
+		c.tokens.forget(m.ID)
+	}
+	p.header = resp.Header
 
-	responseTime := time.Since(startTime).Milliseconds()
+	p.responseTime = time.Since(startTime).Milliseconds()
 
-	body, err := io.ReadAll(resp.Body)
+	p.body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		c.recordFailure(m, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err))
-		return
+		p.err = fmt.Errorf("failed to read response body: %w", err)
+		p.aborted = c.stopped()
+		return p
 	}
+	sum := sha256.Sum256(p.body)
+	p.bodySHA256 = hex.EncodeToString(sum[:])
 
 	expectedCodes := storage.ParseExpectedCodes(m.ExpectedCodes)
 	statusOK := false
@@ -165,27 +692,44 @@ func (c *Checker) performCheck(m *storage.Monitor) {
 	}
 
 	if !statusOK {
-		c.recordFailure(m, resp.StatusCode, fmt.Errorf("unexpected status code: got %d, expected one of %v", resp.StatusCode, expectedCodes))
-		return
+		p.err = fmt.Errorf("unexpected status code: got %d, expected %s", resp.StatusCode, formatCodes(m.ExpectedCodes))
+	} else if m.RequiredProto != "" && resp.Proto != m.RequiredProto {
+		p.err = fmt.Errorf("unexpected protocol: got %s, expected %s", resp.Proto, m.RequiredProto)
+	} else if m.MinBodyBytes > 0 && len(p.body) < m.MinBodyBytes {
+		p.err = fmt.Errorf("response too small: %d bytes < %d", len(p.body), m.MinBodyBytes)
 	}
 
-	keywords := storage.ParseKeywords(m.Keywords)
-	if len(keywords) > 0 {
-		bodyStr := string(body)
-		for _, keyword := range keywords {
-			pattern := "(?i)" + regexp.QuoteMeta(keyword)
-			matched, err := regexp.MatchString(pattern, bodyStr)
-			if err != nil || !matched {
-				c.recordFailure(m, resp.StatusCode, fmt.Errorf("keyword '%s' not found in response", keyword))
-				return
-			}
-		}
+	var missing error
+	p.keywords, missing = matchKeywords(m, p.body, p.header, p.redirects)
+	if p.err == nil {
+		p.err = missing
+	}
+	if p.err == nil && m.JSONSchema != "" {
+		p.err = c.validateJSON(m, p.body)
 	}
+	if p.err == nil && m.ExpectedSHA256 != "" && p.bodySHA256 != m.ExpectedSHA256 {
+		p.err = fmt.Errorf("body hash changed: got sha256 %s, expected %s", p.bodySHA256, m.ExpectedSHA256)
+	}
+
+	return p
+}
 
-	c.recordSuccess(m, resp.StatusCode, responseTime)
+// connectTimeout bounds connecting to m's host on its own, or is 0 to
+// leave that to the check's timeout.
+func connectTimeout(m *storage.Monitor) time.Duration {
+	return time.Duration(m.ConnectTimeout) * time.Second
 }
 
-func (c *Checker) recordSuccess(m *storage.Monitor, statusCode int, responseTime int64) {
+// userAgent returns the monitor's own User-Agent, falling back to the global
+// default from settings.
+func (c *Checker) userAgent(m *storage.Monitor) string {
+	if m.UserAgent != "" {
+		return m.UserAgent
+	}
+	return c.db.GetStringSetting(storage.SettingDefaultUserAgent, config.DefaultUserAgent)
+}
+
+func (c *Checker) recordSuccess(m *storage.Monitor, statusCode int, responseTime int64, contentHash string, conn *connInfo) {
 	now := time.Now()
 
 	result := &storage.CheckResult{
@@ -193,31 +737,64 @@ func (c *Checker) recordSuccess(m *storage.Monitor, statusCode int, responseTime
 		StatusCode:   statusCode,
 		ResponseTime: responseTime,
 		Success:      true,
+		ContentHash:  contentHash,
 		CreatedAt:    now,
 	}
-	c.db.CreateCheckResult(result)
+	conn.apply(result)
+	if !m.IsHeartbeat() {
+		result.Anomalous = c.evaluateAnomaly(m, now, responseTime)
+	}
+	slog.Debug("check succeeded", "monitor", m.Name, "id", m.ID, "status", statusCode, "response_ms", responseTime)
+	c.saveResult(m, result)
 
 	wasDown := m.CurrentStatus == "down"
-	m.CurrentStatus = "up"
+	restarted := !m.IsHeartbeat() && (m.LastCheckAt == nil || m.LastCheckAt.Before(c.started))
+	first := c.confirmFirstCheck(m)
+	c.detectGap(m, now)
+	c.setStatus(m, "up", result, now)
 	m.ConsecutiveFails = 0
 	m.LastCheckAt = &now
-	c.db.UpdateMonitor(m)
+	next := nextCheckAt(m, now)
+	m.NextCheckAt = &next
+	c.saveMonitor(m)
 
-	if wasDown {
+	if wasDown || restarted {
 		incident, err := c.db.GetActiveIncident(m.ID)
 		if err == nil && incident != nil {
-			c.db.ResolveIncident(incident.ID)
+			// An incident still open on the first check after a restart went
+			// unobserved; the site recovered at some point while nothing was
+			// checking, so close it at the restart rather than now.
+			resolvedAt, resolution := now, ""
+			if restarted {
+				resolvedAt, resolution = c.started, "resolved after monitoring gap"
+			}
+			slog.Info("monitor recovered", "monitor", m.Name, "id", m.ID, "downtime", resolvedAt.Sub(incident.StartedAt).Round(time.Second))
+			c.write("resolve incident", m, func() error { return c.db.ResolveIncident(incident, resolvedAt, resolution) })
 
 			if !incident.RecoveryNotified {
-				c.notifier.NotifyRecovery(m.Name, m.URL)
+				c.notifyRecovery(m, incident.ID, resolvedAt.Sub(incident.StartedAt))
 				incident.RecoveryNotified = true
-				c.db.UpdateIncident(incident)
+				c.write("update incident", m, func() error { return c.db.UpdateIncident(incident) })
 			}
 		}
 	}
+
+	if !m.IsHeartbeat() {
+		c.evaluateLatency(m, now, responseTime)
+	}
+
+	if first {
+		summary := fmt.Sprintf("%s is UP, %dms", m.URL, responseTime)
+		if m.IsHeartbeat() {
+			summary = "First ping received"
+		}
+		c.notifyFirstCheck(m, summary)
+	}
+
+	c.publish(m, result)
 }
 
-func (c *Checker) recordFailure(m *storage.Monitor, statusCode int, err error) {
+func (c *Checker) recordFailure(m *storage.Monitor, statusCode int, conn *connInfo, resp *responseSnapshot, err error) {
 	now := time.Now()
 
 	errorMsg := err.Error()
@@ -230,55 +807,286 @@ func (c *Checker) recordFailure(m *storage.Monitor, statusCode int, err error) {
 		ErrorMessage: errorMsg,
 		CreatedAt:    now,
 	}
-	c.db.CreateCheckResult(result)
+	conn.apply(result)
+	resp.apply(result)
+	slog.Debug("check failed", "monitor", m.Name, "id", m.ID, "status", statusCode, "error", errorMsg)
+	c.saveResult(m, result)
 
+	first := c.confirmFirstCheck(m)
+	c.detectGap(m, now)
 	m.ConsecutiveFails++
 	m.LastCheckAt = &now
+	next := nextCheckAt(m, now)
+	m.NextCheckAt = &next
+	if m.Backoff && m.ConsecutiveFails >= config.BackoffThreshold {
+		slog.Debug("backing off", "monitor", m.Name, "id", m.ID, "failures", m.ConsecutiveFails, "next_check", next)
+	}
 
-	if m.ConsecutiveFails >= config.DefaultMaxFailures {
+	threshold := failureThreshold(m)
+	if first {
+		// There is no history to smooth over yet, so alert right away.
+		threshold = 1
+	}
+	if m.ConsecutiveFails >= threshold {
 		wasUp := m.CurrentStatus != "down"
-		m.CurrentStatus = "down"
+		c.setStatus(m, "down", result, now)
 
 		if wasUp {
+			slog.Info("monitor down", "monitor", m.Name, "id", m.ID, "failures", m.ConsecutiveFails, "error", errorMsg)
+
 			incident := &storage.Incident{
 				MonitorID:    m.ID,
 				StartedAt:    now,
 				ErrorMessage: errorMsg,
 			}
-			c.db.CreateIncident(incident)
-
-			c.mu.Lock()
-			ms := c.monitors[m.ID]
-			if ms != nil {
-				if time.Since(ms.lastNotified).Seconds() >= config.NotificationCooldown {
-					c.notifier.NotifyDown(m.Name, m.URL, errorMsg)
-					ms.lastNotified = now
-				}
+			c.write("create incident", m, func() error { return c.db.CreateIncident(incident) })
+
+			if c.claimNotification(m, now, false) {
+				c.notifyDown(m, incident.ID, errorMsg)
 			}
-			c.mu.Unlock()
 		} else {
 			incident, err := c.db.GetActiveIncident(m.ID)
 			if err == nil && incident != nil {
 				incident.ErrorMessage = errorMsg
-				c.db.UpdateIncident(incident)
+				c.write("update incident", m, func() error { return c.db.UpdateIncident(incident) })
 
-				c.mu.Lock()
-				ms := c.monitors[m.ID]
-				if ms != nil && time.Since(ms.lastNotified).Seconds() >= config.NotificationCooldown {
-					c.notifier.NotifyDown(m.Name, m.URL, errorMsg)
-					ms.lastNotified = now
+				if c.claimNotification(m, now, true) {
+					c.notifyDown(m, incident.ID, errorMsg)
 				}
-				c.mu.Unlock()
 			}
 		}
 	}
 
-	c.db.UpdateMonitor(m)
+	c.saveMonitor(m)
+
+	c.publish(m, result)
+}
+
+// confirmFirstCheck reports whether m has never been checked and the
+// notify-first-check setting asks for its first result to be announced.
+// It must run before m.LastCheckAt is set for the current check.
+func (c *Checker) confirmFirstCheck(m *storage.Monitor) bool {
+	return m.LastCheckAt == nil && c.db.GetBoolSetting(storage.SettingNotifyFirstCheck, false)
+}
+
+// failureThreshold is how many consecutive failures mark m down. A missed
+// heartbeat is already a confirmed failure, so it counts immediately.
+func failureThreshold(m *storage.Monitor) int {
+	if m.IsHeartbeat() {
+		return 1
+	}
+	if m.MaxFailures > 0 {
+		return m.MaxFailures
+	}
+	return config.DefaultMaxFailures
+}
+
+func formatCodes(codes string) string {
+	if codes == "" {
+		return "200"
+	}
+	return codes
+}
+
+func (c *Checker) publish(m *storage.Monitor, result *storage.CheckResult) {
+	c.mu.RLock()
+	handlers := c.handlers
+	c.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(*m, *result)
+	}
+}
+
+// Subscribe registers fn to be called after every check. Handlers run on the
+// monitor's check goroutine, so they should return quickly.
+func (c *Checker) Subscribe(fn ResultHandler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handlers = append(c.handlers, fn)
+}
+
+// CheckOnce checks m synchronously and records the result. It does not
+// require Start, so the CLI can use it when no daemon is running.
+func (c *Checker) CheckOnce(m *storage.Monitor) {
+	c.performCheck(m)
+}
+
+// TestResult is the outcome of Test. Err is nil when the check would pass.
+type TestResult struct {
+	StatusCode   int
+	ResponseTime int64
+	Keywords     []KeywordMatch
+	ResolvedIP   string
+	TLSVersion   string
+	Protocol     string
+	Err          error
+
+	// BodySHA256 is the hex SHA-256 of the whole response body.
+	BodySHA256 string
+	// Banner is the first line a banner monitor's server sent.
+	Banner string
+	// PortResults has each port's outcome for a TCP monitor.
+	PortResults []string
+
+	// IPv4Time and IPv6Time are set for monitors checking both families.
+	IPv4Time, IPv6Time int64
+}
+
+// Test runs a single check against m without recording or notifying, so a
+// monitor can be verified before it is saved or a URL watched without
+// saving it. Heartbeat monitors can't be
+// tested since they wait to be pinged.
+func (c *Checker) Test(m *storage.Monitor) TestResult {
+	if m.IsHeartbeat() {
+		return TestResult{Err: fmt.Errorf("heartbeat monitors can't be tested")}
+	}
+	p := c.probe(m)
+	var r storage.CheckResult
+	p.conn.apply(&r)
+	var banner string
+	if m.IsBanner() {
+		banner = string(p.body)
+	}
+	var ports []string
+	if r.PortResults != "" {
+		ports = strings.Split(r.PortResults, "\n")
+	}
+	if p.authFailed {
+		p.err = fmt.Errorf("auth failure: %w", p.err)
+	}
+	return TestResult{
+		StatusCode:   p.statusCode,
+		ResponseTime: p.responseTime,
+		Keywords:     p.keywords,
+		ResolvedIP:   r.ResolvedIP,
+		TLSVersion:   r.TLSVersion,
+		Protocol:     r.Protocol,
+		Err:          p.err,
+		BodySHA256:   p.bodySHA256,
+		Banner:       banner,
+		PortResults:  ports,
+		IPv4Time:     r.IPv4Time,
+		IPv6Time:     r.IPv6Time,
+	}
+}
+
+// CheckAll triggers an immediate check of every running monitor.
+func (c *Checker) CheckAll() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, ms := range c.monitors {
+		select {
+		case ms.checkNow <- struct{}{}:
+		default:
+			checksSkipped.Inc()
+		}
+	}
+}
+
+// CheckMonitor triggers an immediate check of a single running monitor.
+func (c *Checker) CheckMonitor(id uint) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if ms, exists := c.monitors[id]; exists {
+		select {
+		case ms.checkNow <- struct{}{}:
+		default:
+			checksSkipped.Inc()
+		}
+	}
+}
+
+// Reload synchronizes the running monitors with the database: new or
+// re-enabled monitors are started, removed or disabled ones are stopped and
+// monitors whose configuration changed are restarted.
+func (c *Checker) Reload() error {
+	monitors, err := c.db.ListEnabledMonitors()
+	if err != nil {
+		return fmt.Errorf("failed to load monitors: %w", err)
+	}
+
+	monitors = c.filter.Apply(monitors)
+	enabled := make(map[uint]bool, len(monitors))
+	for _, m := range monitors {
+		monitor := m
+		enabled[monitor.ID] = true
+
+		due := time.Now()
+		c.mu.RLock()
+		ms, exists := c.monitors[monitor.ID]
+		unchanged := exists && sameConfig(ms.monitor, &monitor)
+		if exists && !unchanged {
+			due = restartDue(ms, &monitor, due)
+		}
+		c.mu.RUnlock()
+
+		if !unchanged {
+			c.startMonitor(&monitor, due)
+		}
+	}
+
+	c.mu.RLock()
+	var stale []uint
+	for id := range c.monitors {
+		if !enabled[id] {
+			stale = append(stale, id)
+		}
+	}
+	c.mu.RUnlock()
+
+	for _, id := range stale {
+		c.RemoveMonitor(id)
+	}
+
+	return nil
+}
+
+func sameConfig(a, b *storage.Monitor) bool {
+	return a.Name == b.Name &&
+		a.URL == b.URL &&
+		a.CheckInterval == b.CheckInterval &&
+		a.Timeout == b.Timeout &&
+		a.ConnectTimeout == b.ConnectTimeout &&
+		a.ExpectedCodes == b.ExpectedCodes &&
+		a.Type == b.Type &&
+		a.GracePeriod == b.GracePeriod &&
+		a.Backoff == b.Backoff &&
+		a.ActiveHours == b.ActiveHours &&
+		a.LatencyThreshold == b.LatencyThreshold &&
+		a.LatencyWindow == b.LatencyWindow &&
+		a.LatencyAgg == b.LatencyAgg &&
+		a.MaxFailures == b.MaxFailures &&
+		a.NotificationCooldown == b.NotificationCooldown &&
+		a.Channels == b.Channels &&
+		a.OpsGeniePriority == b.OpsGeniePriority &&
+		a.Tags == b.Tags &&
+		a.Keywords == b.Keywords &&
+		a.MinBodyBytes == b.MinBodyBytes &&
+		a.ExpectedSHA256 == b.ExpectedSHA256 &&
+		a.ExpectedBanner == b.ExpectedBanner &&
+		a.JSONSchema == b.JSONSchema &&
+		a.UserAgent == b.UserAgent &&
+		a.CheckHeader == b.CheckHeader &&
+		a.DisableKeepAlive == b.DisableKeepAlive &&
+		a.HTTPVersion == b.HTTPVersion &&
+		a.RequiredProto == b.RequiredProto &&
+		a.AddressFamily == b.AddressFamily &&
+		a.SkipTLSVerify == b.SkipTLSVerify &&
+		a.Proxy == b.Proxy &&
+		a.OAuth2TokenURL == b.OAuth2TokenURL &&
+		a.OAuth2ClientID == b.OAuth2ClientID &&
+		a.OAuth2ClientSecret == b.OAuth2ClientSecret &&
+		a.OAuth2Scopes == b.OAuth2Scopes &&
+		a.WatchContent == b.WatchContent &&
+		a.IgnorePatterns == b.IgnorePatterns
 }
 
 func (c *Checker) AddMonitor(m *storage.Monitor) {
-	if m.Enabled {
-		c.startMonitor(m)
+	if m.Enabled && c.filter.Match(m) {
+		c.startMonitor(m, time.Now())
 	}
 }
 
@@ -292,23 +1100,43 @@ func (c *Checker) RemoveMonitor(id uint) {
 		}
 		close(ms.stopChan)
 		delete(c.monitors, id)
+		monitorsRunning.Set(int64(len(c.monitors)))
 	}
+	c.schemas.forget(id)
+	c.tokens.forget(id)
+	c.closeNotifications(id)
 }
 
+// UpdateMonitor restarts m with its new configuration, keeping its place
+// in the schedule as far as the change allows (see restartDue).
 func (c *Checker) UpdateMonitor(m *storage.Monitor) {
-	c.RemoveMonitor(m.ID)
-	if m.Enabled {
-		c.startMonitor(m)
+	if !m.Enabled || !c.filter.Match(m) {
+		c.RemoveMonitor(m.ID)
+		return
+	}
+
+	due := time.Now()
+	c.mu.RLock()
+	if ms, exists := c.monitors[m.ID]; exists {
+		due = restartDue(ms, m, due)
 	}
+	c.mu.RUnlock()
+
+	c.schemas.forget(m.ID)
+	c.startMonitor(m, due)
 }
 
-func (c *Checker) GetStatus() map[uint]string {
+func (c *Checker) GetStatus() map[uint]MonitorStatus {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	status := make(map[uint]string)
+	status := make(map[uint]MonitorStatus)
 	for id, ms := range c.monitors {
-		status[id] = ms.monitor.CurrentStatus
+		status[id] = MonitorStatus{
+			Status:    ms.status,
+			NextCheck: ms.nextCheck,
+			Delay:     ms.delay,
+		}
 	}
 	return status
 }
@@ -0,0 +1,405 @@
+package checker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// newTestDB opens an empty in-memory database.
+func newTestDB(t *testing.T) *storage.Database {
+	t.Helper()
+	db, err := storage.New(storage.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// mustCreateMonitor creates an enabled HTTP monitor of url.
+func mustCreateMonitor(t *testing.T, db *storage.Database, url string, opts ...func(*storage.Monitor)) *storage.Monitor {
+	t.Helper()
+	m := &storage.Monitor{
+		Name:          url,
+		Type:          storage.MonitorTypeHTTP,
+		URL:           url,
+		Enabled:       true,
+		Public:        true,
+		CheckInterval: 60,
+		Timeout:       10,
+		ExpectedCodes: "200",
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if err := db.CreateMonitor(m); err != nil {
+		t.Fatalf("create monitor: %v", err)
+	}
+	return m
+}
+
+// hungServer starts a server whose handler blocks until the client gives
+// up. entered receives a value each time a request arrives.
+func hungServer(t *testing.T) (srv *httptest.Server, entered chan struct{}) {
+	t.Helper()
+	entered = make(chan struct{}, 16)
+	release := make(chan struct{})
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv, entered
+}
+
+func TestStopTwice(t *testing.T) {
+	db := newTestDB(t)
+	mustCreateMonitor(t, db, "http://127.0.0.1:1/")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New(db, nil)
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cancelling the context stops the checker too, as when the TUI exits
+	// and main then calls Stop itself.
+	cancel()
+	c.Stop()
+	c.Stop()
+
+	c.AddMonitor(mustCreateMonitor(t, db, "http://127.0.0.1:2/"))
+	if n := len(c.GetStatus()); n != 0 {
+		t.Errorf("%d monitors running after Stop, want 0", n)
+	}
+}
+
+func TestStopAbortsHungCheck(t *testing.T) {
+	srv, entered := hungServer(t)
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, srv.URL, func(m *storage.Monitor) { m.Timeout = 30 })
+
+	c := New(db, nil)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		c.Stop()
+		t.Fatal("the check never reached the server")
+	}
+
+	start := time.Now()
+	c.Stop()
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Stop took %s with a check in flight, want it aborted", elapsed)
+	}
+
+	results, err := db.GetRecentCheckResults(m.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("aborted check recorded %d results, want none", len(results))
+	}
+}
+
+func TestNotifySlowNotifier(t *testing.T) {
+	c := New(newTestDB(t), nil)
+
+	// Monitor 1's notifier hangs, as a desktop notification daemon that
+	// never answers would.
+	release := make(chan struct{})
+	var sent atomic.Int32
+	slow := func() {
+		<-release
+		sent.Add(1)
+	}
+	c.notify(1, slow)
+	before := runtime.NumGoroutine()
+	const alerts = 3 * notificationQueue
+	for range alerts - 1 {
+		c.notify(1, slow)
+	}
+	if grown := runtime.NumGoroutine() - before; grown > 0 {
+		t.Errorf("%d goroutines started for alerts that didn't fit the queue", grown)
+	}
+
+	// Other monitors' alerts aren't held up behind it.
+	done := make(chan struct{})
+	c.notify(2, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("another monitor's alert waited for the slow one")
+	}
+
+	close(release)
+	deadline := time.Now().Add(2 * time.Second)
+	for sent.Load() < notificationQueue && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	// The worker holds one alert and the queue the next notificationQueue;
+	// the rest are dropped.
+	if n := sent.Load(); n < notificationQueue || n > notificationQueue+1 {
+		t.Errorf("%d of %d alerts sent, want the %d that fit the queue", n, alerts, notificationQueue)
+	}
+}
+
+// TestRemoveMonitorEndsNotifications expects a removed monitor's queued
+// alerts to go out and its worker to exit, and alerts racing the removal
+// not to panic on the closed queue.
+func TestRemoveMonitorEndsNotifications(t *testing.T) {
+	c := New(newTestDB(t), nil)
+	defer c.Stop()
+
+	before := runtime.NumGoroutine()
+	release := make(chan struct{})
+	var sent atomic.Int32
+	for range 5 {
+		c.notify(1, func() {
+			<-release
+			sent.Add(1)
+		})
+	}
+	c.RemoveMonitor(1)
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for (sent.Load() < 5 || runtime.NumGoroutine() > before) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := sent.Load(); n != 5 {
+		t.Errorf("%d of 5 alerts queued before the removal sent", n)
+	}
+	if grown := runtime.NumGoroutine() - before; grown > 0 {
+		t.Errorf("%d notification workers left running after the monitor was removed", grown)
+	}
+	c.notifyMu.Lock()
+	_, queued := c.notifications[1]
+	c.notifyMu.Unlock()
+	if queued {
+		t.Error("removed monitor still has a notification queue")
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if i%2 == 0 {
+					c.notify(2, func() { sent.Add(1) })
+				} else {
+					c.RemoveMonitor(2)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	c.RemoveMonitor(2)
+}
+
+func TestReloadKeepsSchedule(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, srv.URL)
+	c := New(db, nil)
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	// nextCheck waits for the first check and returns when the one after
+	// it is due.
+	nextCheck := func() time.Time {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if s, ok := c.GetStatus()[m.ID]; ok && s.NextCheck.After(time.Now().Add(10*time.Second)) {
+				return s.NextCheck
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("the first check never finished")
+		return time.Time{}
+	}
+	edit := func(change func(*storage.Monitor)) time.Time {
+		t.Helper()
+		saved, err := db.GetMonitor(m.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		change(saved)
+		if err := db.UpdateMonitor(saved); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Reload(); err != nil {
+			t.Fatal(err)
+		}
+		return c.GetStatus()[m.ID].NextCheck
+	}
+
+	due := nextCheck()
+	lastCheck := due.Add(-60 * time.Second)
+
+	if got := edit(func(m *storage.Monitor) { m.Name = "renamed" }); !got.Equal(due) {
+		t.Errorf("renaming moved the next check from %s to %s", due, got)
+	}
+
+	// A new interval counts from the last check, give or take the time
+	// the check took to record.
+	near := func(got, want time.Time) bool {
+		return got.Sub(want).Abs() < time.Second
+	}
+	if got := edit(func(m *storage.Monitor) { m.CheckInterval = 30 }); !near(got, lastCheck.Add(30*time.Second)) {
+		t.Errorf("shortening the interval to 30s: next check %s after the last, want 30s", got.Sub(lastCheck))
+	}
+	if got := edit(func(m *storage.Monitor) { m.CheckInterval = 120 }); !near(got, lastCheck.Add(120*time.Second)) {
+		t.Errorf("lengthening the interval to 120s: next check %s after the last, want 120s", got.Sub(lastCheck))
+	}
+
+	// Alerts are sent from the running copy of the monitor, so edits to
+	// what they carry reach it without changing the schedule.
+	due = lastCheck.Add(120 * time.Second)
+	for _, tc := range []struct {
+		name   string
+		change func(*storage.Monitor)
+		got    func(*storage.Monitor) string
+		want   string
+	}{
+		{"OpsGenie priority", func(m *storage.Monitor) { m.OpsGeniePriority = "P1" }, func(m *storage.Monitor) string { return m.OpsGeniePriority }, "P1"},
+		{"tags", func(m *storage.Monitor) { m.Tags = "prod,payments" }, func(m *storage.Monitor) string { return m.Tags }, "prod,payments"},
+	} {
+		if got := edit(tc.change); !near(got, due) {
+			t.Errorf("changing the %s moved the next check from %s to %s", tc.name, due, got)
+		}
+		c.mu.RLock()
+		running := tc.got(c.monitors[m.ID].monitor)
+		c.mu.RUnlock()
+		if running != tc.want {
+			t.Errorf("running monitor's %s = %q after reloading, want %q", tc.name, running, tc.want)
+		}
+	}
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("%d checks, want only the first; reloading must not check again", n)
+	}
+}
+
+func TestTimeoutOutlastsConnectTimeout(t *testing.T) {
+	// Scaled down from a 45s timeout with a 5s connect timeout: the
+	// response starts at once and its body takes longer than the connect
+	// timeout, but less than the check's.
+	const (
+		connect = 1
+		timeout = 3
+		slow    = 2 * time.Second
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("part one, "))
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(slow):
+			w.Write([]byte("part two"))
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, srv.URL, func(m *storage.Monitor) {
+		m.ConnectTimeout = connect
+		m.Timeout = timeout
+		m.Keywords = "part two"
+	})
+	c := New(db, nil)
+	defer c.Stop()
+
+	// Nothing caps the request short of the monitor's own timeout.
+	if limit := c.clients.get(c.transportOptions(m)).Timeout; limit != 0 {
+		t.Errorf("check client has a %s timeout of its own", limit)
+	}
+
+	p := c.probe(m)
+	if p.err != nil {
+		t.Fatalf("check within its %ds timeout failed: %v", timeout, p.err)
+	}
+	if string(p.body) != "part one, part two" {
+		t.Errorf("body = %q, want all of it", p.body)
+	}
+
+	// A response slower than the timeout still fails, once it has run out.
+	m.Timeout = 1
+	start := time.Now()
+	if p := c.probe(m); p.err == nil {
+		t.Error("check outlasting its timeout succeeded")
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > slow {
+		t.Errorf("check with a 1s timeout gave up after %s", elapsed)
+	}
+}
+
+func TestFilterLimitsChecks(t *testing.T) {
+	db := newTestDB(t)
+	inScope := mustCreateMonitor(t, db, "http://127.0.0.1:1/", func(m *storage.Monitor) { m.Tags = "prod" })
+	outOfScope := mustCreateMonitor(t, db, "http://127.0.0.1:2/", func(m *storage.Monitor) { m.Tags = "dev" })
+
+	c := New(db, nil)
+	c.SetFilter(storage.MonitorFilter{Tags: []string{"prod"}})
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	running := func() []uint {
+		var ids []uint
+		for id := range c.GetStatus() {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	if ids := running(); len(ids) != 1 || ids[0] != inScope.ID {
+		t.Fatalf("running %v, want only monitor %d", ids, inScope.ID)
+	}
+
+	c.AddMonitor(mustCreateMonitor(t, db, "http://127.0.0.1:3/"))
+	c.UpdateMonitor(outOfScope)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if ids := running(); len(ids) != 1 || ids[0] != inScope.ID {
+		t.Errorf("running %v after adding, updating and reloading monitors outside the filter", ids)
+	}
+
+	// Retagging a monitor out of the filter stops it.
+	inScope.Tags = "dev"
+	c.UpdateMonitor(inScope)
+	if ids := running(); len(ids) != 0 {
+		t.Errorf("running %v after retagging, want none", ids)
+	}
+}
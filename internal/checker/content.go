@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// watchContent hashes the normalized body of a successful check and records
+// a ContentChange when it differs from the previous check's. It returns the
+// hash to store on the check result.
+func (c *Checker) watchContent(m *storage.Monitor, body []byte) string {
+	ignore, err := storage.ParseIgnorePatterns(m.IgnorePatterns)
+	if err != nil {
+		slog.Warn("skipping invalid ignore patterns", "monitor", m.Name, "id", m.ID, "error", err)
+		ignore = nil
+	}
+
+	content := normalizeContent(string(body), ignore)
+	hash := hashContent(content)
+
+	prev, err := c.db.GetContentSnapshot(m.ID)
+	if err != nil {
+		slog.Error("failed to load content snapshot", "monitor", m.Name, "id", m.ID, "error", err)
+		return hash
+	}
+	if prev != nil && prev.Hash == hash {
+		return hash
+	}
+
+	if prev != nil {
+		changed, summary := diffSummary(prev.Body, content)
+		change := &storage.ContentChange{
+			MonitorID:    m.ID,
+			OldHash:      prev.Hash,
+			NewHash:      hash,
+			BytesChanged: changed,
+			Summary:      summary,
+		}
+		if err := c.db.CreateContentChange(change); err != nil {
+			slog.Error("failed to record content change", "monitor", m.Name, "id", m.ID, "error", err)
+		}
+		slog.Info("content changed", "monitor", m.Name, "id", m.ID, "summary", summary)
+		name, url := m.Name, m.URL
+		c.notify(m.ID, func() { c.notifier.NotifyContentChange(name, url, summary) })
+	}
+
+	snapshot := &storage.ContentSnapshot{MonitorID: m.ID, Hash: hash, Body: content}
+	if err := c.db.SaveContentSnapshot(snapshot); err != nil {
+		slog.Error("failed to save content snapshot", "monitor", m.Name, "id", m.ID, "error", err)
+	}
+	return hash
+}
+
+// normalizeContent strips the ignored regions and the whitespace noise that
+// doesn't change what a page says: line endings, trailing spaces and blank
+// lines.
+func normalizeContent(body string, ignore []*regexp.Regexp) string {
+	for _, re := range ignore {
+		body = re.ReplaceAllString(body, "")
+	}
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+
+	lines := strings.Split(body, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffSummary returns how many bytes differ between old and new, ignoring
+// their common prefix and suffix, and a one-line description of the first
+// line that changed.
+func diffSummary(old, new string) (int, string) {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix &&
+		old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	changed := max(len(old), len(new)) - prefix - suffix
+
+	oldLines := strings.Split(old, "\n")
+	newLines := strings.Split(new, "\n")
+	line := 0
+	for line < len(oldLines) && line < len(newLines) && oldLines[line] == newLines[line] {
+		line++
+	}
+
+	var where string
+	if line < len(newLines) {
+		where = fmt.Sprintf("line %d now reads %q", line+1, shorten(strings.TrimSpace(newLines[line]), 80))
+	} else {
+		where = fmt.Sprintf("lines from %d removed", line+1)
+	}
+	return changed, fmt.Sprintf("%d bytes changed, %s", changed, where)
+}
+
+func shorten(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,87 @@
+package checker
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// heartbeatPoll caps how often an overdue heartbeat can go unnoticed,
+// however long the monitor's interval is.
+const heartbeatPoll = 30 * time.Second
+
+// RecordHeartbeat records a ping from a heartbeat monitor's job and brings
+// the monitor back up if it was down. duration is the run time the job
+// reported, in milliseconds, or 0.
+func (c *Checker) RecordHeartbeat(m *storage.Monitor, duration int64) {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+
+	now := time.Now()
+	m.LastPingAt = &now
+	slog.Debug("heartbeat received", "monitor", m.Name, "id", m.ID, "duration_ms", duration)
+
+	// A ping outside active hours is noted but not counted as a check.
+	if sched := m.ActiveSchedule(); sched != nil && !sched.Active(now) {
+		c.saveMonitor(m)
+		return
+	}
+	c.recordSuccess(m, 0, duration, "", nil)
+}
+
+// checkHeartbeat marks a heartbeat monitor down once its ping is overdue.
+// The monitor is re-read first because pings may be recorded by another
+// process, e.g. `statping serve` while the daemon runs the schedule.
+func (c *Checker) checkHeartbeat(m *storage.Monitor) {
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+
+	fresh, err := c.db.GetMonitor(m.ID)
+	if err != nil {
+		slog.Error("failed to load heartbeat monitor", "monitor", m.Name, "id", m.ID, "error", err)
+		return
+	}
+	*m = *fresh
+
+	now := time.Now()
+	if now.Before(heartbeatDeadline(m)) {
+		return
+	}
+
+	// While overdue, record one failure per interval so uptime keeps
+	// counting the monitor as down.
+	if m.CurrentStatus == "down" && m.LastCheckAt != nil && now.Sub(*m.LastCheckAt) < monitorInterval(m) {
+		return
+	}
+
+	since := "since the monitor was created"
+	if m.LastPingAt != nil {
+		since = "since " + m.LastPingAt.Format("2006-01-02 15:04:05")
+	}
+	c.recordFailure(m, 0, nil, nil, fmt.Errorf("no ping received %s", since))
+}
+
+// heartbeatDeadline is when a heartbeat monitor's next ping is due at the
+// latest.
+func heartbeatDeadline(m *storage.Monitor) time.Time {
+	last := m.CreatedAt
+	if m.LastPingAt != nil {
+		last = *m.LastPingAt
+	}
+	// Pings aren't expected outside active hours, so the clock restarts
+	// when they begin.
+	if sched := m.ActiveSchedule(); sched != nil {
+		if start := sched.CurrentStart(time.Now()); start.After(last) {
+			last = start
+		}
+	}
+
+	grace := time.Duration(m.GracePeriod) * time.Second
+	if grace <= 0 {
+		grace = config.DefaultHeartbeatGrace * time.Second
+	}
+	return last.Add(monitorInterval(m) + grace)
+}
@@ -0,0 +1,110 @@
+package checker
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankityadav/statping/internal/jsonschema"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// schemaErrors is how many validation errors a failed check reports.
+const schemaErrors = 3
+
+// schemaCache holds each monitor's compiled JSON schema, so it is compiled
+// when the monitor or its schema file changes rather than on every check.
+type schemaCache struct {
+	mu      sync.Mutex
+	entries map[uint]*schemaEntry
+}
+
+type schemaEntry struct {
+	source  string
+	modTime time.Time // of the schema file, if there is one
+	schema  *jsonschema.Schema
+	err     error
+
+	// warned is set once the monitor has been warned about a response
+	// that isn't JSON, until a JSON response comes back.
+	warned bool
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{entries: make(map[uint]*schemaEntry)}
+}
+
+// get returns m's compiled schema entry, compiling it if the monitor's
+// schema or the file it names changed since the last call.
+func (sc *schemaCache) get(m *storage.Monitor) *schemaEntry {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	e := sc.entries[m.ID]
+	if e != nil && e.source == m.JSONSchema {
+		_, path, _ := storage.JSONSchemaSource(m.JSONSchema)
+		if path == "" {
+			return e
+		}
+		if info, err := os.Stat(path); err == nil && info.ModTime().Equal(e.modTime) {
+			return e
+		}
+	}
+
+	e = &schemaEntry{source: m.JSONSchema}
+	data, path, err := storage.JSONSchemaSource(m.JSONSchema)
+	if err == nil && path != "" {
+		if info, statErr := os.Stat(path); statErr == nil {
+			e.modTime = info.ModTime()
+		}
+	}
+	if err == nil {
+		e.schema, err = jsonschema.Compile(data)
+	}
+	e.err = err
+	sc.entries[m.ID] = e
+	return e
+}
+
+func (sc *schemaCache) forget(id uint) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.entries, id)
+}
+
+// validateJSON checks body against m's JSON schema. A response that isn't
+// JSON is not validated; the monitor is warned about once.
+func (c *Checker) validateJSON(m *storage.Monitor, body []byte) error {
+	e := c.schemas.get(m)
+	if e.err != nil {
+		return fmt.Errorf("JSON schema unusable: %w", e.err)
+	}
+
+	errs, err := e.schema.Validate(body, schemaErrors+1)
+	c.schemas.mu.Lock()
+	defer c.schemas.mu.Unlock()
+	if err != nil {
+		if !e.warned {
+			slog.Warn("response is not JSON, skipping schema validation", "monitor", m.Name, "id", m.ID, "error", err)
+			e.warned = true
+		}
+		return nil
+	}
+	e.warned = false
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var msgs []string
+	for i, verr := range errs {
+		if i == schemaErrors {
+			msgs = append(msgs, "…")
+			break
+		}
+		msgs = append(msgs, verr.Error())
+	}
+	return fmt.Errorf("response does not match JSON schema: %s", strings.Join(msgs, "; "))
+}
@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+type redirectChainKey struct{}
+
+// withRedirectChain makes the client record every redirect the request
+// follows in chain, in order.
+func withRedirectChain(ctx context.Context, chain *[]string) context.Context {
+	return context.WithValue(ctx, redirectChainKey{}, chain)
+}
+
+// checkRedirect records the redirect into the request's chain and keeps
+// the client's default limit of ten redirects.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return fmt.Errorf("stopped after 10 redirects")
+	}
+	if chain, ok := req.Context().Value(redirectChainKey{}).(*[]string); ok {
+		*chain = append(*chain, req.URL.String())
+	}
+	return nil
+}
+
+// matchKeywords counts each of m's keywords in its target and returns the
+// matches with an error naming the first keyword that is missing or found
+// fewer times than its minimum.
+func matchKeywords(m *storage.Monitor, body []byte, header http.Header, redirects []string) ([]KeywordMatch, error) {
+	// Validated when saved; an unparsable list is checked as plain keywords.
+	rules, err := storage.ParseKeywordRules(m.Keywords)
+	if err != nil {
+		rules = nil
+		for _, k := range storage.ParseKeywords(m.Keywords) {
+			rules = append(rules, storage.KeywordRule{Target: storage.KeywordTargetBody, Text: k})
+		}
+	}
+
+	var matches []KeywordMatch
+	var missing error
+	for _, rule := range rules {
+		var count int
+		var where string
+		switch rule.Target {
+		case storage.KeywordTargetHeaders:
+			count = countAll(rule, headerLines(header)...)
+			where = "response headers"
+		case storage.KeywordTargetRedirects:
+			if rule.Hop > 0 {
+				if rule.Hop <= len(redirects) {
+					count = countAll(rule, redirects[rule.Hop-1])
+					where = fmt.Sprintf("redirect hop %d (%s)", rule.Hop, redirects[rule.Hop-1])
+				} else {
+					where = fmt.Sprintf("redirect hop %d (only %d redirects)", rule.Hop, len(redirects))
+				}
+			} else {
+				count = countAll(rule, redirects...)
+				where = "redirect chain"
+				if len(redirects) == 0 {
+					where += " (no redirects)"
+				}
+			}
+		default:
+			count = countAll(rule, string(body))
+			where = "response"
+		}
+		found := count >= max(rule.Min, 1)
+		matches = append(matches, KeywordMatch{Keyword: rule.String(), Found: found, Count: count})
+		if found || missing != nil {
+			continue
+		}
+		what := fmt.Sprintf("keyword '%s'", rule.Text)
+		if rule.Pattern != nil {
+			what = fmt.Sprintf("pattern '%s'", rule.Text)
+		}
+		if rule.Min > 0 {
+			missing = fmt.Errorf("%s in %s: found %d, expected >= %d", what, where, count, rule.Min)
+		} else {
+			missing = fmt.Errorf("%s not found in %s", what, where)
+		}
+	}
+	return matches, missing
+}
+
+// headerLines renders each header value as "Name: value", so a keyword
+// can match a header's name together with the start of its value.
+func headerLines(header http.Header) []string {
+	var lines []string
+	for name, values := range header {
+		for _, v := range values {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return lines
+}
+
+// countAll returns the number of non-overlapping, case-insensitive matches
+// of rule's text or pattern across values.
+func countAll(rule storage.KeywordRule, values ...string) int {
+	var n int
+	for _, v := range values {
+		if rule.Pattern != nil {
+			n += len(rule.Pattern.FindAllStringIndex(v, -1))
+		} else {
+			n += strings.Count(strings.ToLower(v), strings.ToLower(rule.Text))
+		}
+	}
+	return n
+}
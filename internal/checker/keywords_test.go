@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// TestKeywordTargets checks plain and regex keywords against each target
+// of a response reached through two redirects.
+func TestKeywordTargets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/www/login", http.StatusFound)
+	})
+	mux.HandleFunc("/www/login", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/www/home?lang=en", http.StatusFound)
+	})
+	mux.HandleFunc("/www/home", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "a1b2c3"})
+		w.Write([]byte(`<div class="card">1</div><div class="card">2</div> Order #4521 shipped`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, srv.URL+"/start")
+	c := New(db, nil)
+	defer c.Stop()
+
+	tests := []struct {
+		keywords string
+		wantErr  string // empty when the check should pass
+	}{
+		{keywords: "ORDER #4521"},
+		{keywords: `body:class="card">=2`},
+		{keywords: `class="card">=3`, wantErr: `keyword 'class="card"' in response: found 2, expected >= 3`},
+		{keywords: `re:order #\d+ shipped`},
+		{keywords: `body:re:<div class="card">\d</div>>=2`},
+		{keywords: `re:order #\d+ cancelled`, wantErr: `pattern 'order #\d+ cancelled' not found in response`},
+
+		{keywords: "headers:set-cookie: session="},
+		{keywords: "headers:set-cookie: token=", wantErr: "keyword 'set-cookie: token=' not found in response headers"},
+		{keywords: `headers:re:^set-cookie: session=[0-9a-f]{6}$`},
+		{keywords: `headers:re:^set-cookie: session=\d+$`, wantErr: `pattern '^set-cookie: session=\d+$' not found in response headers`},
+
+		{keywords: "redirect-chain:/www/home"},
+		{keywords: "redirect-chain#1:/www/login"},
+		{keywords: "redirect-chain#1:/www/home", wantErr: "keyword '/www/home' not found in redirect hop 1 (" + srv.URL + "/www/login)"},
+		{keywords: "redirect-chain#3:/www/home", wantErr: "keyword '/www/home' not found in redirect hop 3 (only 2 redirects)"},
+		{keywords: `redirect-chain:re:/www/\w+>=2`},
+		{keywords: `redirect-chain#2:re:\?lang=[a-z]{2}$`},
+		{keywords: `redirect-chain#1:re:\?lang=`, wantErr: `pattern '\?lang=' not found in redirect hop 1 (` + srv.URL + "/www/login)"},
+	}
+	for _, tt := range tests {
+		if _, err := storage.ParseKeywordRules(tt.keywords); err != nil {
+			t.Fatalf("ParseKeywordRules(%q): %v", tt.keywords, err)
+		}
+		m.Keywords = tt.keywords
+		r := c.Test(m)
+		switch {
+		case tt.wantErr == "" && r.Err != nil:
+			t.Errorf("%s: %v", tt.keywords, r.Err)
+		case tt.wantErr != "" && (r.Err == nil || !strings.Contains(r.Err.Error(), tt.wantErr)):
+			t.Errorf("%s: error = %v, want %q", tt.keywords, r.Err, tt.wantErr)
+		}
+		// Body is the default target, so its prefix isn't repeated.
+		if want := strings.TrimPrefix(tt.keywords, "body:"); len(r.Keywords) != 1 || r.Keywords[0].Keyword != want {
+			t.Errorf("%s: matches = %+v, want %q", tt.keywords, r.Keywords, want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package checker
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+type latencySample struct {
+	at time.Time
+	ms int64
+}
+
+// latencyWindow holds the successful response times of a monitor's latency
+// rule window. It keeps a running sum for the average and a sorted copy for
+// the p95, so each check costs one insertion and the evictions instead of a
+// query over the whole window.
+type latencyWindow struct {
+	samples []latencySample // oldest first
+	sorted  []int64
+	sum     int64
+
+	// since is when the window started filling. The rule only fires once
+	// it spans a whole window, so a few slow checks after a restart don't
+	// open an incident.
+	since time.Time
+
+	// belowSince is when the aggregate last dropped to the threshold while
+	// an incident is open.
+	belowSince time.Time
+	incident   *storage.Incident
+}
+
+func (w *latencyWindow) add(at time.Time, ms int64) {
+	w.samples = append(w.samples, latencySample{at: at, ms: ms})
+	w.sum += ms
+	i, _ := slices.BinarySearch(w.sorted, ms)
+	w.sorted = slices.Insert(w.sorted, i, ms)
+}
+
+// evict drops the samples taken before cutoff.
+func (w *latencyWindow) evict(cutoff time.Time) {
+	n := 0
+	for n < len(w.samples) && w.samples[n].at.Before(cutoff) {
+		ms := w.samples[n].ms
+		w.sum -= ms
+		if i, ok := slices.BinarySearch(w.sorted, ms); ok {
+			w.sorted = slices.Delete(w.sorted, i, i+1)
+		}
+		n++
+	}
+	w.samples = slices.Delete(w.samples, 0, n)
+}
+
+// value aggregates the window. ok is false while it holds no samples.
+func (w *latencyWindow) value(agg string) (ms int64, ok bool) {
+	n := len(w.samples)
+	if n == 0 {
+		return 0, false
+	}
+	if agg == storage.LatencyAvg {
+		return w.sum / int64(n), true
+	}
+	// Nearest rank, as GetResponseTimePercentiles computes it.
+	rank := max(int(math.Ceil(0.95*float64(n)))-1, 0)
+	return w.sorted[rank], true
+}
+
+// ownState returns m's monitor state when m is the copy its goroutine
+// checks. One-off checks of other copies get nil.
+func (c *Checker) ownState(m *storage.Monitor) *monitorState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ms := c.monitors[m.ID]
+	if ms == nil || ms.monitor != m {
+		return nil
+	}
+	return ms
+}
+
+// loadLatencyWindow seeds a latency window from the checks already stored
+// for the current window, so a restart doesn't start it over, and picks up
+// any open performance incident.
+func (c *Checker) loadLatencyWindow(m *storage.Monitor, now time.Time) *latencyWindow {
+	w := &latencyWindow{since: now}
+	if incident, err := c.db.GetActiveIncidentOfType(m.ID, storage.IncidentPerformance); err == nil && incident != nil {
+		w.incident = incident
+	}
+	if !m.HasLatencyRule() {
+		return w
+	}
+
+	results, err := c.db.GetCheckResultsSince(m.ID, now.Add(-m.LatencyRuleWindow()))
+	if err != nil {
+		slog.Error("failed to load response times", "monitor", m.Name, "id", m.ID, "error", err)
+		return w
+	}
+	// Results are newest first; the current check is added by the caller.
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		if !r.CreatedAt.Before(now) {
+			continue
+		}
+		if r.CreatedAt.Before(w.since) {
+			w.since = r.CreatedAt
+		}
+		if r.Success {
+			w.add(r.CreatedAt, r.ResponseTime)
+		}
+	}
+	return w
+}
+
+// evaluateLatency adds a successful check's response time to m's latency
+// window. A performance incident opens once the aggregate over a full
+// window exceeds the threshold, and resolves once it has stayed at or
+// below the threshold for a whole window.
+func (c *Checker) evaluateLatency(m *storage.Monitor, now time.Time, responseTime int64) {
+	ms := c.ownState(m)
+	if ms == nil {
+		return
+	}
+	if ms.latency == nil {
+		ms.latency = c.loadLatencyWindow(m, now)
+	}
+	w := ms.latency
+
+	if !m.HasLatencyRule() {
+		if w.incident != nil {
+			c.resolveSlow(m, w, now, "latency rule removed", "The latency rule was removed")
+		}
+		return
+	}
+
+	window := m.LatencyRuleWindow()
+	w.evict(now.Add(-window))
+	w.add(now, responseTime)
+
+	agg, _ := storage.ValidateLatencyAgg(m.LatencyAgg)
+	value, ok := w.value(agg)
+	if !ok {
+		return
+	}
+	threshold := int64(m.LatencyThreshold)
+	detail := fmt.Sprintf("%s response time %dms (rule: %s)", agg, value, m.LatencyRule())
+
+	if value > threshold {
+		w.belowSince = time.Time{}
+		// Allow one interval of slack: the oldest check in a window is up
+		// to an interval younger than the window itself.
+		if w.incident == nil && now.Sub(w.since) >= window-monitorInterval(m) {
+			c.openSlow(m, w, now, detail)
+		}
+		return
+	}
+
+	if w.incident == nil {
+		return
+	}
+	if w.belowSince.IsZero() {
+		w.belowSince = now
+	}
+	if now.Sub(w.belowSince) >= window {
+		c.resolveSlow(m, w, now, "", detail)
+	}
+}
+
+func (c *Checker) openSlow(m *storage.Monitor, w *latencyWindow, now time.Time, detail string) {
+	slog.Info("monitor slow", "monitor", m.Name, "id", m.ID, "detail", detail)
+	incident := &storage.Incident{
+		MonitorID:    m.ID,
+		Type:         storage.IncidentPerformance,
+		StartedAt:    now,
+		ErrorMessage: detail,
+	}
+	if err := c.db.CreateIncident(incident); err != nil {
+		slog.Error("failed to create incident", "monitor", m.Name, "id", m.ID, "error", err)
+		return
+	}
+	w.incident = incident
+	c.notifySlow(m, incident.ID, detail)
+}
+
+func (c *Checker) resolveSlow(m *storage.Monitor, w *latencyWindow, now time.Time, resolution, detail string) {
+	incident := w.incident
+	w.incident = nil
+	w.belowSince = time.Time{}
+
+	slog.Info("monitor responsive again", "monitor", m.Name, "id", m.ID, "duration", now.Sub(incident.StartedAt).Round(time.Second))
+	if err := c.db.ResolveIncident(incident, now, resolution); err != nil {
+		slog.Error("failed to resolve incident", "monitor", m.Name, "incident", incident.ID, "error", err)
+	}
+	c.notifySlowRecovery(m, incident.ID, detail)
+	incident.RecoveryNotified = true
+	if err := c.db.UpdateIncident(incident); err != nil {
+		slog.Error("failed to update incident", "monitor", m.Name, "incident", incident.ID, "error", err)
+	}
+}
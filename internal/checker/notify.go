@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+// notificationQueue is how many alerts about one monitor may wait to be
+// sent. More than that means its notifications are stuck, and further ones
+// are dropped.
+const notificationQueue = 32
+
+// noMonitor queues alerts that aren't about one monitor, such as a proxy
+// being down.
+const noMonitor = 0
+
+var (
+	notificationsQueued  = telemetry.NewGauge("statping_notifications_queued", "Alerts waiting for the checker's notification workers.")
+	notificationsDropped = telemetry.NewCounter("statping_notifications_dropped_total", "Alerts dropped because their monitor's notification queue was full.")
+)
+
+// notify queues send on monitorID's notification queue. Desktop alerts
+// block until the notification daemon answers, which can take seconds;
+// off the check goroutine they hold up neither the check nor c.mu. Each
+// monitor has its own queue and worker, so its alerts go out in order and
+// a stuck one only holds up that monitor's. Network channels are
+// delivered in the background by the notifier itself.
+func (c *Checker) notify(monitorID uint, send func()) {
+	// Held while sending, so RemoveMonitor can't close the queue meanwhile;
+	// the send never blocks.
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	select {
+	case c.notificationQueue(monitorID) <- send:
+		notificationsQueued.Add(1)
+	default:
+		notificationsDropped.Inc()
+		slog.Warn("notification queue full, dropping alert", "id", monitorID, "queued", notificationQueue)
+	}
+}
+
+// notificationQueue returns monitorID's queue, starting its worker the
+// first time. c.notifyMu must be held. Workers outlive Stop, so alerts
+// queued while stopping still go out, and exit once their monitor is
+// removed and its queue drained.
+func (c *Checker) notificationQueue(monitorID uint) chan func() {
+	q, ok := c.notifications[monitorID]
+	if !ok {
+		q = make(chan func(), notificationQueue)
+		c.notifications[monitorID] = q
+		go sendNotifications(q)
+	}
+	return q
+}
+
+// closeNotifications closes monitorID's queue, if it has one, so its worker
+// sends what is left and exits. An alert from a check that was still
+// running starts a new queue.
+func (c *Checker) closeNotifications(monitorID uint) {
+	c.notifyMu.Lock()
+	defer c.notifyMu.Unlock()
+	if q, ok := c.notifications[monitorID]; ok {
+		delete(c.notifications, monitorID)
+		close(q)
+	}
+}
+
+// sendNotifications sends the alerts queued on q in order, until q is
+// closed.
+func sendNotifications(q chan func()) {
+	for send := range q {
+		notificationsQueued.Add(-1)
+		send()
+	}
+}
+
+// claimNotification reports whether a down alert for m is due, recording
+// it as sent if so. A reminder that m is still down waits at least the
+// global cooldown even when m has none, so it isn't repeated every check.
+// c.mu is only held for the bookkeeping.
+func (c *Checker) claimNotification(m *storage.Monitor, now time.Time, reminder bool) bool {
+	cooldown := m.Cooldown()
+	if reminder && cooldown == 0 {
+		cooldown = config.NotificationCooldown * time.Second
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ms := c.monitors[m.ID]
+	if ms == nil || now.Sub(ms.lastNotified) < cooldown {
+		return false
+	}
+	ms.lastNotified = now
+	return true
+}
+
+// The notify helpers below hand the notifier a copy of m, since the check
+// goroutine goes on updating the original.
+
+func (c *Checker) notifyDown(m *storage.Monitor, incidentID uint, errorMsg string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifyDown(&snapshot, incidentID, errorMsg) })
+}
+
+func (c *Checker) notifyRecovery(m *storage.Monitor, incidentID uint, downtime time.Duration) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifyRecovery(&snapshot, incidentID, downtime) })
+}
+
+func (c *Checker) notifyFirstCheck(m *storage.Monitor, summary string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifyFirstCheck(&snapshot, summary) })
+}
+
+func (c *Checker) notifySlow(m *storage.Monitor, incidentID uint, detail string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifySlow(&snapshot, incidentID, detail) })
+}
+
+func (c *Checker) notifySlowRecovery(m *storage.Monitor, incidentID uint, detail string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifySlowRecovery(&snapshot, incidentID, detail) })
+}
+
+func (c *Checker) notifyAnomaly(m *storage.Monitor, summary string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifyAnomaly(&snapshot, summary) })
+}
+
+func (c *Checker) notifyAuthFailure(m *storage.Monitor, errorMsg string) {
+	snapshot := *m
+	c.notify(m.ID, func() { c.notifier.NotifyAuthFailure(&snapshot, errorMsg) })
+}
@@ -0,0 +1,184 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// tokenRefreshMargin is how long before it expires a cached token is
+// replaced, so a check never goes out with one that lapses in flight.
+const tokenRefreshMargin = time.Minute
+
+// maxTokenResponse caps how much of a token endpoint's response is read.
+const maxTokenResponse = 64 << 10
+
+// tokenCache holds each monitor's OAuth2 access token until shortly before
+// it expires.
+type tokenCache struct {
+	mu      sync.Mutex
+	entries map[uint]*tokenEntry
+}
+
+type tokenEntry struct {
+	key       string // the settings the token was issued for
+	token     string
+	refreshAt time.Time // zero if the endpoint gave no lifetime
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{entries: make(map[uint]*tokenEntry)}
+}
+
+// get returns the cached token for a monitor if it was issued for the same
+// settings and isn't due for a refresh, or "".
+func (tc *tokenCache) get(id uint, key string) string {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	e := tc.entries[id]
+	if e == nil || e.key != key || (!e.refreshAt.IsZero() && time.Now().After(e.refreshAt)) {
+		return ""
+	}
+	return e.token
+}
+
+func (tc *tokenCache) put(id uint, e *tokenEntry) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.entries[id] = e
+}
+
+func (tc *tokenCache) forget(id uint) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	delete(tc.entries, id)
+}
+
+// oauth2Key identifies the settings a token was issued for, so changing
+// any of them gets a new one.
+func oauth2Key(m *storage.Monitor) string {
+	return strings.Join([]string{m.OAuth2TokenURL, m.OAuth2ClientID, m.OAuth2ClientSecret, m.OAuth2Scopes}, "\n")
+}
+
+// oauth2Token returns a bearer token for m through the client credentials
+// grant, from the cache when it is still fresh. A token without a lifetime
+// is kept until a check is answered with 401. Monitors that aren't saved
+// yet, as when testing one, always get a new token.
+func (c *Checker) oauth2Token(m *storage.Monitor, opts transportOptions, timeout time.Duration) (string, error) {
+	key := oauth2Key(m)
+	if m.ID != 0 {
+		if token := c.tokens.get(m.ID, key); token != "" {
+			return token, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+	ctx = withDialLimits(ctx, connectTimeout(m))
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if m.OAuth2Scopes != "" {
+		form.Set("scope", m.OAuth2Scopes)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", m.OAuth2TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent(m))
+	// RFC 6749 has the credentials form-encoded before they are used as
+	// the basic auth user and password.
+	req.SetBasicAuth(url.QueryEscape(m.OAuth2ClientID), url.QueryEscape(m.OAuth2ClientSecret))
+
+	resp, err := c.clients.get(opts).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponse))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &oauthErr) == nil && oauthErr.Error != "" {
+			if oauthErr.Description != "" {
+				return "", fmt.Errorf("token endpoint returned %d: %s (%s)", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+			}
+			return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, oauthErr.Error)
+		}
+		return "", fmt.Errorf("token endpoint returned %d", resp.StatusCode)
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+	if tok.TokenType != "" && !strings.EqualFold(tok.TokenType, "bearer") {
+		return "", fmt.Errorf("token endpoint returned a %q token, only bearer tokens are supported", tok.TokenType)
+	}
+
+	if m.ID != 0 {
+		e := &tokenEntry{key: key, token: tok.AccessToken}
+		if tok.ExpiresIn > 0 {
+			lifetime := time.Duration(tok.ExpiresIn) * time.Second
+			e.refreshAt = time.Now().Add(lifetime - min(tokenRefreshMargin, lifetime/2))
+		}
+		c.tokens.put(m.ID, e)
+	}
+	return tok.AccessToken, nil
+}
+
+// recordAuthFailure saves a check that couldn't get an OAuth2 token. Like a
+// proxy failure it says nothing about the monitored API, so the monitor's
+// status, failure count and incidents are left alone.
+func (c *Checker) recordAuthFailure(m *storage.Monitor, err error) {
+	now := time.Now()
+
+	result := &storage.CheckResult{
+		MonitorID:    m.ID,
+		Success:      false,
+		AuthError:    true,
+		ErrorMessage: "auth failure: " + err.Error(),
+		CreatedAt:    now,
+	}
+	slog.Warn("oauth2 token request failed", "monitor", m.Name, "id", m.ID, "error", err)
+	c.saveResult(m, result)
+
+	if m.LastCheckAt == nil {
+		c.setStatus(m, "unknown", result, now)
+		m.LastCheckAt = &now
+		c.saveMonitor(m)
+	}
+
+	c.mu.Lock()
+	notify := time.Since(c.authNotified[m.ID]).Seconds() >= config.NotificationCooldown
+	if notify {
+		c.authNotified[m.ID] = now
+	}
+	c.mu.Unlock()
+
+	if notify {
+		c.notifyAuthFailure(m, err.Error())
+	}
+}
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+// writeBackoff is how long to wait before each retry of a write that hit a
+// lock. SQLite has already waited out its busy timeout by then, so a few
+// retries are enough.
+var writeBackoff = []time.Duration{250 * time.Millisecond, time.Second}
+
+// maxPendingResults caps the check results kept in memory while the
+// database can't be written; beyond it the oldest are dropped.
+const maxPendingResults = 1000
+
+var resultsPending = telemetry.NewGauge("statping_check_results_pending", "Check results held in memory because saving them failed.")
+
+// write runs op, retrying it while it fails on a lock. The final error is
+// logged with what was being written and for which monitor.
+func (c *Checker) write(what string, m *storage.Monitor, op func() error) error {
+	err := op()
+	for _, wait := range writeBackoff {
+		if !storage.IsTransient(err) {
+			break
+		}
+		time.Sleep(wait)
+		err = op()
+	}
+	if err != nil {
+		slog.Error("failed to "+what, "monitor", m.Name, "id", m.ID, "error", err)
+	}
+	return err
+}
+
+// saveMonitor saves m's status. On failure the running copy keeps the new
+// state, and the next successful save writes all of it.
+func (c *Checker) saveMonitor(m *storage.Monitor) {
+	c.write("update monitor", m, func() error { return c.db.UpdateMonitor(m) })
+}
+
+// setStatus sets m's status and records the change for its status timeline
+// if it is a change. result is the check that caused it, or nil.
+func (c *Checker) setStatus(m *storage.Monitor, status string, result *storage.CheckResult, at time.Time) {
+	from := m.CurrentStatus
+	if from == "" {
+		from = "unknown"
+	}
+	m.CurrentStatus = status
+	if from == status {
+		return
+	}
+
+	change := &storage.StatusChange{MonitorID: m.ID, At: at, FromStatus: from, ToStatus: status}
+	if result != nil && result.ID != 0 {
+		id := result.ID
+		change.CheckID = &id
+	}
+	c.write("record status change", m, func() error { return c.db.CreateStatusChange(change) })
+}
+
+// saveResult stores result after any results earlier writes failed to
+// save. If it can't be saved because the database is locked it is kept for
+// the next attempt, so a spell of lock errors doesn't leave a hole in the
+// history. The backlog is taken out of c.pending while it is written, so
+// c.pendingMu is never held across a write or a backoff.
+func (c *Checker) saveResult(m *storage.Monitor, result *storage.CheckResult) {
+	unsaved := c.saveBacklog(c.takePending())
+	if len(unsaved) > 0 {
+		// Still locked; keep the results in order rather than retry.
+		c.requeue(append(unsaved, result))
+		return
+	}
+	err := c.write("save check result", m, func() error { return c.db.CreateCheckResult(result) })
+	if storage.IsTransient(err) {
+		c.requeue([]*storage.CheckResult{result})
+	}
+}
+
+// flushPending saves the results held back by earlier failures, e.g. when
+// the checker stops.
+func (c *Checker) flushPending() {
+	unsaved := c.saveBacklog(c.takePending())
+	c.requeue(unsaved)
+	if len(unsaved) > 0 {
+		slog.Error("check results could not be saved", "count", len(unsaved))
+	}
+}
+
+// takePending empties c.pending and returns what it held.
+func (c *Checker) takePending() []*storage.CheckResult {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	backlog := c.pending
+	c.pending = nil
+	resultsPending.Set(0)
+	return backlog
+}
+
+// requeue puts results that could not be saved back in front of any held
+// back since they were taken, dropping the oldest beyond maxPendingResults.
+func (c *Checker) requeue(results []*storage.CheckResult) {
+	if len(results) == 0 {
+		return
+	}
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	c.pending = append(results, c.pending...)
+	for len(c.pending) > maxPendingResults {
+		slog.Warn("dropping unsaved check result", "monitor_id", c.pending[0].MonitorID, "checked_at", c.pending[0].CreatedAt)
+		c.pending = c.pending[1:]
+	}
+	resultsPending.Set(int64(len(c.pending)))
+}
+
+// saveBacklog saves held-back results in order, once each, and returns
+// those from the first that hit a lock on. A result that fails for any
+// other reason would fail every time, so it is logged and dropped.
+func (c *Checker) saveBacklog(backlog []*storage.CheckResult) []*storage.CheckResult {
+	if len(backlog) == 0 {
+		return nil
+	}
+	for i, r := range backlog {
+		err := c.db.CreateCheckResult(r)
+		if storage.IsTransient(err) {
+			return backlog[i:]
+		}
+		if err != nil {
+			slog.Error("dropping check result that can't be saved", "monitor_id", r.MonitorID, "checked_at", r.CreatedAt, "error", err)
+		}
+	}
+	slog.Info("saved check results held back by earlier write failures", "count", len(backlog))
+	return nil
+}
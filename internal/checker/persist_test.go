@@ -0,0 +1,194 @@
+package checker
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	errLocked     = errors.New("database is locked")
+	errConstraint = errors.New("CHECK constraint failed")
+)
+
+// failingWrites makes db's check result inserts fail with whatever fail
+// returns for the result being inserted, standing in for a database that
+// is locked or rejects a row.
+func failingWrites(t *testing.T, db *storage.Database, fail func(*storage.CheckResult) error) {
+	t.Helper()
+	err := db.GetDB().Callback().Create().Before("gorm:create").Register("test:fail_check_results", func(tx *gorm.DB) {
+		if r, ok := tx.Statement.Dest.(*storage.CheckResult); ok {
+			if err := fail(r); err != nil {
+				tx.AddError(err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fastBackoff shortens the retries of locked writes for the test.
+func fastBackoff(t *testing.T, backoff ...time.Duration) {
+	saved := writeBackoff
+	writeBackoff = backoff
+	t.Cleanup(func() { writeBackoff = saved })
+}
+
+func savedResponseTimes(t *testing.T, db *storage.Database, m *storage.Monitor) []int64 {
+	t.Helper()
+	results, err := db.GetRecentCheckResults(m.ID, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rts []int64
+	for i := len(results) - 1; i >= 0; i-- {
+		rts = append(rts, results[i].ResponseTime)
+	}
+	return rts
+}
+
+func TestSaveResultHoldsBackLockedWrites(t *testing.T) {
+	fastBackoff(t, time.Millisecond)
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, "https://api.example.com")
+	var mu sync.Mutex
+	locked := true
+	failingWrites(t, db, func(*storage.CheckResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if locked {
+			return errLocked
+		}
+		return nil
+	})
+	c := New(db, nil)
+
+	for rt := int64(1); rt <= 3; rt++ {
+		c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: rt})
+	}
+	if n := len(c.pending); n != 3 {
+		t.Fatalf("%d results held back while locked, want 3", n)
+	}
+
+	mu.Lock()
+	locked = false
+	mu.Unlock()
+	c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 4})
+
+	if got := savedResponseTimes(t, db, m); len(got) != 4 || got[0] != 1 || got[3] != 4 {
+		t.Errorf("saved %v, want 1 to 4 in order", got)
+	}
+	if n := len(c.pending); n != 0 {
+		t.Errorf("%d results still held back", n)
+	}
+}
+
+func TestSaveResultDropsPermanentFailures(t *testing.T) {
+	fastBackoff(t, time.Millisecond)
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, "https://api.example.com")
+	var mu sync.Mutex
+	locked := true
+	failingWrites(t, db, func(r *storage.CheckResult) error {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.ResponseTime == 2:
+			return errConstraint
+		case locked:
+			return errLocked
+		}
+		return nil
+	})
+	c := New(db, nil)
+
+	// Result 2 is rejected once the lock clears, whether it was held back
+	// or new.
+	for rt := int64(1); rt <= 2; rt++ {
+		c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: rt})
+	}
+	mu.Lock()
+	locked = false
+	mu.Unlock()
+	c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 3})
+	c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 2})
+	c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 4})
+
+	if got := savedResponseTimes(t, db, m); len(got) != 3 || got[0] != 1 || got[1] != 3 || got[2] != 4 {
+		t.Errorf("saved %v, want 1, 3 and 4", got)
+	}
+	if n := len(c.pending); n != 0 {
+		t.Errorf("%d results held back, want the rejected ones dropped", n)
+	}
+}
+
+func TestSaveResultBacksOffWithoutLock(t *testing.T) {
+	fastBackoff(t, 500*time.Millisecond)
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, "https://api.example.com")
+	attempted := make(chan struct{}, 8)
+	failingWrites(t, db, func(*storage.CheckResult) error {
+		attempted <- struct{}{}
+		return errLocked
+	})
+	c := New(db, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.saveResult(m, &storage.CheckResult{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 1})
+	}()
+	<-attempted
+
+	// The first save is now backing off; another monitor's check must be
+	// able to queue its result meanwhile.
+	start := time.Now()
+	c.requeue([]*storage.CheckResult{{MonitorID: m.ID, CreatedAt: time.Now(), Success: true, ResponseTime: 2}})
+	if waited := time.Since(start); waited > 100*time.Millisecond {
+		t.Errorf("waited %s for the pending results during a backoff", waited)
+	}
+	<-done
+
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pending) != 2 || c.pending[0].ResponseTime != 1 || c.pending[1].ResponseTime != 2 {
+		t.Errorf("pending = %v, want results 1 and 2 in order", c.pending)
+	}
+}
+
+func TestSetStatusRecordsChanges(t *testing.T) {
+	db := newTestDB(t)
+	m := mustCreateMonitor(t, db, "http://127.0.0.1:1/")
+	c := New(db, nil)
+
+	start := time.Now().Add(-time.Hour)
+	failed := &storage.CheckResult{MonitorID: m.ID, CreatedAt: start.Add(2 * time.Minute), ErrorMessage: "connection refused"}
+	if err := db.CreateCheckResult(failed); err != nil {
+		t.Fatal(err)
+	}
+	c.setStatus(m, "up", nil, start)
+	c.setStatus(m, "up", nil, start.Add(time.Minute))
+	c.setStatus(m, "down", failed, start.Add(2*time.Minute))
+
+	changes, err := db.GetStatusChanges(m.ID, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("%d status changes recorded, want 2; staying up isn't a change", len(changes))
+	}
+	if c := changes[0]; c.FromStatus != "unknown" || c.ToStatus != "up" || c.CheckID != nil {
+		t.Errorf("first change = %+v, want unknown to up", c)
+	}
+	if c := changes[1]; c.FromStatus != "up" || c.ToStatus != "down" || c.CheckID == nil || *c.CheckID != failed.ID {
+		t.Errorf("second change = %+v, want up to down by check %d", c, failed.ID)
+	}
+	if m.CurrentStatus != "down" {
+		t.Errorf("status = %q, want down", m.CurrentStatus)
+	}
+}
@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// socksTargetErrors are SOCKS replies about the target rather than the
+// proxy itself.
+var socksTargetErrors = []string{
+	"host unreachable",
+	"network unreachable",
+	"connection refused",
+	"TTL expired",
+}
+
+// isProxyError reports whether a request failed because the proxy could
+// not be reached or refused to relay, as opposed to the target failing.
+func isProxyError(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	switch {
+	case opErr.Op == "proxyconnect":
+		return true
+	case strings.HasPrefix(opErr.Op, "socks"):
+		msg := opErr.Err.Error()
+		for _, s := range socksTargetErrors {
+			if strings.Contains(msg, s) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// proxyLabel names the proxy req went through, for logs and notifications.
+func proxyLabel(opts transportOptions, req *http.Request) string {
+	if opts.proxy != "" {
+		return redactProxy(opts.proxy)
+	}
+	if u, err := http.ProxyFromEnvironment(req); err == nil && u != nil {
+		return redactProxy(u.String())
+	}
+	return "proxy"
+}
+
+// redactProxy drops credentials from a proxy URL.
+func redactProxy(proxy string) string {
+	if i := strings.Index(proxy, "@"); i >= 0 {
+		if j := strings.Index(proxy, "://"); j >= 0 && j < i {
+			return proxy[:j+3] + proxy[i+1:]
+		}
+	}
+	return proxy
+}
+
+// recordProxyFailure saves a check that never reached the target. It leaves
+// the monitor's status, failure count and incidents alone and sends one
+// notification per proxy instead of marking every monitor behind it down.
+func (c *Checker) recordProxyFailure(m *storage.Monitor, proxy string, err error) {
+	now := time.Now()
+
+	result := &storage.CheckResult{
+		MonitorID:    m.ID,
+		Success:      false,
+		ProxyError:   true,
+		ErrorMessage: "proxy: " + err.Error(),
+		CreatedAt:    now,
+	}
+	slog.Warn("proxy failed", "monitor", m.Name, "id", m.ID, "proxy", proxy, "error", err)
+	c.saveResult(m, result)
+
+	// A proxy failure says nothing about the site, but the monitor has
+	// been checked and is no longer pending.
+	if m.LastCheckAt == nil {
+		c.setStatus(m, "unknown", result, now)
+		m.LastCheckAt = &now
+		c.saveMonitor(m)
+	}
+
+	c.mu.Lock()
+	notify := time.Since(c.proxyNotified[proxy]).Seconds() >= config.NotificationCooldown
+	if notify {
+		c.proxyNotified[proxy] = now
+	}
+	c.mu.Unlock()
+
+	if notify {
+		msg := err.Error()
+		c.notify(noMonitor, func() { c.notifier.NotifyProxyDown(proxy, msg) })
+	}
+}
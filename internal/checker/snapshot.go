@@ -0,0 +1,58 @@
+package checker
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// snippetSize is how much of a failed check's body is kept.
+const snippetSize = 2048
+
+// snapshotHeaders are the response headers kept for a failed check: the
+// ones that tell a challenge page, redirect or maintenance notice apart.
+var snapshotHeaders = []string{
+	"Server",
+	"Content-Type",
+	"Content-Length",
+	"Location",
+	"Retry-After",
+	"Cache-Control",
+	"Via",
+	"X-Cache",
+	"CF-Ray",
+	"CF-Mitigated",
+	"WWW-Authenticate",
+}
+
+// responseSnapshot is what a failed check got back, stored so the failure
+// can be diagnosed later.
+type responseSnapshot struct {
+	body   []byte
+	header http.Header
+}
+
+func (s *responseSnapshot) apply(r *storage.CheckResult) {
+	if s == nil {
+		return
+	}
+	r.ResponseSnippet = snippet(s.body)
+
+	var lines []string
+	for _, name := range snapshotHeaders {
+		if v := s.header.Get(name); v != "" {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	r.ResponseHeaders = strings.Join(lines, "\n")
+}
+
+// snippet returns the start of body as text. Invalid UTF-8, including a
+// character cut in half at the limit, is dropped.
+func snippet(body []byte) string {
+	if len(body) > snippetSize {
+		body = body[:snippetSize]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(body), ""))
+}
@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// portResult is the outcome of connecting to one port of a TCP monitor.
+type portResult struct {
+	port    string
+	connect time.Duration
+	ip      string
+	err     error
+}
+
+// probeTCP connects to every port of a TCP monitor at once, within the
+// monitor's connect timeout or else its timeout, and fails unless all of them accept. The response
+// time is that of the slowest connect.
+func (c *Checker) probeTCP(m *storage.Monitor, opts transportOptions) probeResult {
+	p := probeResult{conn: &connInfo{}}
+
+	timeout := time.Duration(m.Timeout) * time.Second
+	if timeout == 0 {
+		timeout = time.Duration(config.DefaultTimeout) * time.Second
+	}
+	if connect := connectTimeout(m); connect > 0 && connect < timeout {
+		timeout = connect
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	defer cancel()
+
+	network := opts.network
+	if network == "" {
+		network = "tcp"
+	}
+
+	host, ports := m.TCPPorts()
+	results := make([]portResult, len(ports))
+	var wg sync.WaitGroup
+	for i, port := range ports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = dialPort(ctx, network, host, port)
+		}()
+	}
+	wg.Wait()
+
+	var lines, failed []string
+	var slowest time.Duration
+	for _, r := range results {
+		slowest = max(slowest, r.connect)
+		if r.err != nil {
+			reason := dialFailure(r.err)
+			lines = append(lines, r.port+": "+reason)
+			failed = append(failed, r.port+" "+reason)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %dms", r.port, r.connect.Milliseconds()))
+		if p.conn.resolvedIP == "" {
+			p.conn.resolvedIP = r.ip
+		}
+	}
+	p.responseTime = slowest.Milliseconds()
+	p.conn.connect = slowest
+	p.conn.portResults = strings.Join(lines, "\n")
+
+	if len(failed) > 0 {
+		p.err = errors.New(strings.Join(failed, ", "))
+		p.aborted = c.stopped()
+	}
+	return p
+}
+
+// dialPort connects to host:port and closes the connection straight away.
+func dialPort(ctx context.Context, network, host, port string) portResult {
+	r := portResult{port: port}
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(host, port))
+	r.connect = time.Since(start)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		r.ip = addr.IP.String()
+	}
+	conn.Close()
+	return r
+}
+
+// dialFailure is the short reason a port failed, as listed in a TCP
+// check's error, e.g. "connection refused" or "timeout".
+func dialFailure(err error) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.As(err, &dnsErr):
+		return dnsErr.Err
+	case errors.As(err, &opErr):
+		return opErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,146 @@
+package checker
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// connInfo collects which address a check connected to, what TLS was
+// negotiated and how long each phase took, for debugging intermittent
+// failures. Phases skipped on a reused connection stay zero.
+type connInfo struct {
+	mu         sync.Mutex
+	resolvedIP string
+	tlsVersion string
+	tlsCipher  string
+	protocol   string
+
+	start, dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tlsHandshake, firstByte   time.Duration
+
+	// ipv4Time and ipv6Time are the per-family response times of a
+	// dual-stack check, set by probe once both requests finished.
+	ipv4Time, ipv6Time int64
+
+	// portResults lists each port's outcome for a TCP check.
+	portResults string
+}
+
+// trace returns hooks that fill in c. Dial attempts are recorded too, so a
+// check that never connects still shows the address it tried last. Behind
+// a proxy the address is the proxy's.
+func (c *connInfo) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.start = time.Now()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.dns = since(c.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.resolvedIP = hostOnly(addr)
+			c.connect = since(c.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.tlsStart = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Conn == nil {
+				return
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.resolvedIP = hostOnly(info.Conn.RemoteAddr().String())
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			c.mu.Lock()
+			c.tlsHandshake = since(c.tlsStart)
+			c.mu.Unlock()
+			if err == nil {
+				c.setTLS(&state)
+			}
+		},
+		GotFirstResponseByte: func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.firstByte = since(c.start)
+		},
+	}
+}
+
+// setTLS records the negotiated TLS parameters. Reused connections skip
+// the handshake, so it is also called with the response's state.
+func (c *connInfo) setTLS(state *tls.ConnectionState) {
+	if state == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tlsVersion = tls.VersionName(state.Version)
+	c.tlsCipher = tls.CipherSuiteName(state.CipherSuite)
+}
+
+// setProtocol records the protocol the response came over, e.g. "HTTP/2.0".
+func (c *connInfo) setProtocol(proto string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.protocol = proto
+}
+
+func (c *connInfo) apply(r *storage.CheckResult) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r.ResolvedIP = c.resolvedIP
+	r.TLSVersion = c.tlsVersion
+	r.TLSCipher = c.tlsCipher
+	r.Protocol = c.protocol
+	r.DNSTime = c.dns.Milliseconds()
+	r.ConnectTime = c.connect.Milliseconds()
+	r.TLSTime = c.tlsHandshake.Milliseconds()
+	r.FirstByteTime = c.firstByte.Milliseconds()
+	r.IPv4Time = c.ipv4Time
+	r.IPv6Time = c.ipv6Time
+	r.PortResults = c.portResults
+}
+
+// since is time.Since, but zero for a phase whose start was never seen.
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+func hostOnly(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,203 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// transportOptions is the part of a monitor's configuration that needs its
+// own http.Transport. Monitors with equal options share a client, so
+// connection reuse still works across monitors of the same host.
+type transportOptions struct {
+	disableKeepAlive bool
+	httpVersion      string
+	skipTLSVerify    bool
+
+	// proxy is a proxy URL, storage.ProxyDirect, or empty to use the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+	proxy string
+
+	// network is "tcp4" or "tcp6" to force an address family, or empty to
+	// let the dialer choose. Behind a proxy it applies to the connection
+	// to the proxy.
+	network string
+}
+
+// transportOptions resolves m's options, falling back to the global proxy
+// setting when the monitor has no proxy of its own.
+func (c *Checker) transportOptions(m *storage.Monitor) transportOptions {
+	proxy := m.Proxy
+	if proxy == "" {
+		proxy = c.db.GetStringSetting(storage.SettingProxy, "")
+	}
+	return transportOptions{
+		disableKeepAlive: m.DisableKeepAlive,
+		httpVersion:      m.HTTPVersion,
+		skipTLSVerify:    m.SkipTLSVerify,
+		proxy:            proxy,
+		network:          familyNetwork(m.AddressFamily),
+	}
+}
+
+// familyNetwork returns the dial network forcing family. Monitors checking
+// both families get theirs per probe.
+func familyNetwork(family string) string {
+	switch family {
+	case storage.AddressFamilyIPv4:
+		return "tcp4"
+	case storage.AddressFamilyIPv6:
+		return "tcp6"
+	}
+	return ""
+}
+
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[transportOptions]*http.Client
+}
+
+func newClientPool() *clientPool {
+	return &clientPool{clients: make(map[transportOptions]*http.Client)}
+}
+
+// get returns the client for opts, creating it on first use.
+func (p *clientPool) get(opts transportOptions) *http.Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if client, ok := p.clients[opts]; ok {
+		return client
+	}
+	// No client timeout: every request carries its monitor's own deadline
+	// in its context, which may well be longer than any fixed cap.
+	client := &http.Client{
+		Transport:     newTransport(opts),
+		CheckRedirect: checkRedirect,
+	}
+	p.clients[opts] = client
+	return client
+}
+
+func (p *clientPool) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, client := range p.clients {
+		client.CloseIdleConnections()
+	}
+}
+
+// defaultDialLimit bounds connecting for requests that carry no dial limits.
+const defaultDialLimit = 30 * time.Second
+
+type dialLimitsKey struct{}
+
+// dialLimits bound opening a request's connection. The transport dials
+// with a context that is no longer cancelled with the request's, so
+// without them a check that timed out could leave a dial or TLS handshake
+// running on its behalf.
+type dialLimits struct {
+	connect  time.Duration // the TCP connect alone; 0 for no limit of its own
+	deadline time.Time     // the check's deadline, for connecting and the handshake
+}
+
+// withDialLimits attaches limits to a request's context, and clears the
+// deadline they put on a new connection once the request has it, so that
+// the request's context alone bounds reading the response.
+func withDialLimits(ctx context.Context, connect time.Duration) context.Context {
+	limits := dialLimits{connect: connect}
+	if deadline, ok := ctx.Deadline(); ok {
+		limits.deadline = deadline
+	}
+	ctx = context.WithValue(ctx, dialLimitsKey{}, limits)
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			info.Conn.SetDeadline(time.Time{})
+		},
+	})
+}
+
+// dialLimited dials addr within the limits attached to ctx. A connection
+// it returns has the check's deadline set, which also bounds the TLS
+// handshake and any proxy CONNECT, until withDialLimits clears it.
+func dialLimited(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	limits, ok := ctx.Value(dialLimitsKey{}).(dialLimits)
+	if !ok {
+		limits.connect = defaultDialLimit
+	}
+	if !limits.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, limits.deadline)
+		defer cancel()
+	}
+	if limits.connect > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.connect)
+		defer cancel()
+	}
+
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		// Say so when the connect timeout, not the check's, ran out.
+		checkExpired := !limits.deadline.IsZero() && !time.Now().Before(limits.deadline)
+		if limits.connect > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && !checkExpired {
+			return nil, fmt.Errorf("connect timeout after %s: %w", limits.connect, err)
+		}
+		return nil, err
+	}
+	if !limits.deadline.IsZero() {
+		conn.SetDeadline(limits.deadline)
+	}
+	return conn, nil
+}
+
+func newTransport(opts transportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DisableKeepAlives = opts.disableKeepAlive
+	switch opts.proxy {
+	case "":
+		// The cloned default transport already uses the environment.
+	case storage.ProxyDirect:
+		t.Proxy = nil
+	default:
+		// Validated when saved; an unparsable URL falls back to the
+		// environment rather than failing every check.
+		if u, err := url.Parse(opts.proxy); err == nil {
+			t.Proxy = http.ProxyURL(u)
+		}
+	}
+	dialer := &net.Dialer{KeepAlive: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if opts.network != "" {
+			network = opts.network
+		}
+		return dialLimited(ctx, dialer, network, addr)
+	}
+	// The handshake is bounded by the deadline dialLimited sets instead.
+	t.TLSHandshakeTimeout = 0
+	if opts.skipTLSVerify {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	switch opts.httpVersion {
+	case storage.HTTPVersion1:
+		var p http.Protocols
+		p.SetHTTP1(true)
+		t.Protocols = &p
+	case storage.HTTPVersion2:
+		// Plain http:// URLs can only speak HTTP/2 with prior knowledge.
+		var p http.Protocols
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		t.Protocols = &p
+	}
+	return t
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -11,6 +16,18 @@ const (
 	DefaultTimeout       = 10
 	DefaultMaxFailures   = 3
 	NotificationCooldown = 300
+	DefaultUserAgent     = "Statping/1.0"
+
+	// DefaultHeartbeatGrace is how many seconds a heartbeat ping may be late
+	// before the monitor is marked down.
+	DefaultHeartbeatGrace = 300
+	DefaultBaseURL        = "http://127.0.0.1:8080"
+
+	// Monitors with backoff enabled double their check interval once they
+	// have failed BackoffThreshold times in a row, up to MaxBackoffFactor
+	// times the configured interval.
+	BackoffThreshold = 5
+	MaxBackoffFactor = 10
 )
 
 func GetConfigDir() (string, error) {
@@ -34,3 +51,137 @@ func GetDatabasePath() (string, error) {
 	}
 	return filepath.Join(configDir, "statping.db"), nil
 }
+
+func GetDatabaseConfigPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "database.conf"), nil
+}
+
+// DatabaseConfig selects the database backend. An empty Driver means the
+// default SQLite database.
+type DatabaseConfig struct {
+	Driver string
+	DSN    string
+}
+
+// LoadDatabaseConfig reads database.conf, whose "driver = postgres" and
+// "dsn = postgres://…" lines point statping at another database. Blank
+// lines and lines starting with # are ignored. A missing file yields an
+// empty config.
+func LoadDatabaseConfig() (DatabaseConfig, error) {
+	var cfg DatabaseConfig
+	path, err := GetDatabaseConfigPath()
+	if err != nil {
+		return cfg, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return cfg, fmt.Errorf("%s:%d: expected key = value", path, n)
+		}
+		switch strings.TrimSpace(key) {
+		case "driver":
+			cfg.Driver = strings.TrimSpace(value)
+		case "dsn":
+			cfg.DSN = strings.TrimSpace(value)
+		default:
+			return cfg, fmt.Errorf("%s:%d: unknown key %q, expected driver or dsn", path, n, strings.TrimSpace(key))
+		}
+	}
+	return cfg, scanner.Err()
+}
+
+func GetTokenPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "token"), nil
+}
+
+func GetLogPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "statping.log"), nil
+}
+
+// GetStdoutPath and GetStderrPath are where the LaunchAgent sends the
+// process's standard output and error.
+func GetStdoutPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "statping.out"), nil
+}
+
+func GetStderrPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "statping.err"), nil
+}
+
+func GetPIDPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "daemon.pid"), nil
+}
+
+func GetSocketPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "control.sock"), nil
+}
+
+// LoadOrCreateToken returns the API token used to authenticate against the
+// web server, generating and saving a random one on first use.
+func LoadOrCreateToken() (string, error) {
+	path, err := GetTokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
@@ -0,0 +1,122 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrNotRunning is returned by Connect when no daemon or tray is listening
+// on the control socket.
+var ErrNotRunning = errors.New("statping is not running")
+
+// Client talks to a running daemon or tray over its control socket.
+type Client struct {
+	http *http.Client
+}
+
+// Dial returns a client for the socket at path without checking that
+// anything is listening.
+func Dial(path string) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			},
+		},
+	}
+}
+
+// Connect returns a client for the socket at path, or ErrNotRunning if no
+// process is serving it.
+func Connect(path string) (*Client, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, ErrNotRunning
+	}
+	c := Dial(path)
+	if _, err := c.Status(); err != nil {
+		return nil, ErrNotRunning
+	}
+	return c, nil
+}
+
+func (c *Client) Status() (*Status, error) {
+	var status Status
+	if err := c.do("GET", "/status", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Stats returns the running process's internal metrics.
+func (c *Client) Stats() (*Stats, error) {
+	var stats Stats
+	if err := c.do("GET", "/stats", &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Reload makes the running checker pick up monitor changes from the
+// database.
+func (c *Client) Reload() error {
+	return c.do("POST", "/reload", nil)
+}
+
+// Check triggers an immediate check of monitor id, or of every monitor if
+// id is 0.
+func (c *Client) Check(id uint) error {
+	if id == 0 {
+		return c.do("POST", "/check", nil)
+	}
+	return c.do("POST", fmt.Sprintf("/check?id=%d", id), nil)
+}
+
+// Pause pauses monitor id, until the given time if until is non-nil.
+func (c *Client) Pause(id uint, until *time.Time) error {
+	path := fmt.Sprintf("/pause?id=%d", id)
+	if until != nil {
+		path += "&until=" + url.QueryEscape(until.Format(time.RFC3339))
+	}
+	return c.do("POST", path, nil)
+}
+
+func (c *Client) Resume(id uint) error {
+	return c.do("POST", fmt.Sprintf("/resume?id=%d", id), nil)
+}
+
+func (c *Client) do(method, path string, out interface{}) error {
+	// The host is ignored; every request goes to the socket.
+	req, err := http.NewRequest(method, "http://statping"+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,272 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ankityadav/statping/internal/checker"
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+// Server exposes the running checker to CLI commands over a unix socket in
+// the config dir. There is no authentication: the socket is only
+// accessible to the user who owns it.
+type Server struct {
+	db       *storage.Database
+	checker  *checker.Checker
+	onUpdate func()
+	mode     string
+	started  time.Time
+	mux      *http.ServeMux
+	http     *http.Server
+	path     string
+}
+
+// Status describes the running process and its monitors.
+type Status struct {
+	PID      int             `json:"pid"`
+	Mode     string          `json:"mode"`
+	Started  time.Time       `json:"started"`
+	Monitors []MonitorStatus `json:"monitors"`
+}
+
+type MonitorStatus struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	Status    string     `json:"status"`
+	NextCheck *time.Time `json:"next_check,omitempty"`
+	Interval  int        `json:"interval"` // seconds, longer than configured while backing off
+}
+
+// NewServer creates a control server for c. mode names the hosting command
+// ("daemon" or "tray") and is reported by the status endpoint. onUpdate, if
+// non-nil, is called after a change to the monitor set in place of c.Reload.
+func NewServer(db *storage.Database, c *checker.Checker, mode string, onUpdate func()) *Server {
+	s := &Server{
+		db:       db,
+		checker:  c,
+		onUpdate: onUpdate,
+		mode:     mode,
+		started:  time.Now(),
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("/status", s.handleStatus)
+	s.mux.HandleFunc("/reload", s.handleReload)
+	s.mux.HandleFunc("/check", s.handleCheck)
+	s.mux.HandleFunc("/pause", s.handlePause)
+	s.mux.HandleFunc("/resume", s.handleResume)
+	s.mux.HandleFunc("/stats", s.handleStats)
+
+	return s
+}
+
+// Listen binds the socket at path and serves requests in the background.
+// A socket left behind by a process that has exited is replaced; one that
+// still answers belongs to another instance and is an error.
+func (s *Server) Listen(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		if _, err := Dial(path).Status(); err == nil {
+			return fmt.Errorf("control socket %s is in use by another instance", path)
+		}
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+
+	s.path = path
+	s.http = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("control server error", "error", err)
+		}
+	}()
+
+	slog.Debug("control server listening", "socket", path)
+	return nil
+}
+
+// Close stops the server, dropping open connections, and removes the
+// socket. Closing a server that isn't listening does nothing.
+func (s *Server) Close() error {
+	if s.http == nil {
+		return nil
+	}
+	err := s.http.Close()
+	s.http = nil
+	os.Remove(s.path)
+	return err
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	monitors, err := s.db.ListEnabledMonitors()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	running := s.checker.GetStatus()
+	status := Status{
+		PID:      os.Getpid(),
+		Mode:     s.mode,
+		Started:  s.started,
+		Monitors: make([]MonitorStatus, 0, len(monitors)),
+	}
+	for _, m := range monitors {
+		ms := MonitorStatus{ID: m.ID, Name: m.Name, Status: m.DisplayStatus(), Interval: m.CheckInterval}
+		if current, ok := running[m.ID]; ok {
+			next := current.NextCheck
+			ms.Status = current.Status
+			ms.NextCheck = &next
+			ms.Interval = int(current.Delay / time.Second)
+		}
+		status.Monitors = append(status.Monitors, ms)
+	}
+
+	writeJSON(w, status)
+}
+
+// Stats is the running process's internal metrics. Metrics is empty unless
+// they are enabled.
+type Stats struct {
+	Enabled bool               `json:"enabled"`
+	Metrics []telemetry.Sample `json:"metrics,omitempty"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	stats := Stats{Enabled: telemetry.Enabled()}
+	if stats.Enabled {
+		stats.Metrics = telemetry.Snapshot()
+	}
+	writeJSON(w, stats)
+}
+
+func (s *Server) handleReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	if err := s.reload(); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	writeJSON(w, map[string]bool{"success": true})
+}
+
+// handleCheck triggers an immediate check of one monitor, or of all of them
+// when no id is given.
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	if r.URL.Query().Get("id") == "" {
+		s.checker.CheckAll()
+		writeJSON(w, map[string]bool{"success": true})
+		return
+	}
+
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+	if _, running := s.checker.GetStatus()[id]; !running {
+		http.Error(w, "Monitor is not running", 409)
+		return
+	}
+	s.checker.CheckMonitor(id)
+
+	writeJSON(w, map[string]bool{"success": true})
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.setEnabled(w, r, false)
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.setEnabled(w, r, true)
+}
+
+// setEnabled pauses or resumes a monitor. A pause may carry an until time
+// in RFC 3339 after which it expires.
+func (s *Server) setEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	if enabled {
+		err = s.db.ResumeMonitor(id, storage.AuditSourceCLI, "")
+	} else {
+		var until *time.Time
+		if v := r.URL.Query().Get("until"); v != "" {
+			t, perr := time.Parse(time.RFC3339, v)
+			if perr != nil {
+				http.Error(w, "Invalid until", 400)
+				return
+			}
+			until = &t
+		}
+		err = s.db.PauseMonitor(id, until, storage.AuditSourceCLI, "")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if err := s.reload(); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	writeJSON(w, map[string]bool{"success": true, "enabled": enabled})
+}
+
+func (s *Server) reload() error {
+	if s.onUpdate != nil {
+		s.onUpdate()
+		return nil
+	}
+	return s.checker.Reload()
+}
+
+func parseID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
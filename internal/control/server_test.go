@@ -0,0 +1,75 @@
+package control
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/checker"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	db, err := storage.New(storage.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	s := NewServer(db, checker.New(db, nil), "daemon", nil)
+	path := filepath.Join(t.TempDir(), "statping.sock")
+	if err := s.Listen(path); err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func TestCloseWithHungRequest(t *testing.T) {
+	s, path := newTestServer(t)
+	entered := make(chan struct{})
+	s.mux.HandleFunc("/hang", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+	})
+
+	go Dial(path).http.Get("http://statping/hang")
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the server")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked on a hung request")
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Close: %v", err)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	s, path := newTestServer(t)
+	if _, err := Dial(path).Status(); err != nil {
+		t.Fatalf("status before Close: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("first Close: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := Connect(path); err != ErrNotRunning {
+		t.Errorf("Connect after Close: err = %v, want ErrNotRunning", err)
+	}
+}
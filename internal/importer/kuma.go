@@ -0,0 +1,196 @@
+// Package importer converts monitor definitions exported by other
+// monitoring tools into statping monitors.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// Mapping is one monitor from the source file and the statping monitor it
+// becomes. Monitor is nil when it can't be imported; Skipped says why.
+type Mapping struct {
+	Name     string
+	Type     string
+	Monitor  *storage.Monitor
+	Skipped  string
+	Warnings []string
+}
+
+// kumaBool accepts both JSON booleans and the 0/1 integers older Uptime
+// Kuma versions write.
+type kumaBool bool
+
+func (b *kumaBool) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+type kumaBackup struct {
+	MonitorList []kumaMonitor `json:"monitorList"`
+}
+
+type kumaMonitor struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	URL           string   `json:"url"`
+	Method        string   `json:"method"`
+	Interval      int      `json:"interval"`
+	Timeout       float64  `json:"timeout"`
+	MaxRetries    int      `json:"maxretries"`
+	Keyword       string   `json:"keyword"`
+	InvertKeyword kumaBool `json:"invertKeyword"`
+	AcceptedCodes []string `json:"accepted_statuscodes"`
+	Active        kumaBool `json:"active"`
+	IgnoreTLS     kumaBool `json:"ignoreTls"`
+	UpsideDown    kumaBool `json:"upsideDown"`
+	Headers       string   `json:"headers"`
+	Body          string   `json:"body"`
+	Tags          []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// ParseUptimeKuma reads an Uptime Kuma backup (Settings → Backup → Export)
+// and maps its monitors. HTTP and keyword monitors become HTTP monitors and
+// push monitors become heartbeat monitors; every other type is reported as
+// skipped.
+func ParseUptimeKuma(r io.Reader) ([]Mapping, error) {
+	var backup kumaBackup
+	if err := json.NewDecoder(r).Decode(&backup); err != nil {
+		return nil, fmt.Errorf("invalid Uptime Kuma backup: %w", err)
+	}
+
+	mappings := make([]Mapping, 0, len(backup.MonitorList))
+	for _, km := range backup.MonitorList {
+		mappings = append(mappings, mapKumaMonitor(km))
+	}
+	return mappings, nil
+}
+
+func mapKumaMonitor(km kumaMonitor) Mapping {
+	mp := Mapping{Name: km.Name, Type: km.Type}
+
+	m := &storage.Monitor{
+		Name:          km.Name,
+		Enabled:       bool(km.Active),
+		Public:        true,
+		CheckInterval: km.Interval,
+		ExpectedCodes: "200",
+	}
+	if m.CheckInterval <= 0 {
+		m.CheckInterval = config.DefaultCheckInterval
+	}
+	var tags []string
+	for _, t := range km.Tags {
+		tags = append(tags, t.Name)
+	}
+	m.Tags = strings.Join(storage.ParseTags(strings.Join(tags, ",")), ",")
+
+	switch km.Type {
+	case "http", "keyword":
+		if km.URL == "" {
+			mp.Skipped = "no URL"
+			return mp
+		}
+		m.Type = storage.MonitorTypeHTTP
+		m.URL = km.URL
+		m.Timeout = int(math.Ceil(km.Timeout))
+		if m.Timeout <= 0 {
+			m.Timeout = config.DefaultTimeout
+		}
+		// Kuma retries after the first failure; statping counts failures.
+		m.MaxFailures = km.MaxRetries + 1
+		m.SkipTLSVerify = bool(km.IgnoreTLS)
+		if len(km.AcceptedCodes) > 0 {
+			m.ExpectedCodes = strings.Join(km.AcceptedCodes, ",")
+		}
+		if km.Type == "keyword" {
+			if km.InvertKeyword {
+				mp.Skipped = "inverted keyword checks are not supported"
+				return mp
+			}
+			m.Keywords = km.Keyword
+		}
+		if km.Method != "" && !strings.EqualFold(km.Method, "GET") {
+			mp.Warnings = append(mp.Warnings, fmt.Sprintf("method %s becomes GET", strings.ToUpper(km.Method)))
+		}
+		if strings.TrimSpace(km.Headers) != "" || strings.TrimSpace(km.Body) != "" {
+			mp.Warnings = append(mp.Warnings, "custom headers and body are dropped")
+		}
+	case "push":
+		token, err := storage.NewHeartbeatToken()
+		if err != nil {
+			mp.Skipped = err.Error()
+			return mp
+		}
+		m.Type = storage.MonitorTypeHeartbeat
+		m.HeartbeatToken = token
+		m.URL = storage.HeartbeatURL(token)
+		mp.Warnings = append(mp.Warnings, "new ping URL; update the job")
+	case "port", "ping":
+		mp.Skipped = "statping has no " + km.Type + " checks"
+		return mp
+	default:
+		mp.Skipped = "unsupported type"
+		return mp
+	}
+
+	if km.UpsideDown {
+		mp.Warnings = append(mp.Warnings, "upside down mode is ignored")
+	}
+	mp.Monitor = m
+	return mp
+}
+
+// ResolveConflicts skips mappings whose URL already exists, in existing or
+// earlier in mappings, comparing URLs as storage.NormalizeURL does. Name collisions are skipped
+// too, unless suffix is set, in which case it is appended to the name.
+func ResolveConflicts(mappings []Mapping, existing []storage.Monitor, suffix string) {
+	names := make(map[string]bool)
+	urls := make(map[string]bool)
+	for _, m := range existing {
+		names[m.Name] = true
+		urls[storage.NormalizeURL(m.URL)] = true
+	}
+
+	for i := range mappings {
+		mp := &mappings[i]
+		if mp.Monitor == nil {
+			continue
+		}
+		if urls[storage.NormalizeURL(mp.Monitor.URL)] {
+			mp.Skipped = "URL already monitored"
+			mp.Monitor = nil
+			continue
+		}
+		if names[mp.Monitor.Name] {
+			if suffix == "" {
+				mp.Skipped = "name already in use (see --suffix)"
+				mp.Monitor = nil
+				continue
+			}
+			name := mp.Monitor.Name + suffix
+			for n := 2; names[name]; n++ {
+				name = fmt.Sprintf("%s%s%d", mp.Monitor.Name, suffix, n)
+			}
+			mp.Warnings = append(mp.Warnings, "renamed to "+name)
+			mp.Monitor.Name = name
+		}
+		names[mp.Monitor.Name] = true
+		urls[storage.NormalizeURL(mp.Monitor.URL)] = true
+	}
+}
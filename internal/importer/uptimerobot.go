@@ -0,0 +1,301 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// uptimeRobotURL is the UptimeRobot v2 getMonitors endpoint.
+var uptimeRobotURL = "https://api.uptimerobot.com/v2/getMonitors"
+
+const (
+	// uptimeRobotPageSize is the most monitors getMonitors returns at once.
+	uptimeRobotPageSize = 50
+	// uptimeRobotAttempts is how many times a rate limited or failing
+	// request is tried before giving up.
+	uptimeRobotAttempts = 6
+	uptimeRobotMaxWait  = time.Minute
+)
+
+// urInt accepts both numbers and numeric strings, since the UptimeRobot
+// API returns fields such as sub_type and port as either, or as "".
+type urInt int
+
+func (n *urInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*n = urInt(v)
+	return nil
+}
+
+type urResponse struct {
+	Stat  string `json:"stat"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Pagination struct {
+		Offset int `json:"offset"`
+		Limit  int `json:"limit"`
+		Total  int `json:"total"`
+	} `json:"pagination"`
+	Monitors []urMonitor `json:"monitors"`
+}
+
+type urMonitor struct {
+	ID           int64  `json:"id"`
+	FriendlyName string `json:"friendly_name"`
+	URL          string `json:"url"`
+	Type         urInt  `json:"type"`
+	SubType      urInt  `json:"sub_type"`
+	KeywordType  urInt  `json:"keyword_type"`
+	KeywordValue string `json:"keyword_value"`
+	HTTPUsername string `json:"http_username"`
+	HTTPMethod   urInt  `json:"http_method"`
+	Port         urInt  `json:"port"`
+	Interval     int    `json:"interval"`
+	Timeout      int    `json:"timeout"`
+	Status       urInt  `json:"status"`
+}
+
+// UptimeRobot monitor types, keyword types and port sub types.
+const (
+	urTypeHTTP      = 1
+	urTypeKeyword   = 2
+	urTypePing      = 3
+	urTypePort      = 4
+	urTypeHeartbeat = 5
+
+	urKeywordExists    = 1
+	urKeywordNotExists = 2
+
+	urStatusPaused  = 0
+	urMethodGET     = 2
+	urSubTypeCustom = 99
+)
+
+var urTypeNames = map[int]string{
+	urTypeHTTP:      "http",
+	urTypeKeyword:   "keyword",
+	urTypePing:      "ping",
+	urTypePort:      "port",
+	urTypeHeartbeat: "heartbeat",
+}
+
+// urSubTypePorts are the ports of UptimeRobot's predefined port monitors.
+var urSubTypePorts = map[int]int{1: 80, 2: 443, 3: 21, 4: 25, 5: 110, 6: 143}
+
+// FetchUptimeRobot pages through the monitors of the UptimeRobot account
+// apiKey belongs to and maps them. HTTP and keyword monitors become HTTP
+// monitors, port monitors TCP monitors and heartbeat monitors heartbeat
+// monitors; every other type is reported as skipped. Rate limited and
+// failing requests are retried, waiting as long as the API asks.
+func FetchUptimeRobot(ctx context.Context, apiKey string) ([]Mapping, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("an UptimeRobot API key is required")
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	var mappings []Mapping
+	for offset := 0; ; {
+		page, err := fetchUptimeRobotPage(ctx, client, apiKey, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, um := range page.Monitors {
+			mappings = append(mappings, mapUptimeRobotMonitor(um))
+		}
+		offset += len(page.Monitors)
+		if len(page.Monitors) == 0 || offset >= page.Pagination.Total {
+			return mappings, nil
+		}
+	}
+}
+
+func fetchUptimeRobotPage(ctx context.Context, client *http.Client, apiKey string, offset int) (*urResponse, error) {
+	form := url.Values{
+		"api_key": {apiKey},
+		"format":  {"json"},
+		"offset":  {strconv.Itoa(offset)},
+		"limit":   {strconv.Itoa(uptimeRobotPageSize)},
+	}
+
+	wait := 2 * time.Second
+	for attempt := 1; ; attempt++ {
+		page, retryAfter, err := postUptimeRobot(ctx, client, form)
+		if err == nil || retryAfter < 0 || attempt == uptimeRobotAttempts {
+			return page, err
+		}
+		if retryAfter > 0 {
+			wait = min(retryAfter, uptimeRobotMaxWait)
+		}
+		slog.Warn("uptimerobot request failed, retrying", "error", err, "wait", wait, "attempt", attempt)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, uptimeRobotMaxWait)
+	}
+}
+
+// postUptimeRobot makes one getMonitors request. On failure retryAfter is
+// negative if retrying can't help, otherwise how long the API asked to
+// wait, or 0 if it didn't say.
+func postUptimeRobot(ctx context.Context, client *http.Client, form url.Values) (page *urResponse, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", uptimeRobotURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("UptimeRobot request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read UptimeRobot response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		var wait time.Duration
+		if secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); err == nil && secs > 0 {
+			wait = time.Duration(secs) * time.Second
+		}
+		return nil, wait, fmt.Errorf("UptimeRobot returned %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, -1, fmt.Errorf("UptimeRobot returned %d", resp.StatusCode)
+	}
+
+	var r urResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, -1, fmt.Errorf("invalid UptimeRobot response: %w", err)
+	}
+	if r.Stat != "ok" {
+		msg := "unknown error"
+		if r.Error != nil && r.Error.Message != "" {
+			msg = r.Error.Message
+		}
+		return nil, -1, fmt.Errorf("UptimeRobot API error: %s", msg)
+	}
+	return &r, 0, nil
+}
+
+func mapUptimeRobotMonitor(um urMonitor) Mapping {
+	typeName, ok := urTypeNames[int(um.Type)]
+	if !ok {
+		typeName = strconv.Itoa(int(um.Type))
+	}
+	mp := Mapping{Name: um.FriendlyName, Type: typeName}
+
+	m := &storage.Monitor{
+		Name:          um.FriendlyName,
+		Enabled:       um.Status != urStatusPaused,
+		Public:        true,
+		CheckInterval: um.Interval,
+		ExpectedCodes: "200",
+	}
+	if m.CheckInterval <= 0 {
+		m.CheckInterval = config.DefaultCheckInterval
+	}
+
+	switch um.Type {
+	case urTypeHTTP, urTypeKeyword:
+		if um.URL == "" {
+			mp.Skipped = "no URL"
+			return mp
+		}
+		m.Type = storage.MonitorTypeHTTP
+		m.URL = um.URL
+		m.Timeout = um.Timeout
+		if m.Timeout <= 0 {
+			m.Timeout = config.DefaultTimeout
+		}
+		if um.Type == urTypeKeyword {
+			switch um.KeywordType {
+			case urKeywordExists:
+				m.Keywords = um.KeywordValue
+				if strings.Contains(um.KeywordValue, ",") {
+					mp.Warnings = append(mp.Warnings, "keyword is split at its commas")
+				}
+			case urKeywordNotExists:
+				mp.Skipped = "keyword not-exists checks are not supported"
+				return mp
+			default:
+				mp.Skipped = "unknown keyword type"
+				return mp
+			}
+		}
+		if um.HTTPMethod != 0 && um.HTTPMethod != urMethodGET {
+			mp.Warnings = append(mp.Warnings, "custom HTTP method becomes GET")
+		}
+		if um.HTTPUsername != "" {
+			mp.Warnings = append(mp.Warnings, "HTTP authentication is dropped")
+		}
+	case urTypePort:
+		port := int(um.Port)
+		if um.SubType != urSubTypeCustom {
+			port = urSubTypePorts[int(um.SubType)]
+		}
+		if um.URL == "" || port == 0 {
+			mp.Skipped = "no host or port"
+			return mp
+		}
+		target, err := storage.ValidateTCPTarget(net.JoinHostPort(um.URL, strconv.Itoa(port)))
+		if err != nil {
+			mp.Skipped = err.Error()
+			return mp
+		}
+		m.Type = storage.MonitorTypeTCP
+		m.URL = target
+		m.Timeout = um.Timeout
+		if m.Timeout <= 0 {
+			m.Timeout = config.DefaultTimeout
+		}
+	case urTypeHeartbeat:
+		token, err := storage.NewHeartbeatToken()
+		if err != nil {
+			mp.Skipped = err.Error()
+			return mp
+		}
+		m.Type = storage.MonitorTypeHeartbeat
+		m.HeartbeatToken = token
+		m.URL = storage.HeartbeatURL(token)
+		mp.Warnings = append(mp.Warnings, "new ping URL; update the job")
+	case urTypePing:
+		mp.Skipped = "statping has no ping checks"
+		return mp
+	default:
+		mp.Skipped = "unsupported type"
+		return mp
+	}
+
+	mp.Monitor = m
+	return mp
+}
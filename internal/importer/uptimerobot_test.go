@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// uptimeRobotPages are getMonitors responses by offset, as UptimeRobot
+// sends them: numbers sometimes quoted and sometimes empty strings.
+var uptimeRobotPages = map[string]string{
+	"0": `{"stat": "ok", "pagination": {"offset": 0, "limit": 50, "total": 7}, "monitors": [
+		{"id": 1, "friendly_name": "Website", "url": "https://example.com", "type": 1, "sub_type": "", "port": "", "interval": 300, "timeout": 30, "status": 2},
+		{"id": 2, "friendly_name": "Login page", "url": "https://example.com/login", "type": 2, "keyword_type": "1", "keyword_value": "Sign in", "interval": 60, "status": 0},
+		{"id": 3, "friendly_name": "No errors", "url": "https://example.com/health", "type": 2, "keyword_type": 2, "keyword_value": "error", "interval": 60, "status": 2},
+		{"id": 4, "friendly_name": "HTTPS port", "url": "db.example.com", "type": 4, "sub_type": "2", "port": "", "interval": 120, "status": 2}
+	]}`,
+	"4": `{"stat": "ok", "pagination": {"offset": 4, "limit": 50, "total": 7}, "monitors": [
+		{"id": 5, "friendly_name": "Postgres", "url": "db.example.com", "type": 4, "sub_type": 99, "port": "5432", "interval": 60, "status": 2},
+		{"id": 6, "friendly_name": "Router", "url": "10.0.0.1", "type": 3, "interval": 60, "status": 2},
+		{"id": 7, "friendly_name": "Backup job", "url": "", "type": 5, "interval": 86400, "status": 2}
+	]}`,
+}
+
+// fakeUptimeRobot serves uptimeRobotPages in place of the API, rate
+// limiting the first request.
+func fakeUptimeRobot(t *testing.T) (requests *atomic.Int32) {
+	t.Helper()
+	requests = new(atomic.Int32)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		if r.Method != "POST" || r.FormValue("api_key") != "u123-key" {
+			t.Errorf("%s request with API key %q", r.Method, r.FormValue("api_key"))
+		}
+		page, ok := uptimeRobotPages[r.FormValue("offset")]
+		if !ok {
+			t.Errorf("request for offset %q", r.FormValue("offset"))
+			page = `{"stat": "ok", "monitors": []}`
+		}
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(srv.Close)
+
+	saved := uptimeRobotURL
+	uptimeRobotURL = srv.URL
+	t.Cleanup(func() { uptimeRobotURL = saved })
+	return requests
+}
+
+func TestFetchUptimeRobot(t *testing.T) {
+	requests := fakeUptimeRobot(t)
+
+	start := time.Now()
+	mappings, err := FetchUptimeRobot(context.Background(), "u123-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, before the 1s Retry-After", elapsed)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("%d requests, want a retry and two pages", n)
+	}
+	if len(mappings) != 7 {
+		t.Fatalf("%d mappings, want 7", len(mappings))
+	}
+
+	byName := make(map[string]Mapping, len(mappings))
+	for _, mp := range mappings {
+		byName[mp.Name] = mp
+	}
+	for _, tc := range []struct {
+		name          string
+		typ, url      string
+		interval      int
+		enabled       bool
+		keywords      string
+		skippedReason string
+	}{
+		{name: "Website", typ: storage.MonitorTypeHTTP, url: "https://example.com", interval: 300, enabled: true},
+		{name: "Login page", typ: storage.MonitorTypeHTTP, url: "https://example.com/login", interval: 60, keywords: "Sign in"},
+		{name: "No errors", skippedReason: "not-exists"},
+		{name: "HTTPS port", typ: storage.MonitorTypeTCP, url: "db.example.com:443", interval: 120, enabled: true},
+		{name: "Postgres", typ: storage.MonitorTypeTCP, url: "db.example.com:5432", interval: 60, enabled: true},
+		{name: "Router", skippedReason: "ping"},
+		{name: "Backup job", typ: storage.MonitorTypeHeartbeat, interval: 86400, enabled: true},
+	} {
+		mp, ok := byName[tc.name]
+		if !ok {
+			t.Errorf("%s wasn't mapped", tc.name)
+			continue
+		}
+		if tc.skippedReason != "" {
+			if mp.Monitor != nil || !strings.Contains(mp.Skipped, tc.skippedReason) {
+				t.Errorf("%s: skipped %q, want it skipped for %s", tc.name, mp.Skipped, tc.skippedReason)
+			}
+			continue
+		}
+		m := mp.Monitor
+		if m == nil {
+			t.Errorf("%s: skipped: %s", tc.name, mp.Skipped)
+			continue
+		}
+		if m.Type != tc.typ || (tc.url != "" && m.URL != tc.url) || m.CheckInterval != tc.interval ||
+			m.Enabled != tc.enabled || m.Keywords != tc.keywords {
+			t.Errorf("%s mapped to %+v", tc.name, m)
+		}
+	}
+	if hb := byName["Backup job"].Monitor; hb != nil && (hb.HeartbeatToken == "" || hb.URL != storage.HeartbeatURL(hb.HeartbeatToken)) {
+		t.Errorf("heartbeat mapped without a ping token: %+v", hb)
+	}
+}
+
+func TestFetchUptimeRobotAPIError(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"stat": "fail", "error": {"type": "invalid_parameter", "message": "api_key is wrong"}}`))
+	}))
+	defer srv.Close()
+	saved := uptimeRobotURL
+	uptimeRobotURL = srv.URL
+	defer func() { uptimeRobotURL = saved }()
+
+	_, err := FetchUptimeRobot(context.Background(), "bad")
+	if err == nil || !strings.Contains(err.Error(), "api_key is wrong") {
+		t.Errorf("error = %v, want the API's message", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("%d requests, want no retries of a rejected key", n)
+	}
+}
@@ -0,0 +1,799 @@
+// Package jsonschema validates JSON documents against a JSON Schema. It
+// covers the validation keywords of drafts 4 to 2020-12 that API response
+// checks need: types, enums and consts, object properties, arrays, string
+// and number bounds, patterns, the allOf/anyOf/oneOf/not combinators and
+// references within the schema. Annotations and formats are ignored, as
+// are keywords it doesn't know. A schema that refers back to itself must
+// descend into the value on the way, through properties or items, so that
+// validating any document ends.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled schema.
+type Schema struct {
+	at     string // where the schema is in its document, for errors
+	always *bool  // set for the boolean schemas true and false
+	ref    *Schema
+
+	types []string
+	enum  []any
+	konst *any
+
+	properties           map[string]*Schema
+	patternProperties    map[*regexp.Regexp]*Schema
+	additionalProperties *Schema
+	required             []string
+	minProperties        *int
+	maxProperties        *int
+
+	items       *Schema
+	prefixItems []*Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// Error is one way a document fails its schema. Path is a JSON pointer to
+// the offending value, empty for the document itself.
+type Error struct {
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + e.Message
+}
+
+// Compile parses a schema document.
+func Compile(data []byte) (*Schema, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	c := &compiler{root: doc, refs: map[string]*Schema{}}
+	s, err := c.compile(doc, "#")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCycles(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks data against s. It returns the errors found, at most
+// limit of them when limit is positive, or an error when data is not JSON.
+func (s *Schema) Validate(data []byte, limit int) ([]Error, error) {
+	doc, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	v := validation{limit: limit, active: map[visit]bool{}}
+	v.check(s, doc, "")
+	return v.errs, nil
+}
+
+func decode(data []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+type compiler struct {
+	root any
+	refs map[string]*Schema
+}
+
+func (c *compiler) compile(v any, at string) (*Schema, error) {
+	if b, ok := v.(bool); ok {
+		return &Schema{at: at, always: &b}, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: a schema must be an object or a boolean", at)
+	}
+	s := &Schema{at: at}
+
+	if ref, ok := m["$ref"]; ok {
+		r, ok := ref.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/$ref: must be a string", at)
+		}
+		target, err := c.resolve(r, at)
+		if err != nil {
+			return nil, err
+		}
+		s.ref = target
+	}
+
+	var err error
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []any:
+		for _, x := range t {
+			name, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/type: must list type names", at)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s/type: must be a type name or a list of them", at)
+	}
+	for _, t := range s.types {
+		switch t {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, fmt.Errorf("%s/type: unknown type %q", at, t)
+		}
+	}
+
+	if e, ok := m["enum"]; ok {
+		if s.enum, ok = e.([]any); !ok {
+			return nil, fmt.Errorf("%s/enum: must be an array", at)
+		}
+	}
+	if k, ok := m["const"]; ok {
+		s.konst = &k
+	}
+
+	if p, ok := m["properties"]; ok {
+		props, ok := p.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/properties: must be an object", at)
+		}
+		s.properties = map[string]*Schema{}
+		for name, sub := range props {
+			if s.properties[name], err = c.compile(sub, at+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p, ok := m["patternProperties"]; ok {
+		props, ok := p.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/patternProperties: must be an object", at)
+		}
+		s.patternProperties = map[*regexp.Regexp]*Schema{}
+		for pattern, sub := range props {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%s/patternProperties: invalid pattern %q: %v", at, pattern, err)
+			}
+			if s.patternProperties[re], err = c.compile(sub, at+"/patternProperties/"+escape(pattern)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if a, ok := m["additionalProperties"]; ok {
+		if s.additionalProperties, err = c.compile(a, at+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+	if r, ok := m["required"]; ok {
+		list, ok := r.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/required: must be an array of property names", at)
+		}
+		for _, x := range list {
+			name, ok := x.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required: must be an array of property names", at)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+
+	// Before 2020-12, a list of items described a tuple.
+	switch items := m["items"].(type) {
+	case nil:
+	case []any:
+		if s.prefixItems, err = c.compileList(items, at+"/items"); err != nil {
+			return nil, err
+		}
+		if a, ok := m["additionalItems"]; ok {
+			if s.items, err = c.compile(a, at+"/additionalItems"); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		if s.items, err = c.compile(items, at+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if p, ok := m["prefixItems"]; ok {
+		list, ok := p.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/prefixItems: must be an array of schemas", at)
+		}
+		if s.prefixItems, err = c.compileList(list, at+"/prefixItems"); err != nil {
+			return nil, err
+		}
+	}
+	if u, ok := m["uniqueItems"]; ok {
+		if s.uniqueItems, ok = u.(bool); !ok {
+			return nil, fmt.Errorf("%s/uniqueItems: must be a boolean", at)
+		}
+	}
+
+	if p, ok := m["pattern"]; ok {
+		pattern, ok := p.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern: must be a string", at)
+		}
+		if s.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("%s/pattern: invalid pattern %q: %v", at, pattern, err)
+		}
+	}
+
+	for key, dst := range map[string]**int{
+		"minProperties": &s.minProperties, "maxProperties": &s.maxProperties,
+		"minItems": &s.minItems, "maxItems": &s.maxItems,
+		"minLength": &s.minLength, "maxLength": &s.maxLength,
+	} {
+		if *dst, err = count(m, key, at); err != nil {
+			return nil, err
+		}
+	}
+	for key, dst := range map[string]**float64{
+		"minimum": &s.minimum, "maximum": &s.maximum, "multipleOf": &s.multipleOf,
+	} {
+		if *dst, err = number(m, key, at); err != nil {
+			return nil, err
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf: must be greater than 0", at)
+	}
+	// Draft 4 made the exclusive bounds flags on minimum and maximum.
+	for key, bound := range map[string]struct{ dst, plain **float64 }{
+		"exclusiveMinimum": {&s.exclusiveMinimum, &s.minimum},
+		"exclusiveMaximum": {&s.exclusiveMaximum, &s.maximum},
+	} {
+		if flag, ok := m[key].(bool); ok {
+			if flag {
+				*bound.dst, *bound.plain = *bound.plain, nil
+			}
+			continue
+		}
+		if *bound.dst, err = number(m, key, at); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, dst := range map[string]*[]*Schema{"allOf": &s.allOf, "anyOf": &s.anyOf, "oneOf": &s.oneOf} {
+		v, ok := m[key]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]any)
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%s/%s: must be a non-empty array of schemas", at, key)
+		}
+		if *dst, err = c.compileList(list, at+"/"+key); err != nil {
+			return nil, err
+		}
+	}
+	if n, ok := m["not"]; ok {
+		if s.not, err = c.compile(n, at+"/not"); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (c *compiler) compileList(list []any, at string) ([]*Schema, error) {
+	out := make([]*Schema, len(list))
+	for i, sub := range list {
+		var err error
+		if out[i], err = c.compile(sub, at+"/"+strconv.Itoa(i)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// resolve compiles the schema a reference points to. References are
+// compiled once, so recursive schemas terminate.
+func (c *compiler) resolve(ref, at string) (*Schema, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("%s/$ref: %q points outside the schema; only references within it, like \"#/$defs/item\", are supported", at, ref)
+	}
+	if s, ok := c.refs[ref]; ok {
+		return s, nil
+	}
+
+	pointer, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("%s/$ref: invalid reference %q", at, ref)
+	}
+	target := c.root
+	if pointer != "" {
+		if !strings.HasPrefix(pointer, "/") {
+			return nil, fmt.Errorf("%s/$ref: %q is not a JSON pointer; anchors are not supported", at, ref)
+		}
+		for _, token := range strings.Split(pointer[1:], "/") {
+			token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+			next, ok := step(target, token)
+			if !ok {
+				return nil, fmt.Errorf("%s/$ref: %q does not exist in the schema", at, ref)
+			}
+			target = next
+		}
+	}
+
+	// Register a placeholder first so a reference back to this schema
+	// from inside it resolves to it.
+	s := &Schema{}
+	c.refs[ref] = s
+	compiled, err := c.compile(target, ref)
+	if err != nil {
+		delete(c.refs, ref)
+		return nil, err
+	}
+	*s = *compiled
+	return s, nil
+}
+
+// inPlace returns the schemas s applies to the very value it is given.
+func (s *Schema) inPlace() []*Schema {
+	var subs []*Schema
+	if s.ref != nil {
+		subs = append(subs, s.ref)
+	}
+	subs = append(subs, s.allOf...)
+	subs = append(subs, s.anyOf...)
+	subs = append(subs, s.oneOf...)
+	if s.not != nil {
+		subs = append(subs, s.not)
+	}
+	return subs
+}
+
+// subschemas returns every schema s contains or refers to.
+func (s *Schema) subschemas() []*Schema {
+	subs := s.inPlace()
+	for _, sub := range s.properties {
+		subs = append(subs, sub)
+	}
+	for _, sub := range s.patternProperties {
+		subs = append(subs, sub)
+	}
+	for _, sub := range []*Schema{s.additionalProperties, s.items} {
+		if sub != nil {
+			subs = append(subs, sub)
+		}
+	}
+	return append(subs, s.prefixItems...)
+}
+
+// checkCycles rejects a schema that can reach itself through references
+// and combinators alone. Validating with it would apply it to the same
+// value over and over without end.
+func checkCycles(root *Schema) error {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[*Schema]int{}
+	var cycle func(s *Schema) *Schema
+	cycle = func(s *Schema) *Schema {
+		switch state[s] {
+		case visiting:
+			return s
+		case done:
+			return nil
+		}
+		state[s] = visiting
+		for _, sub := range s.inPlace() {
+			if c := cycle(sub); c != nil {
+				return c
+			}
+		}
+		state[s] = done
+		return nil
+	}
+
+	seen := map[*Schema]bool{}
+	pending := []*Schema{root}
+	for len(pending) > 0 {
+		s := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		if c := cycle(s); c != nil {
+			return fmt.Errorf("%s: refers back to itself without descending into a property or item, so no value can be checked against it", c.at)
+		}
+		pending = append(pending, s.subschemas()...)
+	}
+	return nil
+}
+
+// step follows one JSON pointer token from node.
+func step(node any, token string) (any, bool) {
+	switch n := node.(type) {
+	case map[string]any:
+		v, ok := n[token]
+		return v, ok
+	case []any:
+		i, err := strconv.Atoi(token)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, false
+		}
+		return n[i], true
+	}
+	return nil, false
+}
+
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func count(m map[string]any, key, at string) (*int, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	n, ok := v.(json.Number)
+	if ok {
+		if i, err := strconv.Atoi(n.String()); err == nil && i >= 0 {
+			return &i, nil
+		}
+		if f, err := n.Float64(); err == nil && f >= 0 && f == math.Trunc(f) {
+			i := int(f)
+			return &i, nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s: must be a non-negative integer", at, key)
+}
+
+func number(m map[string]any, key, at string) (*float64, error) {
+	v, ok := m[key]
+	if !ok {
+		return nil, nil
+	}
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			return &f, nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s: must be a number", at, key)
+}
+
+type validation struct {
+	errs  []Error
+	limit int
+
+	// active holds the schemas being applied and where, so a schema that
+	// got past checkCycles still can't recurse without end.
+	active map[visit]bool
+}
+
+type visit struct {
+	schema *Schema
+	path   string
+}
+
+func (v *validation) full() bool {
+	return v.limit > 0 && len(v.errs) >= v.limit
+}
+
+func (v *validation) fail(path, format string, args ...any) {
+	if !v.full() {
+		v.errs = append(v.errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// valid reports whether doc, at path, matches s without recording errors.
+func (v *validation) valid(s *Schema, doc any, path string) bool {
+	sub := validation{limit: 1, active: v.active}
+	sub.check(s, doc, path)
+	return len(sub.errs) == 0
+}
+
+func (v *validation) check(s *Schema, doc any, path string) {
+	if v.full() {
+		return
+	}
+	key := visit{s, path}
+	if v.active[key] {
+		v.fail(path, "schema %s refers back to itself here", s.at)
+		return
+	}
+	v.active[key] = true
+	defer delete(v.active, key)
+
+	if s.always != nil {
+		if !*s.always {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	}
+	if s.ref != nil {
+		v.check(s.ref, doc, path)
+	}
+
+	kind := typeOf(doc)
+	if len(s.types) > 0 {
+		ok := false
+		for _, t := range s.types {
+			if t == kind || (t == "number" && kind == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			v.fail(path, "expected %s, got %s", strings.Join(s.types, " or "), kind)
+			return
+		}
+	}
+	if s.enum != nil {
+		ok := false
+		for _, e := range s.enum {
+			if equal(e, doc) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "must be one of %s", show(s.enum))
+		}
+	}
+	if s.konst != nil && !equal(*s.konst, doc) {
+		v.fail(path, "must be %s", show(*s.konst))
+	}
+
+	switch d := doc.(type) {
+	case map[string]any:
+		v.checkObject(s, d, path)
+	case []any:
+		v.checkArray(s, d, path)
+	case string:
+		n := utf8.RuneCountInString(d)
+		if s.minLength != nil && n < *s.minLength {
+			v.fail(path, "must be at least %d characters, got %d", *s.minLength, n)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			v.fail(path, "must be at most %d characters, got %d", *s.maxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(d) {
+			v.fail(path, "must match %q", s.pattern.String())
+		}
+	case json.Number:
+		v.checkNumber(s, d, path)
+	}
+
+	for _, sub := range s.allOf {
+		v.check(sub, doc, path)
+	}
+	if s.anyOf != nil {
+		ok := false
+		for _, sub := range s.anyOf {
+			if v.valid(sub, doc, path) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			v.fail(path, "must match at least one schema in anyOf")
+		}
+	}
+	if s.oneOf != nil {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if v.valid(sub, doc, path) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(path, "must match exactly one schema in oneOf, matched %d", matched)
+		}
+	}
+	if s.not != nil && v.valid(s.not, doc, path) {
+		v.fail(path, "must not match the schema in not")
+	}
+}
+
+func (v *validation) checkObject(s *Schema, d map[string]any, path string) {
+	for _, name := range s.required {
+		if _, ok := d[name]; !ok {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+	if s.minProperties != nil && len(d) < *s.minProperties {
+		v.fail(path, "must have at least %d properties, got %d", *s.minProperties, len(d))
+	}
+	if s.maxProperties != nil && len(d) > *s.maxProperties {
+		v.fail(path, "must have at most %d properties, got %d", *s.maxProperties, len(d))
+	}
+
+	// Sorted so the errors reported first don't change from check to check.
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, at := d[name], path+"/"+escape(name)
+		matched := false
+		if sub, ok := s.properties[name]; ok {
+			matched = true
+			v.check(sub, value, at)
+		}
+		for re, sub := range s.patternProperties {
+			if re.MatchString(name) {
+				matched = true
+				v.check(sub, value, at)
+			}
+		}
+		if !matched && s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				v.fail(path, "property %q is not allowed", name)
+				continue
+			}
+			v.check(s.additionalProperties, value, at)
+		}
+	}
+}
+
+func (v *validation) checkArray(s *Schema, d []any, path string) {
+	if s.minItems != nil && len(d) < *s.minItems {
+		v.fail(path, "must have at least %d items, got %d", *s.minItems, len(d))
+	}
+	if s.maxItems != nil && len(d) > *s.maxItems {
+		v.fail(path, "must have at most %d items, got %d", *s.maxItems, len(d))
+	}
+	for i, item := range d {
+		at := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(s.prefixItems):
+			v.check(s.prefixItems[i], item, at)
+		case s.items != nil:
+			v.check(s.items, item, at)
+		}
+	}
+	if s.uniqueItems {
+		for i := range d {
+			for j := i + 1; j < len(d); j++ {
+				if equal(d[i], d[j]) {
+					v.fail(path, "items %d and %d are equal", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validation) checkNumber(s *Schema, d json.Number, path string) {
+	f, err := d.Float64()
+	if err != nil {
+		return
+	}
+	if s.minimum != nil && f < *s.minimum {
+		v.fail(path, "must be >= %v, got %v", *s.minimum, d)
+	}
+	if s.maximum != nil && f > *s.maximum {
+		v.fail(path, "must be <= %v, got %v", *s.maximum, d)
+	}
+	if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+		v.fail(path, "must be > %v, got %v", *s.exclusiveMinimum, d)
+	}
+	if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+		v.fail(path, "must be < %v, got %v", *s.exclusiveMaximum, d)
+	}
+	if s.multipleOf != nil {
+		q := f / *s.multipleOf
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "must be a multiple of %v, got %v", *s.multipleOf, d)
+		}
+	}
+}
+
+func typeOf(doc any) string {
+	switch d := doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := d.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", doc)
+}
+
+// equal compares JSON values, treating numbers by value so 1 and 1.0 are
+// the same.
+func equal(a, b any) bool {
+	na, aok := a.(json.Number)
+	nb, bok := b.(json.Number)
+	if aok && bok {
+		fa, err1 := na.Float64()
+		fb, err2 := nb.Float64()
+		return err1 == nil && err2 == nil && fa == fb
+	}
+	switch x := a.(type) {
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, xv := range x {
+			yv, ok := y[k]
+			if !ok || !equal(xv, yv) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func show(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil || len(b) > 80 {
+		return "the allowed values"
+	}
+	return string(b)
+}
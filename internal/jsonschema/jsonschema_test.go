@@ -0,0 +1,204 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		schema  string
+		valid   []string
+		invalid []string
+	}{
+		{"true", `true`, []string{`1`, `null`, `{}`}, nil},
+		{"false", `false`, nil, []string{`1`, `null`}},
+		{"type", `{"type": "string"}`, []string{`"a"`}, []string{`1`, `null`, `{}`}},
+		{"type list", `{"type": ["string", "null"]}`, []string{`"a"`, `null`}, []string{`1`}},
+		{"integer", `{"type": "integer"}`, []string{`1`, `1.0`, `-3`}, []string{`1.5`, `"1"`}},
+		{"number", `{"type": "number"}`, []string{`1`, `1.5`}, []string{`"1"`, `true`}},
+		{"boolean", `{"type": "boolean"}`, []string{`true`, `false`}, []string{`0`, `"true"`}},
+		{"object", `{"type": "object"}`, []string{`{}`}, []string{`[]`}},
+		{"array", `{"type": "array"}`, []string{`[]`}, []string{`{}`}},
+		{"enum", `{"enum": ["up", 1, null]}`, []string{`"up"`, `1.0`, `null`}, []string{`"down"`, `2`}},
+		{"const", `{"const": {"ok": true}}`, []string{`{"ok": true}`}, []string{`{"ok": false}`, `{}`}},
+		{"properties", `{"properties": {"status": {"type": "string"}}}`,
+			[]string{`{"status": "up"}`, `{}`, `{"other": 1}`}, []string{`{"status": 1}`}},
+		{"patternProperties", `{"patternProperties": {"^x-": {"type": "integer"}}}`,
+			[]string{`{"x-a": 1, "y": "s"}`}, []string{`{"x-a": "s"}`}},
+		{"additionalProperties false", `{"properties": {"a": {}}, "additionalProperties": false}`,
+			[]string{`{"a": 1}`}, []string{`{"a": 1, "b": 2}`}},
+		{"additionalProperties schema", `{"properties": {"a": {}}, "additionalProperties": {"type": "string"}}`,
+			[]string{`{"a": 1, "b": "s"}`}, []string{`{"b": 2}`}},
+		{"required", `{"required": ["id", "name"]}`, []string{`{"id": 1, "name": "a"}`, `[]`}, []string{`{"id": 1}`}},
+		{"minProperties", `{"minProperties": 1}`, []string{`{"a": 1}`}, []string{`{}`}},
+		{"maxProperties", `{"maxProperties": 1}`, []string{`{"a": 1}`}, []string{`{"a": 1, "b": 2}`}},
+		{"items", `{"items": {"type": "integer"}}`, []string{`[1, 2]`, `[]`}, []string{`[1, "2"]`}},
+		{"items tuple", `{"items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false}`,
+			[]string{`["a", 1]`, `["a"]`}, []string{`[1, 1]`, `["a", 1, 2]`}},
+		{"prefixItems", `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}}`,
+			[]string{`["a", 1, 2]`}, []string{`["a", "b"]`, `[1]`}},
+		{"minItems", `{"minItems": 2}`, []string{`[1, 2]`}, []string{`[1]`}},
+		{"maxItems", `{"maxItems": 1}`, []string{`[1]`}, []string{`[1, 2]`}},
+		{"uniqueItems", `{"uniqueItems": true}`, []string{`[1, 2, {"a": 1}]`}, []string{`[1, 1.0]`, `[{"a": 1}, {"a": 1}]`}},
+		{"minLength", `{"minLength": 2}`, []string{`"ab"`, `"é€"`}, []string{`"a"`, `"é"`}},
+		{"maxLength", `{"maxLength": 2}`, []string{`"ab"`, `"éé"`}, []string{`"abc"`}},
+		{"pattern", `{"pattern": "^v[0-9]+$"}`, []string{`"v12"`, `3`}, []string{`"12"`}},
+		{"minimum", `{"minimum": 1}`, []string{`1`, `2.5`}, []string{`0.9`}},
+		{"maximum", `{"maximum": 1}`, []string{`1`, `-4`}, []string{`1.1`}},
+		{"exclusiveMinimum", `{"exclusiveMinimum": 1}`, []string{`1.1`}, []string{`1`}},
+		{"exclusiveMaximum", `{"exclusiveMaximum": 1}`, []string{`0.9`}, []string{`1`}},
+		{"draft 4 exclusive bounds", `{"minimum": 0, "exclusiveMinimum": true, "maximum": 10, "exclusiveMaximum": true}`,
+			[]string{`5`}, []string{`0`, `10`}},
+		{"multipleOf", `{"multipleOf": 0.1}`, []string{`0.3`, `2`}, []string{`0.35`}},
+		{"allOf", `{"allOf": [{"type": "integer"}, {"minimum": 2}]}`, []string{`3`}, []string{`1`, `2.5`}},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"minimum": 2}]}`, []string{`"a"`, `3`}, []string{`1`}},
+		{"oneOf", `{"oneOf": [{"type": "integer"}, {"minimum": 2}]}`, []string{`1`, `2.5`}, []string{`3`, `1.5`}},
+		{"not", `{"not": {"type": "null"}}`, []string{`1`}, []string{`null`}},
+		{"unknown keywords", `{"format": "email", "x-note": 1, "title": "t"}`, []string{`"not an email"`}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Compile([]byte(tc.schema))
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			for _, doc := range tc.valid {
+				if errs, err := s.Validate([]byte(doc), 0); err != nil || len(errs) > 0 {
+					t.Errorf("%s: got %v, %v; want valid", doc, errs, err)
+				}
+			}
+			for _, doc := range tc.invalid {
+				if errs, err := s.Validate([]byte(doc), 0); err != nil || len(errs) == 0 {
+					t.Errorf("%s: got valid (%v), want errors", doc, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateRefs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		schema  string
+		valid   []string
+		invalid []string
+	}{
+		{"$defs", `{"$defs": {"id": {"type": "integer", "minimum": 1}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`,
+			[]string{`{"id": 3}`}, []string{`{"id": 0}`, `{"id": "3"}`}},
+		{"definitions", `{"definitions": {"name": {"type": "string"}}, "items": {"$ref": "#/definitions/name"}}`,
+			[]string{`["a"]`}, []string{`[1]`}},
+		{"escaped pointer", `{"$defs": {"a/b": {"const": 1}, "c~d": {"const": 2}}, "prefixItems": [{"$ref": "#/$defs/a~1b"}, {"$ref": "#/$defs/c~0d"}]}`,
+			[]string{`[1, 2]`}, []string{`[2, 1]`}},
+		{"ref alongside keywords", `{"$defs": {"s": {"type": "string"}}, "$ref": "#/$defs/s", "maxLength": 2}`,
+			[]string{`"ab"`}, []string{`"abc"`, `1`}},
+		{"recursive tree", `{"type": "object", "required": ["value"], "properties": {"value": {"type": "integer"}, "children": {"type": "array", "items": {"$ref": "#"}}}}`,
+			[]string{`{"value": 1}`, `{"value": 1, "children": [{"value": 2, "children": [{"value": 3}]}]}`},
+			[]string{`{"value": 1, "children": [{"value": 2, "children": [{"value": "3"}]}]}`}},
+		{"recursive $defs", `{"$defs": {"list": {"type": ["object", "null"], "properties": {"next": {"$ref": "#/$defs/list"}}}}, "$ref": "#/$defs/list"}`,
+			[]string{`{"next": {"next": null}}`}, []string{`{"next": {"next": 1}}`}},
+		{"mutually recursive", `{"$defs": {"a": {"items": {"$ref": "#/$defs/b"}}, "b": {"type": "array", "items": {"$ref": "#/$defs/a"}}}, "$ref": "#/$defs/a"}`,
+			[]string{`[[[[]]]]`, `[[1]]`}, []string{`[1]`, `[[], 2]`}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Compile([]byte(tc.schema))
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			for _, doc := range tc.valid {
+				if errs, err := s.Validate([]byte(doc), 0); err != nil || len(errs) > 0 {
+					t.Errorf("%s: got %v, %v; want valid", doc, errs, err)
+				}
+			}
+			for _, doc := range tc.invalid {
+				if errs, err := s.Validate([]byte(doc), 0); err != nil || len(errs) == 0 {
+					t.Errorf("%s: got valid (%v), want errors", doc, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRejects(t *testing.T) {
+	for _, tc := range []struct {
+		name, schema, want string
+	}{
+		{"not JSON", `{"type": `, "not valid JSON"},
+		{"not a schema", `[]`, "must be an object or a boolean"},
+		{"unknown type", `{"type": "date"}`, `unknown type "date"`},
+		{"bad pattern", `{"pattern": "("}`, "invalid pattern"},
+		{"bad pattern property", `{"patternProperties": {"[": {}}}`, "invalid pattern"},
+		{"negative count", `{"minLength": -1}`, "non-negative integer"},
+		{"fractional count", `{"maxItems": 1.5}`, "non-negative integer"},
+		{"zero multipleOf", `{"multipleOf": 0}`, "greater than 0"},
+		{"empty anyOf", `{"anyOf": []}`, "non-empty array"},
+		{"nested error", `{"properties": {"a": {"items": {"type": 1}}}}`, "#/properties/a/items/type"},
+		{"remote ref", `{"$ref": "https://example.com/schema.json"}`, "points outside the schema"},
+		{"anchor ref", `{"$ref": "#item"}`, "anchors are not supported"},
+		{"missing ref", `{"$ref": "#/$defs/nothing"}`, "does not exist"},
+		{"ref to itself", `{"$ref": "#"}`, "refers back to itself"},
+		{"def to itself", `{"$defs": {"a": {"$ref": "#/$defs/a"}}, "properties": {"x": {"$ref": "#/$defs/a"}}}`, "#/$defs/a: refers back to itself"},
+		{"ref chain", `{"$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`, "refers back to itself"},
+		{"through allOf", `{"allOf": [{"$ref": "#"}]}`, "refers back to itself"},
+		{"through not", `{"$defs": {"a": {"not": {"anyOf": [{"$ref": "#/$defs/a"}]}}}, "items": {"$ref": "#/$defs/a"}}`, "refers back to itself"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile([]byte(tc.schema))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Compile(%s) = %v, want an error containing %q", tc.schema, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateGuardsRecursion(t *testing.T) {
+	// A cycle built by hand, as checkCycles would have refused to compile.
+	s := &Schema{at: "#"}
+	s.allOf = []*Schema{s}
+	errs, err := s.Validate([]byte(`1`), 0)
+	if err != nil || len(errs) != 1 || !strings.Contains(errs[0].Message, "refers back to itself") {
+		t.Errorf("got %v, %v; want one error about the cycle", errs, err)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	s, err := Compile([]byte(`{"type": "object", "required": ["a"], "properties": {"b": {"type": "integer"}, "c/d": {"maxLength": 1}}, "additionalProperties": false}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := []byte(`{"b": "x", "c/d": "long", "e": 1, "f": 2}`)
+
+	errs, err := s.Validate(doc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`(root): missing required property "a"`,
+		`/b: expected integer, got string`,
+		`/c~1d: must be at most 1 characters, got 4`,
+		`(root): property "e" is not allowed`,
+		`(root): property "f" is not allowed`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v, want %d", errs, len(want))
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Errorf("error %d = %q, want %q", i, e.Error(), want[i])
+		}
+	}
+
+	for _, limit := range []int{1, 2, 4} {
+		capped, err := s.Validate(doc, limit)
+		if err != nil || len(capped) != limit {
+			t.Errorf("limit %d: got %d errors, %v", limit, len(capped), err)
+		}
+	}
+
+	if _, err := s.Validate([]byte(`{"a": 1} trailing`), 0); err == nil {
+		t.Error("document with trailing data validated")
+	}
+	if _, err := s.Validate([]byte(`<html>`), 0); err == nil {
+		t.Error("non-JSON document validated")
+	}
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Options controls where log records go and which are kept.
+type Options struct {
+	Level string // debug, info, warn or error
+	File  string // path of the log file; empty disables file output
+
+	// Console also writes records to stderr. It must be false for the TUI
+	// modes, where anything on stderr would corrupt the alternate screen.
+	Console bool
+}
+
+// ParseLevel converts a level name into an slog.Level.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q (use debug, info, warn or error)", s)
+}
+
+// Setup installs the default slog logger described by opts. The returned
+// closer releases the log file, if any.
+func Setup(opts Options) (io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var writers []io.Writer
+	var closer io.Closer = nopCloser{}
+
+	if opts.File != "" {
+		f, err := OpenRotating(opts.File, DefaultMaxSize, DefaultBackups)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, f)
+		closer = f
+	}
+	if opts.Console {
+		writers = append(writers, os.Stderr)
+	}
+
+	var out io.Writer = io.Discard
+	switch len(writers) {
+	case 1:
+		out = writers[0]
+	case 2:
+		out = io.MultiWriter(writers...)
+	}
+
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+
+	// SetDefault routes the log package through the handler too. Keep it on
+	// stderr when console output is off so fatal errors printed by the
+	// commands themselves are still seen.
+	if !opts.Console {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}
+
+	return closer, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,264 @@
+// Package metrics exports check results to external time-series stores.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ankityadav/statping/internal/checker"
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const (
+	flushInterval = 10 * time.Second
+	batchSize     = 500
+	queueSize     = 10000
+	maxAttempts   = 3
+	retryDelay    = 2 * time.Second
+)
+
+// InfluxConfig points at an InfluxDB v2 bucket.
+type InfluxConfig struct {
+	URL    string
+	Org    string
+	Bucket string
+	Token  string
+}
+
+// InfluxConfigFromSettings reads the influx.* settings. ok is false when no
+// URL is configured.
+func InfluxConfigFromSettings(db *storage.Database) (cfg InfluxConfig, ok bool) {
+	cfg = InfluxConfig{
+		URL:    db.GetStringSetting(storage.SettingInfluxURL, ""),
+		Org:    db.GetStringSetting(storage.SettingInfluxOrg, ""),
+		Bucket: db.GetStringSetting(storage.SettingInfluxBucket, ""),
+		Token:  db.GetStringSetting(storage.SettingInfluxToken, ""),
+	}
+	return cfg, cfg.URL != ""
+}
+
+// StartInflux subscribes an InfluxSink to c's results if InfluxDB is
+// configured, and returns nil otherwise. A broken configuration is logged
+// rather than stopping monitoring.
+func StartInflux(db *storage.Database, c *checker.Checker) *InfluxSink {
+	cfg, ok := InfluxConfigFromSettings(db)
+	if !ok {
+		return nil
+	}
+	sink, err := NewInfluxSink(cfg)
+	if err != nil {
+		slog.Error("InfluxDB export disabled", "error", err)
+		return nil
+	}
+	c.Subscribe(sink.Record)
+	slog.Info("exporting check results to InfluxDB", "url", cfg.URL, "bucket", cfg.Bucket)
+	return sink
+}
+
+// InfluxSink writes a line-protocol point per check to InfluxDB. Points are
+// queued and written in batches from a background goroutine, so a slow or
+// unreachable server never delays a check; when the queue is full or a
+// batch still fails after retrying, points are dropped and counted.
+type InfluxSink struct {
+	cfg      InfluxConfig
+	writeURL string
+	client   *http.Client
+	queue    chan string
+	dropped  atomic.Uint64
+	lastWarn atomic.Int64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func NewInfluxSink(cfg InfluxConfig) (*InfluxSink, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid InfluxDB URL %q", cfg.URL)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("InfluxDB bucket is not set")
+	}
+
+	q := url.Values{}
+	q.Set("org", cfg.Org)
+	q.Set("bucket", cfg.Bucket)
+	q.Set("precision", "ms")
+	u.Path = strings.TrimRight(u.Path, "/") + "/api/v2/write"
+	u.RawQuery = q.Encode()
+
+	s := &InfluxSink{
+		cfg:      cfg,
+		writeURL: u.String(),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan string, queueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Record queues a point for a completed check. It matches
+// checker.ResultHandler and never blocks.
+func (s *InfluxSink) Record(m storage.Monitor, r storage.CheckResult) {
+	select {
+	case s.queue <- linePoint(m, r):
+	default:
+		// Warn at most once per flush interval rather than for every check.
+		s.dropped.Add(1)
+		now := time.Now().UnixNano()
+		if last := s.lastWarn.Load(); time.Duration(now-last) >= flushInterval && s.lastWarn.CompareAndSwap(last, now) {
+			slog.Warn("dropped metrics points", "reason", "queue full", "total_dropped", s.dropped.Load())
+		}
+	}
+}
+
+// Dropped is the number of points that were never written.
+func (s *InfluxSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close flushes queued points and stops the background writer.
+func (s *InfluxSink) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+func (s *InfluxSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]string, 0, batchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.write(batch)
+			batch = batch[:0]
+		}
+	}
+
+	for {
+		select {
+		case p := <-s.queue:
+			batch = append(batch, p)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case p := <-s.queue:
+					batch = append(batch, p)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write sends a batch, retrying transient failures. Client errors such as
+// a bad token are not retried.
+func (s *InfluxSink) write(batch []string) {
+	body := strings.Join(batch, "\n")
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var retry bool
+		retry, err = s.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			break
+		}
+		select {
+		case <-time.After(retryDelay * time.Duration(attempt)):
+		case <-s.stop:
+			// Shutting down: one last try is all we get.
+			if _, err = s.post(body); err == nil {
+				return
+			}
+			attempt = maxAttempts
+		}
+	}
+	s.drop(len(batch), err.Error())
+}
+
+func (s *InfluxSink) post(body string) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.writeURL, bytes.NewBufferString(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
+		return false, nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("InfluxDB returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, err
+}
+
+func (s *InfluxSink) drop(n int, reason string) {
+	total := s.dropped.Add(uint64(n))
+	slog.Warn("dropped metrics points", "points", n, "total_dropped", total, "reason", reason)
+}
+
+// linePoint formats a check as an InfluxDB line-protocol point.
+func linePoint(m storage.Monitor, r storage.CheckResult) string {
+	status := "down"
+	if r.Success {
+		status = "up"
+	}
+
+	var b strings.Builder
+	b.WriteString("statping_check")
+	b.WriteString(",monitor=" + strconv.FormatUint(uint64(m.ID), 10))
+	b.WriteString(",name=" + escapeTag(m.Name))
+	b.WriteString(",status=" + status)
+	fmt.Fprintf(&b, " response_time=%di,status_code=%di,success=%t", r.ResponseTime, r.StatusCode, r.Success)
+	fmt.Fprintf(&b, " %d", r.CreatedAt.UnixMilli())
+	return b.String()
+}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+
+func escapeTag(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return tagEscaper.Replace(s)
+}
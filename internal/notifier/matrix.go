@@ -0,0 +1,207 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const (
+	matrixAttempts = 3
+	// matrixMaxWait caps how long a rate-limited message waits before
+	// it is retried.
+	matrixMaxWait = time.Minute
+)
+
+var matrixClient = &http.Client{Timeout: 15 * time.Second}
+
+// matrixTxn makes transaction IDs unique within the process; combined with
+// the start time they are unique across restarts too.
+var (
+	matrixTxn     atomic.Uint64
+	matrixTxnBase = strconv.FormatInt(time.Now().UnixNano(), 36)
+)
+
+type matrixConfig struct {
+	homeserver string
+	token      string
+	room       string
+}
+
+// matrixConfig returns the Matrix room to post to, or false when Matrix is
+// not fully configured.
+func (n *Notifier) matrixConfig() (matrixConfig, bool) {
+	if n.db == nil {
+		return matrixConfig{}, false
+	}
+	cfg := matrixConfig{
+		homeserver: strings.TrimRight(n.db.GetStringSetting(storage.SettingMatrixHomeserver, ""), "/"),
+		token:      n.db.GetStringSetting(storage.SettingMatrixToken, ""),
+		room:       n.db.GetStringSetting(storage.SettingMatrixRoom, ""),
+	}
+	return cfg, cfg.homeserver != "" && cfg.token != "" && cfg.room != ""
+}
+
+// sendMatrix posts a message for m in the background if Matrix is set up
+// and m is routed to it.
+func (n *Notifier) sendMatrix(m *storage.Monitor, plain, formatted string) {
+	if !m.NotifiesVia(storage.ChannelMatrix) {
+		return
+	}
+	cfg, ok := n.matrixConfig()
+	if !ok {
+		return
+	}
+	deliver(func() error {
+		err := cfg.send(plain, formatted)
+		if err != nil {
+			slog.Warn("failed to send Matrix notification", "monitor", m.Name, "room", cfg.room, "error", err)
+		}
+		return err
+	})
+}
+
+type matrixError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+// send posts an m.room.message with an HTML body and a plain-text fallback,
+// waiting and retrying when the homeserver rate limits us.
+func (cfg matrixConfig) send(plain, formatted string) error {
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        "m.text",
+		"body":           plain,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": formatted,
+	})
+	if err != nil {
+		return err
+	}
+
+	txn := fmt.Sprintf("statping-%s-%d", matrixTxnBase, matrixTxn.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		cfg.homeserver, url.PathEscape(cfg.room), url.PathEscape(txn))
+
+	for attempt := 1; ; attempt++ {
+		wait, err := cfg.put(endpoint, body)
+		if err == nil {
+			return nil
+		}
+		if wait == 0 || attempt == matrixAttempts {
+			return err
+		}
+		slog.Debug("Matrix rate limit hit, retrying", "room", cfg.room, "wait", wait)
+		time.Sleep(wait)
+	}
+}
+
+// put sends one request. A non-zero wait means the request was rate limited
+// and may be retried after that long; resending with the same transaction
+// ID is safe because the homeserver deduplicates it.
+func (cfg matrixConfig) put(endpoint string, body []byte) (wait time.Duration, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := matrixClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return 0, nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var merr matrixError
+	json.Unmarshal(data, &merr)
+
+	switch {
+	case merr.ErrCode == "M_LIMIT_EXCEEDED" || resp.StatusCode == http.StatusTooManyRequests:
+		wait = time.Duration(merr.RetryAfterMs) * time.Millisecond
+		if wait <= 0 {
+			wait = time.Second
+		}
+		return min(wait, matrixMaxWait), fmt.Errorf("rate limited by the homeserver")
+	case merr.ErrCode == "M_UNKNOWN_TOKEN" || merr.ErrCode == "M_MISSING_TOKEN":
+		return 0, fmt.Errorf("access token rejected (%s); set a new one with 'statping config set matrix-token'", merr.ErrCode)
+	case merr.ErrCode == "M_FORBIDDEN":
+		return 0, fmt.Errorf("not allowed to post in the room; invite the bot user and join it first: %s", merr.Error)
+	case merr.ErrCode != "":
+		return 0, fmt.Errorf("%s: %s", merr.ErrCode, merr.Error)
+	}
+	return 0, fmt.Errorf("homeserver returned %s", resp.Status)
+}
+
+func matrixDown(m *storage.Monitor, errorMsg string) (plain, formatted string) {
+	plain = fmt.Sprintf("🔴 %s is DOWN\n%s\nError: %s", m.Name, matrixTarget(m), errorMsg)
+	formatted = fmt.Sprintf("<p>🔴 <strong>%s is DOWN</strong></p><p>%s<br>Error: <code>%s</code></p>",
+		html.EscapeString(m.Name), matrixTargetHTML(m), html.EscapeString(errorMsg))
+	return plain, formatted
+}
+
+func matrixRecovery(m *storage.Monitor) (plain, formatted string) {
+	plain = fmt.Sprintf("✅ %s is UP\n%s has recovered", m.Name, matrixTarget(m))
+	formatted = fmt.Sprintf("<p>✅ <strong>%s is UP</strong></p><p>%s has recovered</p>",
+		html.EscapeString(m.Name), matrixTargetHTML(m))
+	return plain, formatted
+}
+
+func matrixFirstCheck(m *storage.Monitor, summary string) (plain, formatted string) {
+	plain = fmt.Sprintf("👀 Monitoring started: %s\n%s", m.Name, summary)
+	formatted = fmt.Sprintf("<p>👀 <strong>Monitoring started: %s</strong></p><p>%s</p>",
+		html.EscapeString(m.Name), html.EscapeString(summary))
+	return plain, formatted
+}
+
+func matrixSlow(m *storage.Monitor, detail string) (plain, formatted string) {
+	plain = fmt.Sprintf("🐢 %s is SLOW\n%s\n%s", m.Name, matrixTarget(m), detail)
+	formatted = fmt.Sprintf("<p>🐢 <strong>%s is SLOW</strong></p><p>%s<br>%s</p>",
+		html.EscapeString(m.Name), matrixTargetHTML(m), html.EscapeString(detail))
+	return plain, formatted
+}
+
+func matrixSlowRecovery(m *storage.Monitor, detail string) (plain, formatted string) {
+	plain = fmt.Sprintf("⚡ %s is responsive again\n%s\n%s", m.Name, matrixTarget(m), detail)
+	formatted = fmt.Sprintf("<p>⚡ <strong>%s is responsive again</strong></p><p>%s<br>%s</p>",
+		html.EscapeString(m.Name), matrixTargetHTML(m), html.EscapeString(detail))
+	return plain, formatted
+}
+
+// matrixTarget describes what m checks. A heartbeat's URL holds its ping
+// token, which must not be posted to a shared room.
+func matrixTarget(m *storage.Monitor) string {
+	if m.IsHeartbeat() {
+		return "Heartbeat monitor"
+	}
+	return "URL: " + m.URL
+}
+
+func matrixTargetHTML(m *storage.Monitor) string {
+	if m.IsHeartbeat() {
+		return "Heartbeat monitor"
+	}
+	u := html.EscapeString(m.URL)
+	return fmt.Sprintf("URL: <a href=\"%s\">%s</a>", u, u)
+}
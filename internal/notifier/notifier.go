@@ -1,14 +1,46 @@
 package notifier
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
 	"github.com/gen2brain/beeep"
 )
 
+// Every notification but desktop ones is delivered in the background;
+// these count them.
+var (
+	notificationsPending = telemetry.NewGauge("statping_notifications_pending", "Non-desktop notifications being delivered.")
+	notificationsSent    = telemetry.NewCounter("statping_notifications_sent_total", "Non-desktop notifications delivered.")
+	notificationsFailed  = telemetry.NewCounter("statping_notifications_failed_total", "Non-desktop notifications that could not be delivered.")
+)
+
+// deliver runs send in the background and counts its outcome.
+func deliver(send func() error) {
+	notificationsPending.Add(1)
+	go func() {
+		defer notificationsPending.Add(-1)
+		if err := send(); err != nil {
+			notificationsFailed.Inc()
+			return
+		}
+		notificationsSent.Inc()
+	}()
+}
+
 type Notifier struct {
-	enabled bool
+	mu           sync.RWMutex
+	enabled      bool
+	snoozedUntil time.Time
+	db           *storage.Database
 }
 
 func New() *Notifier {
@@ -17,32 +49,425 @@ func New() *Notifier {
 	}
 }
 
-func (n *Notifier) NotifyDown(name, url, errorMsg string) {
-	if !n.enabled {
+// NewPersistent creates a Notifier whose enabled flag and snooze are kept in
+// the settings table. State is re-read before every notification, so a
+// snooze set from the tray also silences a daemon running alongside it.
+func NewPersistent(db *storage.Database) *Notifier {
+	n := &Notifier{db: db}
+	n.load()
+	return n
+}
+
+func (n *Notifier) load() {
+	if n.db == nil {
+		return
+	}
+	enabled := n.db.GetBoolSetting(storage.SettingNotificationsEnabled, true)
+	until := n.db.GetTimeSetting(storage.SettingSnoozedUntil)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.enabled = enabled
+	n.snoozedUntil = until
+}
+
+func (n *Notifier) muted() bool {
+	n.load()
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return !n.enabled || time.Now().Before(n.snoozedUntil)
+}
+
+// Events only announced on the desktop, as named in the notification log.
+const (
+	eventContentChange     = "content_change"
+	eventProxyDown         = "proxy_down"
+	eventAuthFailure       = "auth_failure"
+	eventLatencyAnomaly    = "latency_anomaly"
+	eventDownSummary       = "down_summary"
+	eventMonitoringResumed = "monitoring_resumed"
+)
+
+// withheld reports whether notifications are off or snoozed. If so the
+// event is written to the notification log instead, so what was missed
+// can be reviewed with `statping notify status`.
+func (n *Notifier) withheld(monitorID uint, event, url, detail string) bool {
+	if !n.muted() {
+		return false
+	}
+	if n.db != nil {
+		d := storage.NotificationDelivery{Channel: storage.DeliveryMuted, MonitorID: monitorID, Event: event, URL: url, Error: detail}
+		if err := n.db.CreateNotificationDelivery(&d); err != nil {
+			slog.Warn("failed to log muted notification", "event", event, "error", err)
+		}
+	}
+	return true
+}
+
+// NotifyDown alerts on every channel m is routed to. Alerts for the same
+// incident are deduplicated by channels that support it.
+func (n *Notifier) NotifyDown(m *storage.Monitor, incidentID uint, errorMsg string) {
+	if n.withheld(m.ID, WebhookDown, m.URL, errorMsg) {
+		return
+	}
+
+	plain, formatted := matrixDown(m, errorMsg)
+	n.sendMatrix(m, plain, formatted)
+	n.sendWebhook(m, WebhookDown, errorMsg)
+	n.sendOpsGenie(m, incidentID, WebhookDown, errorMsg)
+	n.sendTeams(m, WebhookDown, teamsDown(m, errorMsg))
+	n.sendSignal(m, WebhookDown, signalDown(m, errorMsg))
+	n.sendURLs(m, urlDown(m, errorMsg))
+	if !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("🔴 %s is DOWN", m.Name)
+	message := fmt.Sprintf("URL: %s\nError: %s", m.URL, errorMsg)
+
+	if err := beeep.Alert(title, message, ""); err != nil {
+		slog.Warn("failed to send down notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifyRecovery announces that incidentID is over, after downtime, and
+// closes its alerts. The OpsGenie alert is closed even while notifications
+// are muted, since it may have been opened before they were.
+func (n *Notifier) NotifyRecovery(m *storage.Monitor, incidentID uint, downtime time.Duration) {
+	n.sendOpsGenie(m, incidentID, WebhookRecovery, "")
+	if n.withheld(m.ID, WebhookRecovery, m.URL, "down for "+downtime.Round(time.Second).String()) {
+		return
+	}
+
+	plain, formatted := matrixRecovery(m)
+	n.sendMatrix(m, plain, formatted)
+	n.sendWebhook(m, WebhookRecovery, m.Name+" has recovered")
+	n.sendTeams(m, WebhookRecovery, teamsRecovery(m, downtime))
+	n.sendSignal(m, WebhookRecovery, signalRecovery(m, downtime))
+	n.sendURLs(m, urlRecovery(m, downtime))
+	if !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("✅ %s is UP", m.Name)
+	message := fmt.Sprintf("URL: %s has recovered", m.URL)
+
+	if err := beeep.Notify(title, message, ""); err != nil {
+		slog.Warn("failed to send recovery notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifyFirstCheck confirms that a newly added monitor passed its first
+// check, e.g. "example.com is UP, 230ms", on every channel m is routed to
+// except OpsGenie, where only incidents open alerts.
+func (n *Notifier) NotifyFirstCheck(m *storage.Monitor, summary string) {
+	if n.withheld(m.ID, WebhookFirstCheck, m.URL, summary) {
+		return
+	}
+
+	plain, formatted := matrixFirstCheck(m, summary)
+	n.sendMatrix(m, plain, formatted)
+	n.sendWebhook(m, WebhookFirstCheck, summary)
+	n.sendTeams(m, WebhookFirstCheck, teamsFirstCheck(m, summary))
+	n.sendSignal(m, WebhookFirstCheck, signalFirstCheck(m, summary))
+	n.sendURLs(m, urlFirstCheck(m, summary))
+	if !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("👀 Monitoring started: %s", m.Name)
+	if err := beeep.Notify(title, summary, ""); err != nil {
+		slog.Warn("failed to send first check notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifySlow alerts on every channel m is routed to that m opened
+// performance incident incidentID: it is up but responding slower than its
+// latency rule allows.
+func (n *Notifier) NotifySlow(m *storage.Monitor, incidentID uint, detail string) {
+	if n.withheld(m.ID, WebhookSlow, m.URL, detail) {
+		return
+	}
+
+	plain, formatted := matrixSlow(m, detail)
+	n.sendMatrix(m, plain, formatted)
+	n.sendWebhook(m, WebhookSlow, detail)
+	n.sendOpsGenie(m, incidentID, WebhookSlow, detail)
+	n.sendTeams(m, WebhookSlow, teamsSlow(m, detail))
+	n.sendSignal(m, WebhookSlow, signalSlow(m, detail))
+	n.sendURLs(m, urlSlow(m, detail))
+	if !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("🐢 %s is SLOW", m.Name)
+	message := fmt.Sprintf("URL: %s\n%s", m.URL, detail)
+
+	if err := beeep.Alert(title, message, ""); err != nil {
+		slog.Warn("failed to send slow notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifySlowRecovery reports that m's response times are back within its
+// latency rule, closing performance incident incidentID. As with
+// NotifyRecovery, its OpsGenie alert is closed even while muted.
+func (n *Notifier) NotifySlowRecovery(m *storage.Monitor, incidentID uint, detail string) {
+	n.sendOpsGenie(m, incidentID, WebhookSlowRecovery, "")
+	if n.withheld(m.ID, WebhookSlowRecovery, m.URL, detail) {
+		return
+	}
+
+	plain, formatted := matrixSlowRecovery(m, detail)
+	n.sendMatrix(m, plain, formatted)
+	n.sendWebhook(m, WebhookSlowRecovery, detail)
+	n.sendTeams(m, WebhookSlowRecovery, teamsSlowRecovery(m, detail))
+	n.sendSignal(m, WebhookSlowRecovery, signalSlowRecovery(m, detail))
+	n.sendURLs(m, urlSlowRecovery(m, detail))
+	if !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("⚡ %s is responsive again", m.Name)
+	message := fmt.Sprintf("URL: %s\n%s", m.URL, detail)
+
+	if err := beeep.Notify(title, message, ""); err != nil {
+		slog.Warn("failed to send slow recovery notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifyContentChange reports that a watched page's content changed.
+func (n *Notifier) NotifyContentChange(name, url, summary string) {
+	if n.withheld(0, eventContentChange, url, name+": "+summary) {
+		return
+	}
+
+	title := fmt.Sprintf("📝 %s changed", name)
+	message := fmt.Sprintf("URL: %s\n%s", url, summary)
+
+	if err := beeep.Notify(title, message, ""); err != nil {
+		slog.Warn("failed to send content change notification", "monitor", name, "error", err)
+	}
+}
+
+// NotifyProxyDown reports that checks could not reach their proxy. It is
+// sent once per proxy rather than under each affected monitor's name.
+func (n *Notifier) NotifyProxyDown(proxy, errorMsg string) {
+	if n.withheld(0, eventProxyDown, proxy, errorMsg) {
 		return
 	}
 
-	title := fmt.Sprintf("🔴 %s is DOWN", name)
-	message := fmt.Sprintf("URL: %s\nError: %s", url, errorMsg)
+	title := "🟠 Proxy unreachable"
+	message := fmt.Sprintf("Proxy: %s\nChecks through it can't run\nError: %s", proxy, errorMsg)
 
 	if err := beeep.Alert(title, message, ""); err != nil {
-		log.Printf("Failed to send notification: %v", err)
+		slog.Warn("failed to send proxy notification", "proxy", proxy, "error", err)
 	}
 }
 
-func (n *Notifier) NotifyRecovery(name, url string) {
-	if !n.enabled {
+// NotifyAuthFailure reports that m's checks can't run because its OAuth2
+// token endpoint failed. The monitored API may well be up, so this is not
+// sent as a down alert.
+func (n *Notifier) NotifyAuthFailure(m *storage.Monitor, errorMsg string) {
+	if n.withheld(m.ID, eventAuthFailure, m.URL, errorMsg) || !m.NotifiesVia(storage.ChannelDesktop) {
 		return
 	}
 
-	title := fmt.Sprintf("✅ %s is UP", name)
-	message := fmt.Sprintf("URL: %s has recovered", url)
+	title := fmt.Sprintf("🟠 %s: auth failure", m.Name)
+	message := fmt.Sprintf("Token URL: %s\nChecks can't run until a token is issued\nError: %s", m.OAuth2TokenURL, errorMsg)
+
+	if err := beeep.Alert(title, message, ""); err != nil {
+		slog.Warn("failed to send auth failure notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifyAnomaly reports that several of m's recent response times were far
+// above its baseline. It is low priority, so it goes to the desktop only
+// and never as an alert.
+func (n *Notifier) NotifyAnomaly(m *storage.Monitor, summary string) {
+	if n.withheld(m.ID, eventLatencyAnomaly, m.URL, summary) || !m.NotifiesVia(storage.ChannelDesktop) {
+		return
+	}
+
+	title := fmt.Sprintf("📈 %s: unusual latency", m.Name)
+	message := fmt.Sprintf("URL: %s\n%s", m.URL, summary)
 
 	if err := beeep.Notify(title, message, ""); err != nil {
-		log.Printf("Failed to send notification: %v", err)
+		slog.Warn("failed to send anomaly notification", "monitor", m.Name, "error", err)
+	}
+}
+
+// NotifyDownSummary sends a single alert listing every monitor that is
+// currently down, e.g. when notifications are resumed after a snooze.
+func (n *Notifier) NotifyDownSummary(names []string) {
+	if len(names) == 0 || n.withheld(0, eventDownSummary, "", strings.Join(names, ", ")) {
+		return
+	}
+
+	title := fmt.Sprintf("🔴 %d monitor(s) DOWN", len(names))
+	message := strings.Join(names, "\n")
+
+	if err := beeep.Alert(title, message, ""); err != nil {
+		slog.Warn("failed to send down summary notification", "count", len(names), "error", err)
 	}
 }
 
+// NotifyMonitoringResumed reports that checks are running again after the
+// checker was not seen for gap, so uptime has a hole.
+func (n *Notifier) NotifyMonitoringResumed(gap time.Duration) {
+	if n.withheld(0, eventMonitoringResumed, "", "no checks for "+gap.Round(time.Second).String()) {
+		return
+	}
+
+	title := "⏯️ Monitoring resumed"
+	message := fmt.Sprintf("No checks ran for %s, e.g. because statping wasn't running or the machine slept", gap)
+
+	if err := beeep.Notify(title, message, ""); err != nil {
+		slog.Warn("failed to send monitoring resumed notification", "error", err)
+	}
+}
+
+// Test sends a desktop notification regardless of the mute settings and
+// returns the error from the notification backend.
+func (n *Notifier) Test() error {
+	return beeep.Notify("Statping", "Test notification from statping doctor", "")
+}
+
+// TestChannel sends a test notification on channel and waits for it to be
+// delivered, regardless of the mute settings. The OpsGenie test alert is
+// closed again right away; "teams" posts to every Teams webhook and
+// "teams:<name>" to one.
+func (n *Notifier) TestChannel(channel string) error {
+	if channel == storage.ChannelTeams || strings.HasPrefix(channel, storage.ChannelTeams+":") {
+		hooks := n.teamsWebhooks(&storage.Monitor{Channels: channel})
+		if len(hooks) == 0 {
+			return fmt.Errorf("no matching Teams webhook is configured; set teams-webhooks")
+		}
+		body, err := json.Marshal(teamsCardMessage("accent", "Test notification from statping", []teamsFact{{Title: "Sent by", Value: "statping test-notify"}}))
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, h := range hooks {
+			if err := postTeams(n.db, h.URL, WebhookTest, 0, body); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", h.Name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	switch channel {
+	case storage.ChannelDesktop:
+		return n.Test()
+	case storage.ChannelMatrix:
+		cfg, ok := n.matrixConfig()
+		if !ok {
+			return fmt.Errorf("Matrix is not configured; set matrix-homeserver, matrix-token and matrix-room")
+		}
+		return cfg.send("Test notification from statping", "<p>Test notification from statping</p>")
+	case storage.ChannelWebhook:
+		url := ""
+		if n.db != nil {
+			url = n.db.GetStringSetting(storage.SettingWebhookURL, "")
+		}
+		if url == "" {
+			return fmt.Errorf("no webhook is configured; set webhook-url")
+		}
+		body, err := json.Marshal(WebhookPayload{Event: WebhookTest, Message: "Test notification from statping", Timestamp: time.Now().UTC()})
+		if err != nil {
+			return err
+		}
+		_, err = DeliverWebhook(n.db, url, n.db.GetStringSetting(storage.SettingWebhookSecret, ""), WebhookTest, 0, body)
+		return err
+	case storage.ChannelSignal:
+		cfg, ok := n.signalConfig()
+		if !ok {
+			return fmt.Errorf("Signal is not configured; set signal-recipients")
+		}
+		if cfg.rpcURL == "" {
+			if _, err := exec.LookPath(cfg.binary); err != nil {
+				return fmt.Errorf("signal-cli not found (%v); install it or set signal-cli-path", err)
+			}
+		}
+		return cfg.send(n.db, WebhookTest, 0, "Test notification from statping")
+	case storage.ChannelOpsGenie:
+		cfg, ok := n.opsgenieConfig()
+		if !ok {
+			return fmt.Errorf("OpsGenie is not configured; set opsgenie-api-key")
+		}
+		alias := fmt.Sprintf("statping-test-%d", time.Now().Unix())
+		alert := opsgenieAlert{
+			Message:     "Test alert from statping",
+			Alias:       alias,
+			Description: "Sent by 'statping test-notify'; it is closed again right away.",
+			Source:      "statping",
+			Priority:    "P5",
+		}
+		if err := cfg.create(n.db, WebhookTest, 0, alert); err != nil {
+			return err
+		}
+		return cfg.close(n.db, WebhookTest, 0, alias, "Test complete")
+	}
+	if channels := n.urlChannels(&storage.Monitor{Channels: channel}); len(channels) > 0 {
+		return n.TestURLs(channels)
+	}
+	return fmt.Errorf("no %s channel is configured", channel)
+}
+
+// TestURLs sends a test message on each of channels and waits for them.
+func (n *Notifier) TestURLs(channels []URLChannel) error {
+	var errs []error
+	msg := Message{Event: WebhookTest, Title: "Statping", Body: "Test notification from statping"}
+	for _, ch := range channels {
+		if err := SendURL(n.db, ch, 0, msg); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 func (n *Notifier) SetEnabled(enabled bool) {
+	n.mu.Lock()
 	n.enabled = enabled
+	n.mu.Unlock()
+
+	if n.db != nil {
+		if err := n.db.SetBoolSetting(storage.SettingNotificationsEnabled, enabled); err != nil {
+			slog.Error("failed to persist notification setting", "error", err)
+		}
+	}
+}
+
+// IsEnabled reports whether notifications are on, re-reading the flag
+// since another process may have changed it.
+func (n *Notifier) IsEnabled() bool {
+	n.load()
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.enabled
+}
+
+// Snooze mutes all notifications until the given time. A zero time resumes
+// notifications immediately.
+func (n *Notifier) Snooze(until time.Time) {
+	n.mu.Lock()
+	n.snoozedUntil = until
+	n.mu.Unlock()
+
+	if n.db != nil {
+		if err := n.db.SetTimeSetting(storage.SettingSnoozedUntil, until); err != nil {
+			slog.Error("failed to persist snooze state", "error", err)
+		}
+	}
+}
+
+func (n *Notifier) SnoozedUntil() time.Time {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.snoozedUntil
+}
+
+func (n *Notifier) IsSnoozed() bool {
+	return time.Now().Before(n.SnoozedUntil())
 }
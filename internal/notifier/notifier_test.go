@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// newTestDB opens an empty in-memory database.
+func newTestDB(t *testing.T) *storage.Database {
+	t.Helper()
+	db, err := storage.New(storage.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSlowAndFirstCheckReachTeams(t *testing.T) {
+	titles := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg teamsMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("decode card: %v", err)
+		} else {
+			titles <- msg.Attachments[0].Content.Body[0].Items[0].Text
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	db := newTestDB(t)
+	if err := db.SetSetting(storage.SettingTeamsWebhooks, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	n := NewPersistent(db)
+	m := &storage.Monitor{ID: 1, Name: "api", Type: storage.MonitorTypeHTTP, URL: "https://api.example.com", Channels: storage.ChannelTeams}
+
+	n.NotifyFirstCheck(m, "api is UP, 230ms")
+	n.NotifySlow(m, 7, "p95 2.1s over 15m, threshold 2s")
+	n.NotifySlowRecovery(m, 7, "p95 0.4s over 15m")
+
+	want := map[string]bool{
+		"👀 Monitoring started: api": true,
+		"🐢 api is SLOW":             true,
+		"⚡ api is responsive again": true,
+	}
+	for len(want) > 0 {
+		select {
+		case title := <-titles:
+			if !want[title] {
+				t.Errorf("unexpected card %q", title)
+			}
+			delete(want, title)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("cards never posted: %v", want)
+		}
+	}
+}
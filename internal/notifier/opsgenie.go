@@ -0,0 +1,269 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// OpsGenie regions. Accounts hosted in the EU must use the EU endpoint.
+const (
+	OpsGenieRegionUS = "us"
+	OpsGenieRegionEU = "eu"
+)
+
+const (
+	opsgenieAttempts = 4
+	// opsgenieMaxWait caps how long a rate-limited or failed request
+	// waits before it is retried.
+	opsgenieMaxWait = time.Minute
+	// opsgenieMessageLimit is the longest alert message OpsGenie accepts.
+	opsgenieMessageLimit = 130
+)
+
+var opsgenieClient = &http.Client{Timeout: 15 * time.Second}
+
+// opsgenieEndpoints are the Alert API base URLs per region.
+var opsgenieEndpoints = map[string]string{
+	OpsGenieRegionUS: "https://api.opsgenie.com/v2/alerts",
+	OpsGenieRegionEU: "https://api.eu.opsgenie.com/v2/alerts",
+}
+
+type opsgenieConfig struct {
+	apiKey   string
+	endpoint string
+}
+
+// opsgenieConfig returns the OpsGenie account to alert, or false when no
+// API key is set.
+func (n *Notifier) opsgenieConfig() (opsgenieConfig, bool) {
+	if n.db == nil {
+		return opsgenieConfig{}, false
+	}
+	endpoint, ok := opsgenieEndpoints[strings.ToLower(n.db.GetStringSetting(storage.SettingOpsGenieRegion, OpsGenieRegionUS))]
+	if !ok {
+		endpoint = opsgenieEndpoints[OpsGenieRegionUS]
+	}
+	cfg := opsgenieConfig{
+		apiKey:   n.db.GetStringSetting(storage.SettingOpsGenieAPIKey, ""),
+		endpoint: endpoint,
+	}
+	return cfg, cfg.apiKey != ""
+}
+
+// opsgenieAlias identifies the alert for an incident, so repeated down
+// notifications for the same incident update one alert and the recovery
+// closes it.
+func opsgenieAlias(monitorID, incidentID uint) string {
+	return fmt.Sprintf("statping-%d-%d", monitorID, incidentID)
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Entity      string            `json:"entity,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// sendOpsGenie opens or closes the alert for m's incident in the
+// background if OpsGenie is set up and m is routed to it. Down and slow
+// events open it; their recoveries close it.
+func (n *Notifier) sendOpsGenie(m *storage.Monitor, incidentID uint, event, errorMsg string) {
+	if !m.NotifiesVia(storage.ChannelOpsGenie) {
+		return
+	}
+	cfg, ok := n.opsgenieConfig()
+	if !ok {
+		return
+	}
+	alias := opsgenieAlias(m.ID, incidentID)
+	deliver(func() error {
+		var err error
+		switch event {
+		case WebhookRecovery:
+			err = cfg.close(n.db, event, m.ID, alias, m.Name+" has recovered")
+		case WebhookSlowRecovery:
+			err = cfg.close(n.db, event, m.ID, alias, m.Name+" is responsive again")
+		case WebhookSlow:
+			err = cfg.create(n.db, event, m.ID, opsgenieSlow(m, alias, errorMsg))
+		default:
+			err = cfg.create(n.db, event, m.ID, opsgenieDown(m, alias, errorMsg))
+		}
+		if err != nil {
+			slog.Warn("failed to send OpsGenie alert", "monitor", m.Name, "alias", alias, "event", event, "error", err)
+		}
+		return err
+	})
+}
+
+func opsgenieDown(m *storage.Monitor, alias, errorMsg string) opsgenieAlert {
+	alert := opsgenieOpen(m, alias)
+	alert.Message = truncateMessage(m.Name+" is DOWN: "+errorMsg, opsgenieMessageLimit)
+	alert.Description = fmt.Sprintf("%s\nError: %s", matrixTarget(m), errorMsg)
+	return alert
+}
+
+func opsgenieSlow(m *storage.Monitor, alias, detail string) opsgenieAlert {
+	alert := opsgenieOpen(m, alias)
+	alert.Message = truncateMessage(m.Name+" is SLOW: "+detail, opsgenieMessageLimit)
+	alert.Description = fmt.Sprintf("%s\n%s", matrixTarget(m), detail)
+	return alert
+}
+
+// opsgenieOpen fills in the fields every alert about m shares.
+func opsgenieOpen(m *storage.Monitor, alias string) opsgenieAlert {
+	priority := m.OpsGeniePriority
+	if priority == "" {
+		priority = storage.DefaultOpsGeniePriority
+	}
+	details := map[string]string{"monitor_id": strconv.FormatUint(uint64(m.ID), 10), "type": m.Type}
+	if !m.IsHeartbeat() {
+		details["url"] = m.URL
+	}
+	return opsgenieAlert{
+		Alias:    alias,
+		Tags:     storage.ParseTags(m.Tags),
+		Details:  details,
+		Entity:   m.Name,
+		Source:   "statping",
+		Priority: priority,
+	}
+}
+
+func truncateMessage(s string, limit int) string {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s
+	}
+	return string(r[:limit-1]) + "…"
+}
+
+// create opens an alert. OpsGenie deduplicates open alerts by alias, so
+// sending it again for the same incident only bumps its count.
+func (cfg opsgenieConfig) create(db *storage.Database, event string, monitorID uint, alert opsgenieAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return cfg.post(db, event, monitorID, cfg.endpoint, body)
+}
+
+// close closes the alert with alias.
+func (cfg opsgenieConfig) close(db *storage.Database, event string, monitorID uint, alias, note string) error {
+	body, err := json.Marshal(opsgenieClose{Source: "statping", Note: note})
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/%s/close?identifierType=alias", cfg.endpoint, url.PathEscape(alias))
+	return cfg.post(db, event, monitorID, endpoint, body)
+}
+
+// post sends body to endpoint, retrying network errors, rate limits and
+// 5xx responses with exponential backoff. Attempts are recorded in the
+// notification log when db is not nil.
+func (cfg opsgenieConfig) post(db *storage.Database, event string, monitorID uint, endpoint string, body []byte) error {
+	var err error
+	for attempt := 1; attempt <= opsgenieAttempts; attempt++ {
+		d, wait := cfg.postOnce(endpoint, body)
+		d.Channel = storage.ChannelOpsGenie
+		d.MonitorID = monitorID
+		d.Event = event
+		d.Attempt = attempt
+		if db != nil {
+			if err := db.CreateNotificationDelivery(&d); err != nil {
+				slog.Error("failed to record OpsGenie delivery", "error", err)
+			}
+		}
+		if d.Success {
+			return nil
+		}
+
+		err = fmt.Errorf("%s", d.Error)
+		if d.StatusCode != 0 && d.StatusCode < 500 && d.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+		if attempt < opsgenieAttempts {
+			if wait == 0 {
+				wait = time.Duration(1<<(attempt-1)) * 2 * time.Second
+			}
+			wait = min(wait, opsgenieMaxWait)
+			slog.Info("OpsGenie request failed, retrying", "event", event, "attempt", attempt, "wait", wait, "error", err)
+			time.Sleep(wait)
+		}
+	}
+	return err
+}
+
+// postOnce makes a single request. A non-zero wait is how long OpsGenie
+// asked us to wait before retrying.
+func (cfg opsgenieConfig) postOnce(endpoint string, body []byte) (d storage.NotificationDelivery, wait time.Duration) {
+	d = storage.NotificationDelivery{URL: endpoint}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		d.Error = err.Error()
+		return d, 0
+	}
+	req.Header.Set("Authorization", "GenieKey "+cfg.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := opsgenieClient.Do(req)
+	d.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		d.Error = err.Error()
+		return d, 0
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookSnippet))
+	d.StatusCode = resp.StatusCode
+	d.ResponseSnippet = string(snippet)
+	d.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	switch {
+	case d.Success:
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		d.Error = "API key rejected (" + resp.Status + "); set a new one with 'statping config set opsgenie-api-key'"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		d.Error = "rate limited by OpsGenie"
+		wait = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+	default:
+		d.Error = "OpsGenie returned " + resp.Status
+	}
+	return d, wait
+}
+
+// retryAfter returns how long a Retry-After header value, either seconds or
+// an HTTP date, asks us to wait, or 0 if it doesn't say.
+func retryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+func TestOpsGenieHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	var retried atomic.Int64
+	start := time.Now()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		retried.Store(int64(time.Since(start)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	cfg := opsgenieConfig{apiKey: "key", endpoint: srv.URL}
+	if err := cfg.create(nil, WebhookDown, 1, opsgenieAlert{Message: "api is DOWN", Alias: "statping-1-1"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("%d requests, want a rate-limited one and its retry", n)
+	}
+	// Without Retry-After the first retry would wait 2s.
+	if got := time.Duration(retried.Load()); got < time.Second || got >= 2*time.Second {
+		t.Errorf("retried after %s, want the 1s OpsGenie asked for", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"3", 3 * time.Second},
+		{" 10 ", 10 * time.Second},
+		{"-1", 0},
+		{"Fri, 02 Jan 2026 03:04:35 GMT", 30 * time.Second},
+		{"Fri, 02 Jan 2026 03:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestOpsGenieClosesWhileMuted(t *testing.T) {
+	requests := make(chan string, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+	saved := opsgenieEndpoints[OpsGenieRegionUS]
+	opsgenieEndpoints[OpsGenieRegionUS] = srv.URL
+	defer func() { opsgenieEndpoints[OpsGenieRegionUS] = saved }()
+
+	db := newTestDB(t)
+	if err := db.SetSetting(storage.SettingOpsGenieAPIKey, "key"); err != nil {
+		t.Fatal(err)
+	}
+	n := NewPersistent(db)
+	m := &storage.Monitor{ID: 1, Name: "api", Type: storage.MonitorTypeHTTP, URL: "https://api.example.com", Channels: storage.ChannelOpsGenie}
+
+	n.NotifyDown(m, 7, "connection refused")
+	if path := <-requests; path != "/" {
+		t.Fatalf("down alert posted to %s", path)
+	}
+
+	// Snoozed during the outage: nothing new is opened, but the alert
+	// opened before is still closed.
+	if err := db.SetTimeSetting(storage.SettingSnoozedUntil, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	n.NotifyDown(m, 8, "connection refused")
+	n.NotifyRecovery(m, 7, 5*time.Minute)
+	n.NotifySlowRecovery(m, 9, "p95 0.4s over 15m")
+
+	want := map[string]bool{
+		"/statping-1-7/close": true,
+		"/statping-1-9/close": true,
+	}
+	for len(want) > 0 {
+		select {
+		case path := <-requests:
+			if !want[path] {
+				t.Errorf("unexpected request to %s while snoozed", path)
+			}
+			delete(want, path)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("alerts never closed: %v", want)
+		}
+	}
+	select {
+	case path := <-requests:
+		t.Errorf("unexpected request to %s while snoozed", path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
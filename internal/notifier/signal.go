@@ -0,0 +1,219 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// DefaultSignalCLI is the signal-cli binary used when no path is set; it
+// is looked up in PATH.
+const DefaultSignalCLI = "signal-cli"
+
+// signalTimeout bounds one send. signal-cli starts a JVM and may have to
+// sync with the server first, so it can take a while.
+const signalTimeout = 90 * time.Second
+
+var (
+	signalRPCClient = &http.Client{Timeout: signalTimeout}
+	signalRPCID     atomic.Uint64
+
+	// signalMissing makes a missing binary a single warning rather than
+	// an error on every alert.
+	signalMissing sync.Once
+)
+
+type signalConfig struct {
+	binary     string
+	account    string
+	rpcURL     string
+	recipients []string
+}
+
+// signalConfig returns how to reach Signal, or false when no recipients
+// are set.
+func (n *Notifier) signalConfig() (signalConfig, bool) {
+	if n.db == nil {
+		return signalConfig{}, false
+	}
+	cfg := signalConfig{
+		binary:     n.db.GetStringSetting(storage.SettingSignalCLI, DefaultSignalCLI),
+		account:    n.db.GetStringSetting(storage.SettingSignalAccount, ""),
+		rpcURL:     n.db.GetStringSetting(storage.SettingSignalRPCURL, ""),
+		recipients: ParseSignalRecipients(n.db.GetStringSetting(storage.SettingSignalRecipients, "")),
+	}
+	return cfg, len(cfg.recipients) > 0
+}
+
+// ParseSignalRecipients splits a comma- or space-separated list of phone
+// numbers, usernames or group IDs.
+func ParseSignalRecipients(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+// sendSignal sends text to the Signal recipients in the background if
+// Signal is set up and m is routed to it.
+func (n *Notifier) sendSignal(m *storage.Monitor, event, text string) {
+	if !m.NotifiesVia(storage.ChannelSignal) {
+		return
+	}
+	cfg, ok := n.signalConfig()
+	if !ok {
+		return
+	}
+	if cfg.rpcURL == "" {
+		if _, err := exec.LookPath(cfg.binary); err != nil {
+			signalMissing.Do(func() {
+				slog.Warn("signal-cli not found, skipping Signal notifications; install it or set signal-cli-path", "path", cfg.binary, "error", err)
+			})
+			return
+		}
+	}
+	deliver(func() error {
+		err := cfg.send(n.db, event, m.ID, text)
+		if err != nil {
+			slog.Warn("failed to send Signal notification", "monitor", m.Name, "error", err)
+		}
+		return err
+	})
+}
+
+// send delivers text through the JSON-RPC daemon when one is configured
+// and by running signal-cli otherwise, and records the attempt in the
+// notification log when db is not nil.
+func (cfg signalConfig) send(db *storage.Database, event string, monitorID uint, text string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), signalTimeout)
+	defer cancel()
+
+	d := storage.NotificationDelivery{Channel: storage.ChannelSignal, MonitorID: monitorID, Event: event, Attempt: 1}
+	start := time.Now()
+	var err error
+	if cfg.rpcURL != "" {
+		d.URL = cfg.rpcURL
+		err = cfg.sendRPC(ctx, text)
+	} else {
+		d.URL = cfg.binary
+		err = cfg.exec(ctx, text)
+	}
+	d.Latency = time.Since(start).Milliseconds()
+	d.Success = err == nil
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if db != nil {
+		if err := db.CreateNotificationDelivery(&d); err != nil {
+			slog.Error("failed to record Signal delivery", "error", err)
+		}
+	}
+	return err
+}
+
+// exec runs "signal-cli [-a account] send -m text recipient...". Its
+// stderr is returned in the error, since that is where signal-cli says
+// what went wrong.
+func (cfg signalConfig) exec(ctx context.Context, text string) error {
+	var args []string
+	if cfg.account != "" {
+		args = append(args, "-a", cfg.account)
+	}
+	args = append(args, "send", "-m", text)
+	args = append(args, cfg.recipients...)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.binary, args...)
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("signal-cli did not finish within %s", signalTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+type signalRPCRequest struct {
+	JSONRPC string         `json:"jsonrpc"`
+	Method  string         `json:"method"`
+	Params  map[string]any `json:"params"`
+	ID      uint64         `json:"id"`
+}
+
+type signalRPCResponse struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// sendRPC calls "send" on a signal-cli daemon started with --http, e.g.
+// http://127.0.0.1:8080/api/v1/rpc.
+func (cfg signalConfig) sendRPC(ctx context.Context, text string) error {
+	params := map[string]any{"recipient": cfg.recipients, "message": text}
+	if cfg.account != "" {
+		params["account"] = cfg.account
+	}
+	body, err := json.Marshal(signalRPCRequest{JSONRPC: "2.0", Method: "send", Params: params, ID: signalRPCID.Add(1)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", cfg.rpcURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := signalRPCClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("signal-cli daemon returned %s", resp.Status)
+	}
+	var rpcResp signalRPCResponse
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		return fmt.Errorf("invalid response from signal-cli daemon: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("signal-cli daemon: %s (code %d)", rpcResp.Error.Message, rpcResp.Error.Code)
+	}
+	return nil
+}
+
+func signalDown(m *storage.Monitor, errorMsg string) string {
+	return fmt.Sprintf("🔴 %s is DOWN\n%s\nError: %s", m.Name, matrixTarget(m), errorMsg)
+}
+
+func signalRecovery(m *storage.Monitor, downtime time.Duration) string {
+	return fmt.Sprintf("✅ %s is UP\n%s has recovered after %s", m.Name, matrixTarget(m), downtime.Round(time.Second))
+}
+
+func signalFirstCheck(m *storage.Monitor, summary string) string {
+	return fmt.Sprintf("👀 Monitoring started: %s\n%s", m.Name, summary)
+}
+
+func signalSlow(m *storage.Monitor, detail string) string {
+	return fmt.Sprintf("🐢 %s is SLOW\n%s\n%s", m.Name, matrixTarget(m), detail)
+}
+
+func signalSlowRecovery(m *storage.Monitor, detail string) string {
+	return fmt.Sprintf("⚡ %s is responsive again\n%s\n%s", m.Name, matrixTarget(m), detail)
+}
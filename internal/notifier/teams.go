@@ -0,0 +1,300 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const (
+	teamsAttempts = 3
+	// Teams rejects messages over about 28KB. A card holds a name and at
+	// most two long texts, such as the URL and the error; capping each at
+	// this many bytes of JSON keeps cards well below it.
+	teamsErrorLimit = 8000
+	teamsNameLimit  = 800
+)
+
+var teamsClient = &http.Client{Timeout: 15 * time.Second}
+
+// teamsMessage is the body of an incoming webhook post carrying one
+// Adaptive Card.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+// teamsElement is the subset of Adaptive Card elements the cards use.
+type teamsElement struct {
+	Type   string         `json:"type"`
+	Style  string         `json:"style,omitempty"`
+	Bleed  bool           `json:"bleed,omitempty"`
+	Items  []teamsElement `json:"items,omitempty"`
+	Text   string         `json:"text,omitempty"`
+	Size   string         `json:"size,omitempty"`
+	Weight string         `json:"weight,omitempty"`
+	Color  string         `json:"color,omitempty"`
+	Wrap   bool           `json:"wrap,omitempty"`
+	Facts  []teamsFact    `json:"facts,omitempty"`
+}
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsCardMessage builds a card with a coloured header, "attention" for
+// red, "warning" for amber, "good" for green or "accent" for blue, and a
+// fact list.
+func teamsCardMessage(style, title string, facts []teamsFact) teamsMessage {
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body: []teamsElement{
+					{Type: "Container", Style: style, Bleed: true, Items: []teamsElement{
+						{Type: "TextBlock", Text: title, Size: "Large", Weight: "Bolder", Color: style, Wrap: true},
+					}},
+					{Type: "FactSet", Facts: facts},
+				},
+			},
+		}},
+	}
+}
+
+func teamsDown(m *storage.Monitor, errorMsg string) teamsMessage {
+	name := teamsText(m.Name, teamsNameLimit)
+	return teamsCardMessage("attention", "🔴 "+name+" is DOWN", []teamsFact{
+		teamsTargetFact(m),
+		{Title: "Error", Value: teamsText(errorMsg, teamsErrorLimit)},
+		{Title: "Consecutive failures", Value: strconv.Itoa(m.ConsecutiveFails)},
+	})
+}
+
+func teamsRecovery(m *storage.Monitor, downtime time.Duration) teamsMessage {
+	name := teamsText(m.Name, teamsNameLimit)
+	return teamsCardMessage("good", "✅ "+name+" is UP", []teamsFact{
+		teamsTargetFact(m),
+		{Title: "Outage duration", Value: downtime.Round(time.Second).String()},
+	})
+}
+
+func teamsFirstCheck(m *storage.Monitor, summary string) teamsMessage {
+	name := teamsText(m.Name, teamsNameLimit)
+	return teamsCardMessage("accent", "👀 Monitoring started: "+name, []teamsFact{
+		teamsTargetFact(m),
+		{Title: "First check", Value: teamsText(summary, teamsErrorLimit)},
+	})
+}
+
+func teamsSlow(m *storage.Monitor, detail string) teamsMessage {
+	name := teamsText(m.Name, teamsNameLimit)
+	return teamsCardMessage("warning", "🐢 "+name+" is SLOW", []teamsFact{
+		teamsTargetFact(m),
+		{Title: "Latency", Value: teamsText(detail, teamsErrorLimit)},
+	})
+}
+
+func teamsSlowRecovery(m *storage.Monitor, detail string) teamsMessage {
+	name := teamsText(m.Name, teamsNameLimit)
+	return teamsCardMessage("good", "⚡ "+name+" is responsive again", []teamsFact{
+		teamsTargetFact(m),
+		{Title: "Latency", Value: teamsText(detail, teamsErrorLimit)},
+	})
+}
+
+// teamsTargetFact describes what m checks, leaving out a heartbeat's ping
+// token.
+func teamsTargetFact(m *storage.Monitor) teamsFact {
+	if m.IsHeartbeat() {
+		return teamsFact{Title: "Type", Value: "Heartbeat monitor"}
+	}
+	return teamsFact{Title: "URL", Value: teamsText(m.URL, teamsErrorLimit)}
+}
+
+// teamsEscaper backslash-escapes the characters Teams would otherwise read
+// as Markdown, so error text such as a stack trace is shown as sent.
+var teamsEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "#", `\#`, "~", `\~`,
+)
+
+func teamsEscape(s string) string {
+	return teamsEscaper.Replace(s)
+}
+
+// teamsText escapes s for a card, cut with "…" so that it takes at most
+// limit bytes once encoded as JSON. Escaping and encoding can make a
+// character several times longer, e.g. < is sent as \u0026lt;, so
+// counting characters doesn't bound the size of the card.
+func teamsText(s string, limit int) string {
+	escaped := teamsEscape(s)
+	if jsonLen(escaped) <= limit {
+		return escaped
+	}
+	const ellipsis = "…"
+	var b strings.Builder
+	size := jsonLen(ellipsis)
+	for _, r := range s {
+		e := teamsEscape(string(r))
+		n := jsonLen(e)
+		if size+n > limit {
+			break
+		}
+		b.WriteString(e)
+		size += n
+	}
+	return b.String() + ellipsis
+}
+
+// jsonLen returns the length of s encoded as a JSON string, without quotes.
+func jsonLen(s string) int {
+	b, _ := json.Marshal(s)
+	return len(b) - 2
+}
+
+// teamsWebhooks returns the configured Teams webhooks m is routed to.
+func (n *Notifier) teamsWebhooks(m *storage.Monitor) []storage.TeamsWebhook {
+	if n.db == nil || !m.NotifiesVia(storage.ChannelTeams) {
+		return nil
+	}
+	hooks, err := storage.ParseTeamsWebhooks(n.db.GetStringSetting(storage.SettingTeamsWebhooks, ""))
+	if err != nil {
+		slog.Warn("ignoring invalid teams-webhooks setting", "error", err)
+		return nil
+	}
+	names, all := m.TeamsWebhooks()
+	if all {
+		return hooks
+	}
+	var out []storage.TeamsWebhook
+	for _, h := range hooks {
+		for _, name := range names {
+			if h.Name == name {
+				out = append(out, h)
+			}
+		}
+	}
+	return out
+}
+
+// sendTeams posts msg to each Teams webhook m is routed to, in the
+// background.
+func (n *Notifier) sendTeams(m *storage.Monitor, event string, msg teamsMessage) {
+	hooks := n.teamsWebhooks(m)
+	if len(hooks) == 0 {
+		return
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		slog.Warn("failed to encode Teams card", "monitor", m.Name, "error", err)
+		return
+	}
+	for _, h := range hooks {
+		deliver(func() error {
+			err := postTeams(n.db, h.URL, event, m.ID, body)
+			if err != nil {
+				slog.Warn("failed to send Teams notification", "monitor", m.Name, "webhook", h.Name, "error", err)
+			}
+			return err
+		})
+	}
+}
+
+// postTeams posts body to a Teams webhook, retrying network errors, rate
+// limits and 5xx responses. Attempts are recorded in the notification log
+// when db is not nil.
+func postTeams(db *storage.Database, url, event string, monitorID uint, body []byte) error {
+	var err error
+	for attempt := 1; attempt <= teamsAttempts; attempt++ {
+		d := postTeamsOnce(url, body)
+		d.Channel = storage.ChannelTeams
+		d.MonitorID = monitorID
+		d.Event = event
+		d.Attempt = attempt
+		if db != nil {
+			if err := db.CreateNotificationDelivery(&d); err != nil {
+				slog.Error("failed to record Teams delivery", "error", err)
+			}
+		}
+		if d.Success {
+			return nil
+		}
+
+		err = fmt.Errorf("%s", d.Error)
+		if d.StatusCode != 0 && d.StatusCode < 500 && d.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+		if attempt < teamsAttempts {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+	}
+	return err
+}
+
+func postTeamsOnce(url string, body []byte) storage.NotificationDelivery {
+	// The webhook URL embeds its secret; log only the host.
+	d := storage.NotificationDelivery{URL: redactWebhookURL(url)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := teamsClient.Do(req)
+	d.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookSnippet))
+	d.StatusCode = resp.StatusCode
+	d.ResponseSnippet = string(snippet)
+	d.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !d.Success {
+		d.Error = "Teams returned " + resp.Status
+	}
+	return d
+}
+
+func redactWebhookURL(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return "(invalid URL)"
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	return scheme + "://" + host + "/…"
+}
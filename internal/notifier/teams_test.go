@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// teamsMaxBody is the largest message Teams accepts.
+const teamsMaxBody = 28 * 1024
+
+func TestTeamsCards(t *testing.T) {
+	api := &storage.Monitor{ID: 1, Name: "API *prod*", Type: storage.MonitorTypeHTTP, URL: "https://api.example.com/health?a=1&b=2", ConsecutiveFails: 3}
+	backup := &storage.Monitor{ID: 2, Name: "nightly backup", Type: storage.MonitorTypeHeartbeat, URL: storage.HeartbeatURL("secret-token")}
+	// A stack trace full of characters that escaping makes longer, well
+	// past the error limit.
+	trace := strings.Repeat("panic: <nil> map[string]*_Foo_ `x` [1] #2 ~3\n", 500)
+
+	tests := []struct {
+		name string
+		msg  teamsMessage
+	}{
+		{"down", teamsDown(api, "unexpected status 503 (want 200)")},
+		{"down_escaped", teamsDown(api, "body lacks `ok`: got <html># *Error* [retry]_later_ ~now")},
+		{"down_long", teamsDown(api, trace)},
+		{"down_heartbeat", teamsDown(backup, "no ping for 25h")},
+		{"recovery", teamsRecovery(api, 5*time.Minute+3*time.Second)},
+		{"first_check", teamsFirstCheck(api, "API *prod* is UP, 230ms")},
+		{"slow", teamsSlow(api, "p95 2.1s over 15m, threshold 2s")},
+		{"slow_recovery", teamsSlowRecovery(backup, "p95 0.4s over 15m")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(body) > teamsMaxBody {
+				t.Errorf("card is %d bytes, over Teams' %d", len(body), teamsMaxBody)
+			}
+			if strings.Contains(string(body), "secret-token") {
+				t.Error("card shows a heartbeat's ping token")
+			}
+
+			var got bytes.Buffer
+			if err := json.Indent(&got, body, "", "  "); err != nil {
+				t.Fatal(err)
+			}
+			got.WriteByte('\n')
+			golden := filepath.Join("testdata", "teams_"+tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("card differs from %s:\n%s", golden, got.String())
+			}
+		})
+	}
+}
+
+func TestTeamsCardSize(t *testing.T) {
+	// Every character here grows when escaped for Markdown and again when
+	// encoded as JSON.
+	m := &storage.Monitor{
+		Name:             strings.Repeat("<", 1000),
+		Type:             storage.MonitorTypeHTTP,
+		URL:              "https://example.com/?q=" + strings.Repeat("<", 5000),
+		ConsecutiveFails: 3,
+	}
+	for name, msg := range map[string]teamsMessage{
+		"down":  teamsDown(m, strings.Repeat("<", 50000)),
+		"slow":  teamsSlow(m, strings.Repeat("<", 50000)),
+		"first": teamsFirstCheck(m, strings.Repeat("<", 50000)),
+	} {
+		body, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(body) > teamsMaxBody {
+			t.Errorf("%s card is %d bytes, over Teams' %d", name, len(body), teamsMaxBody)
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// slackChannel posts to a Slack incoming webhook,
+// slack://hook/<A>/<B>/<C> for https://hooks.slack.com/services/<A>/<B>/<C>.
+type slackChannel struct {
+	tokens []string
+}
+
+func newSlackChannel(u *url.URL) (URLChannel, error) {
+	tokens := pathSegments(u)
+	if len(tokens) > 0 && tokens[0] == "hook" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) != 3 {
+		return nil, fmt.Errorf("slack URL needs the three tokens of the webhook: slack://hook/<T…>/<B…>/<secret>")
+	}
+	return slackChannel{tokens: tokens}, nil
+}
+
+func (c slackChannel) Kind() string { return storage.ChannelSlack }
+
+func (c slackChannel) String() string {
+	return "slack://hook/" + c.tokens[0] + "/" + c.tokens[1] + "/" + mask(c.tokens[2])
+}
+
+func (c slackChannel) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]string{"text": "*" + msg.Title + "*\n" + msg.Body})
+	if err != nil {
+		return err
+	}
+	return postURL(ctx, "https://hooks.slack.com/services/"+strings.Join(c.tokens, "/"), "application/json", body, nil)
+}
+
+// telegramChannel sends through a Telegram bot,
+// telegram://<bot token>@<chat id>[/<chat id>…].
+type telegramChannel struct {
+	token string
+	chats []string
+}
+
+func newTelegramChannel(u *url.URL) (URLChannel, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("telegram URL needs the bot token: telegram://<bot token>@<chat id>")
+	}
+	token := u.User.Username()
+	if p, ok := u.User.Password(); ok {
+		token += ":" + p
+	}
+	chats := pathSegments(u)
+	if len(chats) == 0 {
+		return nil, fmt.Errorf("telegram URL needs at least one chat ID: telegram://<bot token>@<chat id>")
+	}
+	return telegramChannel{token: token, chats: chats}, nil
+}
+
+func (c telegramChannel) Kind() string { return storage.ChannelTelegram }
+
+func (c telegramChannel) String() string {
+	return "telegram://" + mask(c.token) + "@" + strings.Join(c.chats, "/")
+}
+
+func (c telegramChannel) Send(ctx context.Context, msg Message) error {
+	for _, chat := range c.chats {
+		body, err := json.Marshal(map[string]any{
+			"chat_id":                  chat,
+			"text":                     msg.Title + "\n" + msg.Body,
+			"disable_web_page_preview": true,
+		})
+		if err != nil {
+			return err
+		}
+		if err := postURL(ctx, "https://api.telegram.org/bot"+c.token+"/sendMessage", "application/json", body, nil); err != nil {
+			return fmt.Errorf("chat %s: %w", chat, err)
+		}
+	}
+	return nil
+}
+
+// emailChannel sends mail through an SMTP server,
+// mailto://<user>:<password>@<smtp host>[:port]?to=<addr>[,<addr>…][&from=<addr>].
+// Port 465 uses implicit TLS; other ports upgrade with STARTTLS when the
+// server offers it.
+type emailChannel struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+	to       []string
+}
+
+func newEmailChannel(u *url.URL) (URLChannel, error) {
+	c := emailChannel{host: u.Hostname(), port: u.Port()}
+	if c.host == "" {
+		return nil, fmt.Errorf("mailto URL needs the SMTP server: mailto://<user>:<password>@<smtp host>?to=<address>")
+	}
+	if c.port == "" {
+		c.port = "587"
+	}
+	if u.User != nil {
+		c.user = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+
+	q := u.Query()
+	for _, addr := range strings.Split(q.Get("to"), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("mailto URL has an invalid recipient %q", addr)
+		}
+		c.to = append(c.to, addr)
+	}
+	if len(c.to) == 0 {
+		return nil, fmt.Errorf("mailto URL needs recipients: add ?to=<address>[,<address>…]")
+	}
+
+	c.from = q.Get("from")
+	if c.from == "" && strings.Contains(c.user, "@") {
+		c.from = c.user
+	}
+	if c.from == "" {
+		c.from = "statping@" + c.host
+	}
+	if _, err := mail.ParseAddress(c.from); err != nil {
+		return nil, fmt.Errorf("mailto URL has an invalid sender %q", c.from)
+	}
+	return c, nil
+}
+
+func (c emailChannel) Kind() string { return storage.ChannelEmail }
+
+func (c emailChannel) String() string {
+	userinfo := ""
+	if c.user != "" {
+		userinfo = c.user + ":****@"
+	}
+	return fmt.Sprintf("mailto://%s%s:%s?to=%s", userinfo, c.host, c.port, strings.Join(c.to, ","))
+}
+
+func (c emailChannel) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(c.host, c.port)
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	var conn net.Conn
+	var err error
+	if c.port == "465" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: c.host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && c.port != "465" {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.user, c.password, c.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.from); err != nil {
+		return err
+	}
+	for _, to := range c.to {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		c.from, strings.Join(c.to, ", "), mimeHeader(msg.Title), time.Now().Format(time.RFC1123Z),
+		strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// mimeHeader encodes s for a header when it isn't plain ASCII.
+func mimeHeader(s string) string {
+	for _, r := range s {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", s)
+		}
+	}
+	return s
+}
+
+// ntfyChannel publishes to an ntfy topic, ntfy://<topic> on ntfy.sh or
+// ntfy://[<user>:<password>@]<host>/<topic> on a self-hosted server.
+// Both are reached over HTTPS.
+type ntfyChannel struct {
+	server   string
+	topic    string
+	user     string
+	password string
+	priority string
+}
+
+func newNtfyChannel(u *url.URL) (URLChannel, error) {
+	parts := pathSegments(u)
+	c := ntfyChannel{server: "ntfy.sh", priority: u.Query().Get("priority")}
+	switch len(parts) {
+	case 1:
+		c.topic = parts[0]
+	case 2:
+		c.server, c.topic = parts[0], parts[1]
+	default:
+		return nil, fmt.Errorf("ntfy URL needs a topic: ntfy://<topic> or ntfy://<host>/<topic>")
+	}
+	if u.User != nil {
+		c.user = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	switch c.priority {
+	case "", "1", "2", "3", "4", "5", "min", "low", "default", "high", "max", "urgent":
+	default:
+		return nil, fmt.Errorf("ntfy priority %q: use 1 to 5 or min, low, default, high, max", c.priority)
+	}
+	return c, nil
+}
+
+func (c ntfyChannel) Kind() string { return storage.ChannelNtfy }
+
+func (c ntfyChannel) String() string {
+	userinfo := ""
+	if c.user != "" {
+		userinfo = c.user + ":****@"
+	}
+	return "ntfy://" + userinfo + c.server + "/" + c.topic
+}
+
+func (c ntfyChannel) Send(ctx context.Context, msg Message) error {
+	header := http.Header{}
+	header.Set("Title", mimeHeader(msg.Title))
+	if c.priority != "" {
+		header.Set("Priority", c.priority)
+	} else if msg.Event == WebhookDown {
+		header.Set("Priority", "high")
+	}
+	if c.user != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.user+":"+c.password)))
+	}
+	return postURL(ctx, "https://"+c.server+"/"+url.PathEscape(c.topic), "text/plain; charset=utf-8", []byte(msg.Body), header)
+}
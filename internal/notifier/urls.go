@@ -0,0 +1,211 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// URLChannel is a notification channel configured by a URL such as
+// slack://hook/T000/B000/XXXX, in the style of Apprise and Shoutrrr.
+type URLChannel interface {
+	// Kind is the channel monitors are routed to it by, e.g. "slack".
+	Kind() string
+	// String is the URL with its secrets masked.
+	String() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is what a URL channel delivers.
+type Message struct {
+	Event string
+	Title string
+	Body  string
+}
+
+// urlSchemes maps a URL scheme to the constructor of its channel.
+var urlSchemes = map[string]func(u *url.URL) (URLChannel, error){
+	"slack":    newSlackChannel,
+	"telegram": newTelegramChannel,
+	"tgram":    newTelegramChannel,
+	"mailto":   newEmailChannel,
+	"ntfy":     newNtfyChannel,
+}
+
+// URLSchemes lists the schemes ParseNotifyURL understands.
+func URLSchemes() []string {
+	return []string{"slack", "telegram", "mailto", "ntfy"}
+}
+
+// ParseNotifyURL parses one channel URL.
+func ParseNotifyURL(raw string) (URLChannel, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("malformed notification URL: %w", err)
+	}
+	newChannel, ok := urlSchemes[strings.ToLower(u.Scheme)]
+	if !ok {
+		schemes := URLSchemes()
+		list := strings.Join(schemes[:len(schemes)-1], "://, ") + ":// or " + schemes[len(schemes)-1] + "://"
+		if u.Scheme == "" {
+			return nil, fmt.Errorf("notification URL %q has no scheme: start it with %s", raw, list)
+		}
+		return nil, fmt.Errorf("unsupported notification URL scheme %q: use %s", u.Scheme, list)
+	}
+	return newChannel(u)
+}
+
+// ParseNotifyURLs parses the notify-urls setting, a whitespace-separated
+// list of channel URLs.
+func ParseNotifyURLs(s string) ([]URLChannel, error) {
+	var channels []URLChannel
+	for i, raw := range strings.Fields(s) {
+		ch, err := ParseNotifyURL(raw)
+		if err != nil {
+			return nil, fmt.Errorf("notification URL %d: %w", i+1, err)
+		}
+		channels = append(channels, ch)
+	}
+	return channels, nil
+}
+
+// urlChannels returns the URL channels m is routed to.
+func (n *Notifier) urlChannels(m *storage.Monitor) []URLChannel {
+	if n.db == nil {
+		return nil
+	}
+	channels, err := ParseNotifyURLs(n.db.GetStringSetting(storage.SettingNotifyURLs, ""))
+	if err != nil {
+		slog.Warn("ignoring invalid notify-urls setting", "error", err)
+		return nil
+	}
+	var out []URLChannel
+	for _, ch := range channels {
+		if m.NotifiesVia(ch.Kind()) {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// sendURLs delivers msg in the background to every URL channel m is
+// routed to.
+func (n *Notifier) sendURLs(m *storage.Monitor, msg Message) {
+	for _, ch := range n.urlChannels(m) {
+		deliver(func() error {
+			err := SendURL(n.db, ch, m.ID, msg)
+			if err != nil {
+				slog.Warn("failed to send notification", "monitor", m.Name, "channel", ch.String(), "error", err)
+			}
+			return err
+		})
+	}
+}
+
+// SendURL delivers msg on ch and records the attempt in the notification
+// log when db is not nil.
+func SendURL(db *storage.Database, ch URLChannel, monitorID uint, msg Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.Send(ctx, msg)
+	d := storage.NotificationDelivery{
+		Channel:   ch.Kind(),
+		MonitorID: monitorID,
+		Event:     msg.Event,
+		URL:       ch.String(),
+		Attempt:   1,
+		Latency:   time.Since(start).Milliseconds(),
+		Success:   err == nil,
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if db != nil {
+		if err := db.CreateNotificationDelivery(&d); err != nil {
+			slog.Error("failed to record delivery", "channel", d.URL, "error", err)
+		}
+	}
+	return err
+}
+
+var urlClient = &http.Client{Timeout: 15 * time.Second}
+
+// postURL sends body to endpoint and turns a non-2xx response into an
+// error that quotes the start of the response.
+func postURL(ctx context.Context, endpoint, contentType string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := urlClient.Do(req)
+	if err != nil {
+		// The endpoint may hold a token; keep it out of the error.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			return uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if s := strings.TrimSpace(string(snippet)); s != "" {
+		return fmt.Errorf("%s: %s", resp.Status, s)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+// pathSegments returns the non-empty segments of u's host and path.
+func pathSegments(u *url.URL) []string {
+	var out []string
+	for _, s := range append([]string{u.Host}, strings.Split(u.Path, "/")...) {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func mask(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return secret[:4] + "****"
+}
+
+func urlDown(m *storage.Monitor, errorMsg string) Message {
+	return Message{Event: WebhookDown, Title: "🔴 " + m.Name + " is DOWN", Body: matrixTarget(m) + "\nError: " + errorMsg}
+}
+
+func urlRecovery(m *storage.Monitor, downtime time.Duration) Message {
+	return Message{Event: WebhookRecovery, Title: "✅ " + m.Name + " is UP", Body: fmt.Sprintf("%s has recovered after %s", matrixTarget(m), downtime.Round(time.Second))}
+}
+
+func urlFirstCheck(m *storage.Monitor, summary string) Message {
+	return Message{Event: WebhookFirstCheck, Title: "👀 Monitoring started: " + m.Name, Body: summary}
+}
+
+func urlSlow(m *storage.Monitor, detail string) Message {
+	return Message{Event: WebhookSlow, Title: "🐢 " + m.Name + " is SLOW", Body: matrixTarget(m) + "\n" + detail}
+}
+
+func urlSlowRecovery(m *storage.Monitor, detail string) Message {
+	return Message{Event: WebhookSlowRecovery, Title: "⚡ " + m.Name + " is responsive again", Body: matrixTarget(m) + "\n" + detail}
+}
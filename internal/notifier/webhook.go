@@ -0,0 +1,168 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// Webhook events.
+const (
+	WebhookDown         = "down"
+	WebhookRecovery     = "recovery"
+	WebhookFirstCheck   = "first_check"
+	WebhookSlow         = "slow"
+	WebhookSlowRecovery = "slow_recovery"
+	WebhookTest         = "test"
+)
+
+// WebhookSignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>" of
+// "<unix time>.<body>" keyed with the webhook secret. Receivers should
+// reject timestamps older than a few minutes so a captured request can't
+// be replayed.
+const WebhookSignatureHeader = "X-Statping-Signature"
+
+const (
+	webhookAttempts = 3
+	webhookSnippet  = 512
+)
+
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+// WebhookPayload is the JSON body posted to the webhook.
+type WebhookPayload struct {
+	Event     string          `json:"event"`
+	Monitor   *WebhookMonitor `json:"monitor,omitempty"`
+	Message   string          `json:"message"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// WebhookMonitor identifies the monitor an event is about. A heartbeat's
+// URL holds its ping token, so it is left out.
+type WebhookMonitor struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	URL  string `json:"url,omitempty"`
+}
+
+// SignWebhook returns the signature header value for body sent at t.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook posts an event about m in the background if a webhook is
+// set up and m is routed to it.
+func (n *Notifier) sendWebhook(m *storage.Monitor, event, message string) {
+	if n.db == nil || !m.NotifiesVia(storage.ChannelWebhook) {
+		return
+	}
+	url := n.db.GetStringSetting(storage.SettingWebhookURL, "")
+	if url == "" {
+		return
+	}
+	secret := n.db.GetStringSetting(storage.SettingWebhookSecret, "")
+
+	wm := &WebhookMonitor{ID: m.ID, Name: m.Name, Type: m.Type}
+	if !m.IsHeartbeat() {
+		wm.URL = m.URL
+	}
+	body, err := json.Marshal(WebhookPayload{Event: event, Monitor: wm, Message: message, Timestamp: time.Now().UTC()})
+	if err != nil {
+		slog.Warn("failed to encode webhook payload", "monitor", m.Name, "error", err)
+		return
+	}
+	deliver(func() error {
+		_, err := DeliverWebhook(n.db, url, secret, event, m.ID, body)
+		if err != nil {
+			slog.Warn("failed to deliver webhook", "monitor", m.Name, "url", url, "error", err)
+		}
+		return err
+	})
+}
+
+// DeliverWebhook posts body to url, signed with secret when it is set,
+// retrying network errors and 5xx responses, and returns every attempt.
+// Attempts are also recorded in the notification log when db is not nil.
+func DeliverWebhook(db *storage.Database, url, secret, event string, monitorID uint, body []byte) ([]storage.NotificationDelivery, error) {
+	var attempts []storage.NotificationDelivery
+	var err error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		d := postWebhook(url, secret, body)
+		d.Channel = storage.ChannelWebhook
+		d.MonitorID = monitorID
+		d.Event = event
+		d.Attempt = attempt
+		if db != nil {
+			if err := db.CreateNotificationDelivery(&d); err != nil {
+				slog.Error("failed to record webhook delivery", "url", url, "error", err)
+			}
+		}
+		attempts = append(attempts, d)
+		if d.Success {
+			return attempts, nil
+		}
+
+		err = fmt.Errorf("%s", d.Error)
+		if d.StatusCode != 0 && d.StatusCode < 500 && d.StatusCode != http.StatusTooManyRequests {
+			return attempts, err
+		}
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+		}
+	}
+	return attempts, err
+}
+
+// postWebhook makes a single delivery attempt, signed with a fresh
+// timestamp.
+func postWebhook(url, secret string, body []byte) storage.NotificationDelivery {
+	d := storage.NotificationDelivery{URL: url}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Statping-Webhook/1.0")
+	if secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, time.Now(), body))
+	}
+
+	start := time.Now()
+	resp, err := webhookClient.Do(req)
+	d.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		d.Error = err.Error()
+		return d
+	}
+	defer resp.Body.Close()
+
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, webhookSnippet))
+	d.StatusCode = resp.StatusCode
+	d.ResponseSnippet = string(snippet)
+	d.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !d.Success {
+		d.Error = "receiver returned " + resp.Status
+	}
+	return d
+}
@@ -0,0 +1,93 @@
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrRunning is returned by Acquire when another live process holds the
+// PID file.
+var ErrRunning = errors.New("already running")
+
+// PIDFile is a PID file owned by the current process.
+type PIDFile struct {
+	path string
+	pid  int
+}
+
+// Acquire writes the current PID to path. It fails with ErrRunning if the
+// file names a process that is still alive; a stale file left behind by a
+// process that has exited is replaced.
+func Acquire(path string) (*PIDFile, error) {
+	pid, running, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if running {
+		return nil, fmt.Errorf("%w (pid %d)", ErrRunning, pid)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			// Lost a race with another instance starting up.
+			return nil, ErrRunning
+		}
+		return nil, fmt.Errorf("failed to create PID file: %w", err)
+	}
+	defer f.Close()
+
+	self := os.Getpid()
+	if _, err := fmt.Fprintf(f, "%d\n", self); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write PID file: %w", err)
+	}
+
+	return &PIDFile{path: path, pid: self}, nil
+}
+
+// Release removes the PID file if it still belongs to this process.
+func (p *PIDFile) Release() error {
+	pid, err := readPID(p.path)
+	if err != nil || pid != p.pid {
+		return nil
+	}
+	return os.Remove(p.path)
+}
+
+// Read returns the PID recorded in path and whether that process is alive.
+// A missing file reports (0, false, nil); a stale one is removed.
+func Read(path string) (int, bool, error) {
+	pid, err := readPID(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	if pid > 0 && Alive(pid) {
+		return pid, true, nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return 0, false, fmt.Errorf("failed to remove stale PID file: %w", err)
+	}
+	return pid, false, nil
+}
+
+func readPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		// Garbage in the file is treated like a dead process.
+		return 0, nil
+	}
+	return pid, nil
+}
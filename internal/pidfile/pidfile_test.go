@@ -0,0 +1,98 @@
+package pidfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statping.pid")
+
+	p, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, running, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid != os.Getpid() || !running {
+		t.Errorf("Read = %d, %v, want %d, true", pid, running, os.Getpid())
+	}
+
+	if _, err := Acquire(path); !errors.Is(err, ErrRunning) {
+		t.Errorf("second Acquire: err = %v, want ErrRunning", err)
+	}
+
+	if err := p.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("PID file still exists after Release: %v", err)
+	}
+}
+
+func TestStalePIDFile(t *testing.T) {
+	// Run a process to completion to get a PID nothing is using.
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dead := cmd.Process.Pid
+	if Alive(dead) {
+		t.Skipf("pid %d was reused", dead)
+	}
+
+	path := filepath.Join(t.TempDir(), "statping.pid")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", dead)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, running, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running {
+		t.Error("Read reports a stale PID file as running")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stale PID file was not removed: %v", err)
+	}
+
+	if _, err := Acquire(path); err != nil {
+		t.Errorf("Acquire over a stale PID file: %v", err)
+	}
+}
+
+func TestStop(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperSleep$")
+	cmd.Env = append(os.Environ(), "STATPING_PIDFILE_HELPER=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pid := cmd.Process.Pid
+	if !Alive(pid) {
+		t.Fatalf("helper process %d is not alive", pid)
+	}
+
+	if err := Stop(pid); err != nil {
+		t.Fatal(err)
+	}
+	cmd.Wait()
+	if Alive(pid) {
+		t.Errorf("process %d still alive after Stop", pid)
+	}
+}
+
+// TestHelperSleep is the process TestStop stops.
+func TestHelperSleep(t *testing.T) {
+	if os.Getenv("STATPING_PIDFILE_HELPER") == "" {
+		t.Skip("helper process for TestStop")
+	}
+	time.Sleep(time.Minute)
+}
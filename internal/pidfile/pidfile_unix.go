@@ -0,0 +1,29 @@
+//go:build unix
+
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Alive reports whether a process with the given PID exists.
+func Alive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	// EPERM means the process exists but belongs to someone else.
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// Stop asks the process with the given PID to exit.
+func Stop(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,233 @@
+// Package report renders uptime reports for a period as Markdown or as a
+// self-contained HTML page that can be sent by email.
+package report
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+//go:embed templates/*
+var templatesFS embed.FS
+
+// chartPoints is roughly how many points each latency chart has.
+const chartPoints = 60
+
+// Report holds everything shown in a report.
+type Report struct {
+	Start     time.Time
+	End       time.Time
+	Period    string
+	Uptime    float64
+	HasData   bool
+	Monitors  []MonitorRow
+	Incidents []IncidentRow
+}
+
+// MonitorRow summarizes one monitor over the period.
+type MonitorRow struct {
+	Name       string
+	URL        string
+	Checks     int64
+	Uptime     float64
+	HasLatency bool
+	AvgLatency float64
+	P95Latency int64
+	Incidents  int64
+	Downtime   time.Duration
+	Chart      []storage.CheckBucket
+}
+
+// IncidentRow is one entry of the incident timeline.
+type IncidentRow struct {
+	Monitor  string
+	Kind     string
+	Started  time.Time
+	Duration time.Duration
+	Ongoing  bool
+	Error    string
+}
+
+// Build collects the report for the period ending now from the aggregate
+// storage queries. Paused monitors without checks in the period are left
+// out.
+func Build(db *storage.Database, period time.Duration, label string) (*Report, error) {
+	end := time.Now()
+	start := end.Add(-period)
+	r := &Report{Start: start, End: end, Period: label}
+
+	monitors, err := db.ListMonitors()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list monitors: %w", err)
+	}
+
+	names := make(map[uint]string, len(monitors))
+	var up, known time.Duration
+	for i := range monitors {
+		m := &monitors[i]
+		names[m.ID] = m.Name
+
+		stats, err := db.GetCheckResultStats(m.ID, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stats for %s: %w", m.Name, err)
+		}
+		if stats.Total == 0 && !m.Enabled {
+			continue
+		}
+		p, err := db.GetResponseTimePercentiles(m.ID, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load latency for %s: %w", m.Name, err)
+		}
+		inc, err := db.GetIncidentStats(m.ID, start)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load incidents for %s: %w", m.Name, err)
+		}
+		ok := true
+		chart, err := db.GetCheckResultBuckets(m.ID, start, chartBucket(period), &ok)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load chart for %s: %w", m.Name, err)
+		}
+
+		r.Monitors = append(r.Monitors, MonitorRow{
+			Name:       m.Name,
+			URL:        m.URL,
+			Checks:     stats.Total,
+			Uptime:     stats.Uptime.Percent(),
+			HasLatency: stats.HasResponseTime(),
+			AvgLatency: stats.AvgResponseTime,
+			P95Latency: p.P95,
+			Incidents:  inc.Count,
+			Downtime:   inc.Downtime,
+			Chart:      chart,
+		})
+		up += stats.Uptime.Up
+		known += stats.Uptime.Up + stats.Uptime.Down
+	}
+	if known > 0 {
+		r.HasData = true
+		r.Uptime = float64(up) / float64(known) * 100
+	}
+
+	incidents, err := db.GetIncidentsSince(start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load incidents: %w", err)
+	}
+	for _, inc := range incidents {
+		name, ok := names[inc.MonitorID]
+		if !ok {
+			continue
+		}
+		kind := "Down"
+		if inc.IsPerformance() {
+			kind = "Slow"
+		}
+		r.Incidents = append(r.Incidents, IncidentRow{
+			Monitor:  name,
+			Kind:     kind,
+			Started:  inc.StartedAt,
+			Duration: inc.Duration(),
+			Ongoing:  !inc.IsResolved(),
+			Error:    inc.ErrorMessage,
+		})
+	}
+	return r, nil
+}
+
+// chartBucket picks a bucket width that gives about chartPoints points,
+// rounded to whole minutes.
+func chartBucket(period time.Duration) time.Duration {
+	return max((period / chartPoints).Round(time.Minute), time.Minute)
+}
+
+var funcs = map[string]any{
+	"pct":      func(v float64) string { return fmt.Sprintf("%.3f%%", v) },
+	"ms":       func(v float64) string { return fmt.Sprintf("%.0fms", v) },
+	"duration": formatDuration,
+	"date":     func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+	"uptimeClass": func(v float64) string {
+		switch {
+		case v >= 99.9:
+			return "good"
+		case v >= 99:
+			return "warn"
+		default:
+			return "bad"
+		}
+	},
+	"cell": func(s string) string {
+		return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+	},
+}
+
+// WriteMarkdown renders the report as Markdown.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	tmpl, err := template.New("report.md").Funcs(funcs).ParseFS(templatesFS, "templates/report.md")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, r)
+}
+
+// WriteHTML renders the report as a single HTML page with inline styles and
+// SVG charts, so it displays without loading anything else.
+func (r *Report) WriteHTML(w io.Writer) error {
+	htmlFuncs := htmltemplate.FuncMap{"chart": latencyChart}
+	for name, fn := range funcs {
+		htmlFuncs[name] = fn
+	}
+	tmpl, err := htmltemplate.New("report.html").Funcs(htmlFuncs).ParseFS(templatesFS, "templates/report.html")
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, r)
+}
+
+// latencyChart draws the average response time of each bucket as an SVG
+// line chart.
+func latencyChart(points []storage.CheckBucket) htmltemplate.HTML {
+	const width, height, pad = 600, 120, 4
+	if len(points) < 2 {
+		return `<p class="muted">Not enough data for a chart</p>`
+	}
+
+	var maxMs float64
+	for _, p := range points {
+		maxMs = max(maxMs, p.AvgResponseTime)
+	}
+	if maxMs == 0 {
+		maxMs = 1
+	}
+
+	first, last := points[0].Start, points[len(points)-1].Start
+	span := last.Sub(first).Seconds()
+	var coords []string
+	for _, p := range points {
+		x := pad + p.Start.Sub(first).Seconds()/span*(width-2*pad)
+		y := height - pad - p.AvgResponseTime/maxMs*(height-2*pad)
+		coords = append(coords, fmt.Sprintf("%.1f,%.1f", x, y))
+	}
+
+	return htmltemplate.HTML(fmt.Sprintf(
+		`<svg viewBox="0 0 %d %d" width="100%%" height="%d" xmlns="http://www.w3.org/2000/svg" role="img">`+
+			`<polyline fill="none" stroke="#58a6ff" stroke-width="1.5" points="%s"/>`+
+			`<text x="%d" y="12" font-size="10" fill="#8b949e">max %.0fms</text></svg>`,
+		width, height, height, strings.Join(coords, " "), pad, maxMs))
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckerHeartbeatInterval is how often a running checker records that it
+// is alive, whether or not any monitor was due.
+const CheckerHeartbeatInterval = time.Minute
+
+// MonitoringActivity is when a checker was last seen working: the newest
+// of its heartbeat and any monitor's last check. Silence longer than
+// Threshold means monitoring stalled, e.g. because the daemon died or the
+// machine slept.
+type MonitoringActivity struct {
+	Last      time.Time
+	Threshold time.Duration
+}
+
+// Stalled reports whether nothing was checked within the threshold. A
+// database that was never checked has not stalled.
+func (a MonitoringActivity) Stalled(now time.Time) bool {
+	return a.Threshold > 0 && !a.Last.IsZero() && now.Sub(a.Last) > a.Threshold
+}
+
+// Silence is how long ago the last activity was.
+func (a MonitoringActivity) Silence(now time.Time) time.Duration {
+	return now.Sub(a.Last)
+}
+
+// GetMonitoringActivity returns the latest checker activity. The threshold
+// is twice the smallest interval of the enabled monitors, but never less
+// than two checker heartbeats; with no enabled monitors it is zero.
+func (d *Database) GetMonitoringActivity() (MonitoringActivity, error) {
+	var a MonitoringActivity
+
+	var interval *int
+	err := d.db.Model(&Monitor{}).Where("enabled = ?", true).Select("MIN(check_interval)").Scan(&interval).Error
+	if err != nil || interval == nil {
+		return a, err
+	}
+	a.Threshold = max(2*time.Duration(*interval)*time.Second, 2*CheckerHeartbeatInterval)
+
+	var newest Monitor
+	err = d.db.Select("last_check_at").Where("last_check_at IS NOT NULL").Order("last_check_at desc").First(&newest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return a, err
+	}
+	if newest.LastCheckAt != nil {
+		a.Last = *newest.LastCheckAt
+	}
+	if beat := d.GetTimeSetting(SettingCheckerHeartbeat); beat.After(a.Last) {
+		a.Last = beat
+	}
+	return a, nil
+}
+
+// RecordCheckerHeartbeat marks the checker as alive at t.
+func (d *Database) RecordCheckerHeartbeat(t time.Time) error {
+	return d.SetTimeSetting(SettingCheckerHeartbeat, t)
+}
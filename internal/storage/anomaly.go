@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Defaults for latency anomaly detection.
+const (
+	DefaultAnomalySigma    = 3.0
+	DefaultAnomalyBaseline = 50
+
+	// MinAnomalyBaseline is the fewest successful checks a baseline is
+	// built from; fewer give a standard deviation too noisy to judge by.
+	MinAnomalyBaseline = 10
+	maxAnomalyBaseline = 1000
+)
+
+// ParseAnomalySigma parses how many standard deviations above its baseline
+// a response time must be to count as anomalous. 0 turns detection off.
+func ParseAnomalySigma(v string) (float64, error) {
+	sigma, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || sigma < 0 {
+		return 0, fmt.Errorf("invalid anomaly sigma %q: use a number of standard deviations, e.g. 3, or 0 to turn detection off", v)
+	}
+	return sigma, nil
+}
+
+// ParseAnomalyBaseline parses how many recent successful checks a
+// monitor's latency baseline covers.
+func ParseAnomalyBaseline(v string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < MinAnomalyBaseline || n > maxAnomalyBaseline {
+		return 0, fmt.Errorf("invalid anomaly baseline %q: use a number of checks from %d to %d", v, MinAnomalyBaseline, maxAnomalyBaseline)
+	}
+	return n, nil
+}
+
+// AnomalyAlert asks for a notification once Count of a monitor's last
+// Window successful checks were anomalous. The zero value sends none.
+type AnomalyAlert struct {
+	Count  int
+	Window int
+}
+
+func (a AnomalyAlert) Enabled() bool {
+	return a.Count > 0
+}
+
+func (a AnomalyAlert) String() string {
+	if !a.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d", a.Count, a.Window)
+}
+
+// ParseAnomalyAlert parses an anomaly alert written as "M/N", e.g. "3/5"
+// for three of the last five checks. An empty string turns alerts off.
+func ParseAnomalyAlert(v string) (AnomalyAlert, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return AnomalyAlert{}, nil
+	}
+	count, window, ok := strings.Cut(v, "/")
+	m, err1 := strconv.Atoi(strings.TrimSpace(count))
+	n, err2 := strconv.Atoi(strings.TrimSpace(window))
+	if !ok || err1 != nil || err2 != nil || m < 1 || n < m || n > 100 {
+		return AnomalyAlert{}, fmt.Errorf("invalid anomaly alert %q: use M/N, e.g. 3/5 for 3 of the last 5 checks, with M <= N <= 100", v)
+	}
+	return AnomalyAlert{Count: m, Window: n}, nil
+}
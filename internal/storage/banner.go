@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidateBannerTarget checks the host:port a banner monitor connects to
+// and returns it trimmed.
+func ValidateBannerTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		return "", fmt.Errorf("invalid banner target %q: use host:port without a scheme, e.g. example.com:22", target)
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return "", fmt.Errorf("invalid banner target %q: use host:port, e.g. example.com:22", target)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid port in banner target %q", target)
+	}
+	return target, nil
+}
+
+// BannerPattern is what a banner monitor expects the server's first line
+// to start with, or to match when written as /regex/.
+type BannerPattern struct {
+	prefix string
+	re     *regexp.Regexp
+}
+
+// ParseBannerPattern parses an expected banner. Empty matches any banner.
+func ParseBannerPattern(p string) (*BannerPattern, error) {
+	if len(p) >= 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+		re, err := regexp.Compile(p[1 : len(p)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid banner pattern %q: %w", p, err)
+		}
+		return &BannerPattern{re: re}, nil
+	}
+	return &BannerPattern{prefix: p}, nil
+}
+
+// Match reports whether banner is what p expects.
+func (p *BannerPattern) Match(banner string) bool {
+	if p.re != nil {
+		return p.re.MatchString(banner)
+	}
+	return strings.HasPrefix(banner, p.prefix)
+}
+
+// ValidateExpectedBanner checks a monitor's expected banner. Only banner
+// monitors read one.
+func ValidateExpectedBanner(p, monitorType string) error {
+	if p == "" {
+		return nil
+	}
+	if monitorType != MonitorTypeBanner {
+		return fmt.Errorf("an expected banner only applies to banner monitors")
+	}
+	_, err := ParseBannerPattern(p)
+	return err
+}
+
+// IsBanner reports whether m is a banner monitor.
+func (m *Monitor) IsBanner() bool {
+	return m.Type == MonitorTypeBanner
+}
+
+// DisplayTarget is how m's target is shown in lists. A banner monitor's
+// host:port is marked as such, since it isn't a URL.
+func (m *Monitor) DisplayTarget() string {
+	if m.IsBanner() {
+		return m.URL + " (banner)"
+	}
+	if m.IsTCP() {
+		return m.URL + " (tcp)"
+	}
+	return m.URL
+}
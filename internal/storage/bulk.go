@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Actions accepted by BulkMonitors.
+const (
+	BulkDelete  = "delete"
+	BulkEnable  = "enable"
+	BulkDisable = "disable"
+)
+
+// BulkResult is the outcome of a bulk action for one monitor ID.
+type BulkResult struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkMonitors applies action to every monitor in ids in one transaction.
+// IDs that don't exist are reported in their result and don't stop the
+// others; any database error rolls the whole batch back. Deleted monitors
+// go to the trash, and enabling or disabling is recorded in the audit log
+// like a pause or resume from source.
+func (d *Database) BulkMonitors(action string, ids []uint, source string) ([]BulkResult, error) {
+	switch action {
+	case BulkDelete, BulkEnable, BulkDisable:
+	default:
+		return nil, fmt.Errorf("invalid action %q: use delete, enable or disable", action)
+	}
+
+	results := make([]BulkResult, 0, len(ids))
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			var m Monitor
+			if err := tx.First(&m, id).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					results = append(results, BulkResult{ID: id, Error: ErrMonitorNotFound.Error()})
+					continue
+				}
+				return err
+			}
+
+			var err error
+			switch action {
+			case BulkDelete:
+				err = tx.Delete(&m).Error
+			case BulkEnable:
+				err = resumeMonitor(tx, id, source, "bulk")
+			case BulkDisable:
+				err = pauseMonitor(tx, id, nil, source, "bulk")
+			}
+			if err != nil {
+				return err
+			}
+			results = append(results, BulkResult{ID: id, Name: m.Name, Success: true})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ParseIgnorePatterns compiles a monitor's newline-separated ignore
+// patterns. Text matching any of them is stripped from the body before it
+// is hashed for content watching.
+func ParseIgnorePatterns(patterns string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, p := range strings.Split(patterns, "\n") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", p, err)
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// GetContentSnapshot returns the last body recorded for a watched monitor,
+// or nil if there is none yet.
+func (d *Database) GetContentSnapshot(monitorID uint) (*ContentSnapshot, error) {
+	var s ContentSnapshot
+	err := d.db.Where("monitor_id = ?", monitorID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (d *Database) SaveContentSnapshot(s *ContentSnapshot) error {
+	return d.db.Save(s).Error
+}
+
+func (d *Database) CreateContentChange(c *ContentChange) error {
+	return d.db.Create(c).Error
+}
+
+func (d *Database) GetContentChanges(monitorID uint, limit int) ([]ContentChange, error) {
+	var changes []ContentChange
+	err := d.db.Where("monitor_id = ?", monitorID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, err
+}
@@ -0,0 +1,528 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDSNEnv names the environment variable holding the DSN of a
+// Postgres database to run the storage tests against as well as SQLite.
+const postgresDSNEnv = "STATPING_TEST_POSTGRES_DSN"
+
+// newTestDB opens an empty in-memory SQLite database.
+func newTestDB(t *testing.T) *Database {
+	t.Helper()
+	d, err := New(DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// newPostgresTestDB opens dsn with a schema of its own, dropped when the
+// test ends, so tests neither see nor leave behind each other's rows.
+func newPostgresTestDB(t *testing.T, dsn string) *Database {
+	t.Helper()
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	schema := fmt.Sprintf("statping_test_%d", time.Now().UnixNano())
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.Exec("DROP SCHEMA " + schema + " CASCADE")
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	d, err := New(DriverPostgres, withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// withSearchPath points a URL or keyword/value DSN at schema.
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+// forEachBackend runs fn against a fresh SQLite database, and against a
+// fresh Postgres schema when STATPING_TEST_POSTGRES_DSN is set.
+func forEachBackend(t *testing.T, fn func(t *testing.T, d *Database)) {
+	t.Run(DriverSQLite, func(t *testing.T) {
+		fn(t, newTestDB(t))
+	})
+	t.Run(DriverPostgres, func(t *testing.T) {
+		dsn := os.Getenv(postgresDSNEnv)
+		if dsn == "" {
+			t.Skip(postgresDSNEnv + " is not set")
+		}
+		fn(t, newPostgresTestDB(t, dsn))
+	})
+}
+
+// mustCreateMonitor creates a monitor for url with the given overrides.
+func mustCreateMonitor(t *testing.T, d *Database, url string, opts ...func(*Monitor)) *Monitor {
+	t.Helper()
+	m := &Monitor{Name: url, URL: url, Enabled: true, Public: true, CheckInterval: 60, Timeout: 10}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if err := d.CreateMonitor(m); err != nil {
+		t.Fatalf("create monitor %s: %v", url, err)
+	}
+	return m
+}
+
+// mustCreateCheck records a check of m at the given time.
+func mustCreateCheck(t *testing.T, d *Database, m *Monitor, at time.Time, success bool, responseTime int64) *CheckResult {
+	t.Helper()
+	cr := &CheckResult{MonitorID: m.ID, CreatedAt: at, Success: success, ResponseTime: responseTime}
+	if !success {
+		cr.ErrorMessage = "connection refused"
+	}
+	if err := d.CreateCheckResult(cr); err != nil {
+		t.Fatalf("create check result: %v", err)
+	}
+	return cr
+}
+
+func TestBackendQueries(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, d *Database) {
+		private := mustCreateMonitor(t, d, "https://private.example.com", func(m *Monitor) {
+			m.Public = false
+			m.Enabled = false
+		})
+		m := mustCreateMonitor(t, d, "https://api.example.com")
+
+		got, err := d.GetMonitor(private.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Public || got.Enabled {
+			t.Errorf("private monitor stored as public=%v enabled=%v", got.Public, got.Enabled)
+		}
+		public, err := d.ListPublicMonitors()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(public) != 1 || public[0].ID != m.ID {
+			t.Errorf("ListPublicMonitors = %v, want only monitor %d", public, m.ID)
+		}
+
+		now := time.Now().Truncate(time.Second)
+		since := now.Add(-time.Hour)
+		mustCreateCheck(t, d, m, now.Add(-30*time.Minute), true, 100)
+		mustCreateCheck(t, d, m, now.Add(-20*time.Minute), true, 201)
+		mustCreateCheck(t, d, m, now.Add(-10*time.Minute), false, 0)
+
+		stats, err := d.GetCheckResultStats(m.ID, since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Total != 3 || stats.Successful != 2 {
+			t.Errorf("stats counts = %d/%d, want 2/3", stats.Successful, stats.Total)
+		}
+		if stats.AvgResponseTime != 150.5 {
+			t.Errorf("average response time = %v, want 150.5", stats.AvgResponseTime)
+		}
+
+		buckets, err := d.GetCheckResultBuckets(m.ID, since, time.Hour, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var checks, failures int64
+		for _, b := range buckets {
+			checks += b.Checks
+			failures += b.Failures
+		}
+		if checks != 3 || failures != 1 {
+			t.Errorf("buckets hold %d checks and %d failures, want 3 and 1", checks, failures)
+		}
+
+		series, err := d.GetCheckSeries(m.ID, since, now, 15*time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks = 0
+		for _, p := range series {
+			checks += p.Checks
+		}
+		if checks != 3 {
+			t.Errorf("series holds %d checks, want 3", checks)
+		}
+
+		days, err := d.GetDailyUptime(m.ID, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks = 0
+		for _, day := range days {
+			checks += day.Checks
+		}
+		if checks != 3 {
+			t.Errorf("daily uptime holds %d checks, want 3", checks)
+		}
+	})
+}
+
+func TestCheckResultStats(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	since := now.Add(-time.Hour)
+
+	tests := []struct {
+		name              string
+		checks            []bool
+		total, successful int64
+		avg               float64
+	}{
+		{"empty", nil, 0, 0, 0},
+		{"all failed", []bool{false, false, false}, 3, 0, 0},
+		{"mixed", []bool{true, false, true, false}, 4, 2, 200},
+	}
+	forEachBackend(t, func(t *testing.T, d *Database) {
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				m := mustCreateMonitor(t, d, "https://"+strings.ReplaceAll(tt.name, " ", "-")+".example.com")
+				for i, success := range tt.checks {
+					// Failed checks ran into the timeout, which must not
+					// count towards the average.
+					rt := int64(10000)
+					if success {
+						rt = int64(100 * (i + 1))
+					}
+					mustCreateCheck(t, d, m, now.Add(-time.Duration(i+1)*time.Minute), success, rt)
+				}
+
+				stats, err := d.GetCheckResultStats(m.ID, since)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if stats.Total != tt.total || stats.Successful != tt.successful {
+					t.Errorf("counts = %d/%d, want %d/%d", stats.Successful, stats.Total, tt.successful, tt.total)
+				}
+				if stats.AvgResponseTime != tt.avg {
+					t.Errorf("average response time = %v, want %v", stats.AvgResponseTime, tt.avg)
+				}
+				if stats.HasResponseTime() != (tt.successful > 0) {
+					t.Errorf("HasResponseTime = %v with %d successful checks", stats.HasResponseTime(), tt.successful)
+				}
+			})
+		}
+	})
+}
+
+func TestBatchedStatsMatchPerMonitor(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, d *Database) {
+		now := time.Now().Truncate(time.Second)
+		since := now.Add(-time.Hour)
+
+		var monitors []*Monitor
+		maxRT := map[uint]int64{}
+		for i, pattern := range []string{"sssf", "ffff", "sfsfsfs", ""} {
+			m := mustCreateMonitor(t, d, fmt.Sprintf("https://m%d.example.com", i))
+			monitors = append(monitors, m)
+			// A check before the window must be left out of the stats
+			// but still counts as the latest result if it is the only one.
+			mustCreateCheck(t, d, m, since.Add(-time.Minute), true, 9999)
+			for j, c := range pattern {
+				rt := int64(50*(i+1) + 10*j)
+				mustCreateCheck(t, d, m, now.Add(-time.Duration(len(pattern)-j)*time.Minute), c == 's', rt)
+				if c == 's' {
+					maxRT[m.ID] = max(maxRT[m.ID], rt)
+				}
+			}
+		}
+
+		all, err := d.GetStatsForAllMonitors(since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		latest, err := d.GetLatestResultPerMonitor()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, m := range monitors {
+			want, err := d.GetCheckResultStats(m.ID, since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := all[m.ID]
+			if want.Total == 0 {
+				if ok {
+					t.Errorf("monitor %d without checks in the window has stats %+v", m.ID, got)
+				}
+			} else if got.Checks != want.Total || got.Successes != want.Successful ||
+				got.AvgResponseTime != want.AvgResponseTime || got.MaxResponseTime != maxRT[m.ID] {
+				t.Errorf("monitor %d: batched stats %+v, per-monitor %+v with max %d", m.ID, got, want, maxRT[m.ID])
+			}
+
+			recent, err := d.GetRecentCheckResults(m.ID, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recent) != 1 || latest[m.ID].ID != recent[0].ID {
+				t.Errorf("monitor %d: latest result %d, per-monitor %v", m.ID, latest[m.ID].ID, recent)
+			}
+		}
+		if len(latest) != len(monitors) {
+			t.Errorf("%d latest results for %d monitors", len(latest), len(monitors))
+		}
+	})
+}
+
+func TestDeleteMonitorNotFound(t *testing.T) {
+	d := newTestDB(t)
+	if _, err := d.DeleteMonitor(42); !errors.Is(err, ErrMonitorNotFound) {
+		t.Errorf("deleting a missing monitor: err = %v, want ErrMonitorNotFound", err)
+	}
+
+	m := mustCreateMonitor(t, d, "https://api.example.com")
+	if _, err := d.DeleteMonitor(m.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.DeleteMonitor(m.ID); !errors.Is(err, ErrMonitorNotFound) {
+		t.Errorf("deleting a trashed monitor: err = %v, want ErrMonitorNotFound", err)
+	}
+}
+
+func TestDeleteMonitorLeavesNoOrphans(t *testing.T) {
+	d := newTestDB(t)
+	now := time.Now()
+	m := mustCreateMonitor(t, d, "https://api.example.com", func(m *Monitor) { m.Name = "API" })
+	other := mustCreateMonitor(t, d, "https://www.example.com")
+	for i := 0; i < 3; i++ {
+		mustCreateCheck(t, d, m, now.Add(-time.Duration(i)*time.Minute), i > 0, 50)
+	}
+	mustCreateCheck(t, d, other, now, true, 50)
+	if err := d.CreateIncident(&Incident{MonitorID: m.ID, StartedAt: now, ErrorMessage: "down"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PauseMonitor(m.ID, nil, AuditSourceCLI, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []uint{m.ID, other.ID} {
+		if err := d.CreateNotificationDelivery(&NotificationDelivery{Channel: ChannelWebhook, MonitorID: id, Event: "down", Success: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := d.DeleteMonitor(m.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.Name != "API" {
+		t.Errorf("DeleteMonitor returned %q, want API", deleted.Name)
+	}
+	if n := countRows(t, d, &CheckResult{}, m.ID); n != 3 {
+		t.Errorf("trashed monitor has %d check results, want 3 kept for restore", n)
+	}
+
+	results, err := d.EmptyTrash(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("EmptyTrash removed %d monitors, want 1", len(results))
+	}
+	if r := results[0]; r.CheckResults != 3 || r.Incidents != 1 {
+		t.Errorf("EmptyTrash removed %d check results and %d incidents, want 3 and 1", r.CheckResults, r.Incidents)
+	}
+	if got, want := results[0].Summary(), "Removed monitor 'API' and 3 check results, 1 incidents"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	if n := countRows(t, d, &CheckResult{}, m.ID); n != 0 {
+		t.Errorf("%d orphaned check results left", n)
+	}
+	if n := countRows(t, d, &Incident{}, m.ID); n != 0 {
+		t.Errorf("%d orphaned incidents left", n)
+	}
+	if n := countRows(t, d, &AuditEntry{}, m.ID); n != 0 {
+		t.Errorf("%d orphaned audit entries left", n)
+	}
+	if n := countRows(t, d, &NotificationDelivery{}, m.ID); n != 0 {
+		t.Errorf("%d orphaned notification log entries left", n)
+	}
+	if n := countRows(t, d, &NotificationDelivery{}, other.ID); n != 1 {
+		t.Errorf("other monitor has %d notification log entries, want 1", n)
+	}
+	if n := countRows(t, d, &CheckResult{}, other.ID); n != 1 {
+		t.Errorf("other monitor has %d check results, want 1", n)
+	}
+	if _, err := d.RestoreMonitor(m.ID); !errors.Is(err, ErrMonitorNotFound) {
+		t.Errorf("restoring a purged monitor: err = %v, want ErrMonitorNotFound", err)
+	}
+}
+
+// countRows counts the rows of model's table that belong to monitorID.
+func countRows(t *testing.T, d *Database, model interface{}, monitorID uint) int64 {
+	t.Helper()
+	var n int64
+	if err := d.GetDB().Model(model).Where("monitor_id = ?", monitorID).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		dsn, want string
+	}{
+		{"/var/lib/statping/statping.db", "/var/lib/statping/statping.db?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL"},
+		{"statping.db?cache=shared", "statping.db?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL&cache=shared"},
+		{"file:statping.db?mode=ro", "file:statping.db?_busy_timeout=5000&_journal_mode=WAL&_synchronous=NORMAL&mode=ro"},
+		{"statping.db?_journal_mode=DELETE", "statping.db?_busy_timeout=5000&_journal_mode=DELETE&_synchronous=NORMAL"},
+		{"statping.db?_timeout=100&_sync=FULL", "statping.db?_journal_mode=WAL&_sync=FULL&_timeout=100"},
+	}
+	for _, tt := range tests {
+		got, err := sqliteDSN(tt.dsn)
+		if err != nil {
+			t.Errorf("sqliteDSN(%q): %v", tt.dsn, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+
+	if _, err := sqliteDSN("statping.db?cache=%zz"); err == nil {
+		t.Error("sqliteDSN accepted a malformed query")
+	}
+}
+
+func TestSQLitePath(t *testing.T) {
+	tests := map[string]string{
+		"/var/lib/statping/statping.db":          "/var/lib/statping/statping.db",
+		"file:/tmp/statping.db?mode=ro":          "/tmp/statping.db",
+		":memory:":                               "",
+		"file:statping?mode=memory&cache=shared": "",
+	}
+	for dsn, want := range tests {
+		if got := sqlitePath(dsn); got != want {
+			t.Errorf("sqlitePath(%q) = %q, want %q", dsn, got, want)
+		}
+	}
+}
+
+// TestOpenExisting expects a missing database to stay missing and an
+// existing one to be opened without being migrated.
+func TestOpenExisting(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data", "statping.db")
+	if _, err := OpenExisting(DriverSQLite, path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("OpenExisting of a missing database = %v, want os.ErrNotExist", err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("OpenExisting created %s", filepath.Dir(path))
+	}
+
+	empty := filepath.Join(dir, "empty.db")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := OpenExisting(DriverSQLite, empty)
+	if err != nil {
+		t.Fatalf("OpenExisting: %v", err)
+	}
+	t.Cleanup(func() { d.Close() })
+	if d.db.Migrator().HasTable(&Monitor{}) {
+		t.Error("OpenExisting migrated the database")
+	}
+	if got := d.GetStringSetting(SettingDefaultUserAgent, "default"); got != "default" {
+		t.Errorf("GetStringSetting on an unmigrated database = %q, want the default", got)
+	}
+}
+
+// TestConcurrentAccess writes check results from one goroutine while
+// others read, as the daemon and the TUI do, and expects no lock errors.
+func TestConcurrentAccess(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "statping.db")
+	writer, err := New(DriverSQLite, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	m := mustCreateMonitor(t, writer, "https://api.example.com")
+
+	const writes = 200
+	errs := make(chan error, 8)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(done)
+		for i := 0; i < writes; i++ {
+			if err := writer.CreateCheckResult(&CheckResult{MonitorID: m.ID, Success: true, ResponseTime: int64(i)}); err != nil {
+				errs <- fmt.Errorf("write: %w", err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		// A separate connection, like another process would have.
+		reader, err := New(DriverSQLite, dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := reader.ListMonitors(); err != nil {
+					errs <- fmt.Errorf("list monitors: %w", err)
+					return
+				}
+				if _, err := reader.GetRecentCheckResults(m.ID, 20); err != nil {
+					errs <- fmt.Errorf("recent check results: %w", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if n := countRows(t, writer, &CheckResult{}, m.ID); n != writes {
+		t.Errorf("%d check results saved, want %d", n, writes)
+	}
+}
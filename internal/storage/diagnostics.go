@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// IntegrityCheck runs SQLite's integrity check and returns its findings,
+// which are just "ok" for a healthy database. Only SQLite databases have
+// one.
+func (d *Database) IntegrityCheck() (string, error) {
+	var rows []string
+	if err := d.db.Raw("PRAGMA integrity_check").Scan(&rows).Error; err != nil {
+		return "", err
+	}
+	return strings.Join(rows, "\n"), nil
+}
+
+// JournalMode returns a SQLite database's journal mode, normally "wal".
+func (d *Database) JournalMode() (string, error) {
+	var mode string
+	err := d.db.Raw("PRAGMA journal_mode").Scan(&mode).Error
+	return mode, err
+}
+
+var errRollback = errors.New("rollback")
+
+// CheckWritable takes the database's write lock and releases it without
+// changing anything. It fails when another connection holds the lock for
+// longer than the busy timeout.
+func (d *Database) CheckWritable() error {
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM settings WHERE 1 = 0").Error; err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if errors.Is(err, errRollback) {
+		return nil
+	}
+	return err
+}
+
+// CountRows returns the number of monitors and stored check results.
+func (d *Database) CountRows() (monitors, checks int64, err error) {
+	if err = d.db.Model(&Monitor{}).Count(&monitors).Error; err != nil {
+		return
+	}
+	err = d.db.Model(&CheckResult{}).Count(&checks).Error
+	return
+}
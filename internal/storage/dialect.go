@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// unixSeconds returns the SQL for a timestamp column as whole Unix seconds.
+func (d *Database) unixSeconds(column string) string {
+	if d.driver == DriverPostgres {
+		return "CAST(EXTRACT(EPOCH FROM " + column + ") AS BIGINT)"
+	}
+	return "CAST(strftime('%s', " + column + ") AS INTEGER)"
+}
+
+// secondsBetween returns the SQL for the number of seconds from the
+// timestamp start to the timestamp end.
+func (d *Database) secondsBetween(start, end string) string {
+	if d.driver == DriverPostgres {
+		return "EXTRACT(EPOCH FROM (" + end + " - " + start + "))"
+	}
+	return "(julianday(" + end + ") - julianday(" + start + ")) * 86400"
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// RedactDSN hides the password in a database DSN so it can be logged or
+// shown.
+func RedactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			return u.Redacted()
+		}
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}xxxxx")
+}
+
+// IsTransient reports whether err is a write that lost a race for a lock,
+// SQLite's "database is locked" or a Postgres deadlock or serialization
+// failure, and may succeed if retried.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"database is locked", "database table is locked", "sqlite_busy", "deadlock detected", "could not serialize access"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
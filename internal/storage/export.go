@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 1000
+
+// EachCheckResultBatch calls fn with consecutive batches of a monitor's
+// check results since the given time, oldest first, so callers never hold
+// the whole history in memory.
+func (d *Database) EachCheckResultBatch(monitorID uint, since time.Time, fn func([]CheckResult) error) error {
+	var batch []CheckResult
+	return d.db.Where("monitor_id = ? AND created_at >= ?", monitorID, since).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+			return fn(batch)
+		}).Error
+}
+
+// WriteCheckResultsCSV streams a monitor's check results since the given
+// time to w as CSV and returns the number of rows written. If w can be
+// flushed (e.g. an http.ResponseWriter), it is flushed after every batch.
+func (d *Database) WriteCheckResultsCSV(w io.Writer, monitorID uint, since time.Time) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"timestamp", "status_code", "response_time_ms", "success", "error"}); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err := d.EachCheckResultBatch(monitorID, since, func(batch []CheckResult) error {
+		for _, r := range batch {
+			record := []string{
+				r.CreatedAt.Format(time.RFC3339),
+				strconv.Itoa(r.StatusCode),
+				strconv.FormatInt(r.ResponseTime, 10),
+				strconv.FormatBool(r.Success),
+				r.ErrorMessage,
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+			rows++
+		}
+
+		cw.Flush()
+		if f, ok := w.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+		return cw.Error()
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	cw.Flush()
+	return rows, cw.Error()
+}
+
+// ParsePeriod parses look-back periods such as "24h", "7d" or "30d".
+func ParsePeriod(period string) (time.Duration, error) {
+	if len(period) < 2 {
+		return 0, fmt.Errorf("invalid period %q", period)
+	}
+	n, err := strconv.Atoi(period[:len(period)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid period %q", period)
+	}
+
+	switch period[len(period)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return 0, fmt.Errorf("invalid period %q, use hours or days like 24h or 30d", period)
+}
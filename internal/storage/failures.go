@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FailureReason is one cause of failed checks and how often it occurred.
+type FailureReason struct {
+	Reason  string  `json:"reason"`
+	Count   int64   `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// GetFailureReasons groups a monitor's failed checks since the given time
+// by NormalizeFailure and returns the limit most common reasons, most
+// frequent first. Percent is the share of all failures in the period.
+func (d *Database) GetFailureReasons(monitorID uint, since time.Time, limit int) ([]FailureReason, error) {
+	var rows []struct {
+		ErrorMessage string
+		StatusCode   int
+		ProxyError   bool
+		Count        int64
+	}
+	err := d.db.Model(&CheckResult{}).
+		Select("error_message, status_code, proxy_error, COUNT(*) as count").
+		Where("monitor_id = ? AND created_at >= ? AND success = ?", monitorID, since, false).
+		Group("error_message, status_code, proxy_error").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	counts := make(map[string]int64)
+	for _, r := range rows {
+		counts[NormalizeFailure(r.ErrorMessage, r.StatusCode, r.ProxyError)] += r.Count
+		total += r.Count
+	}
+
+	reasons := make([]FailureReason, 0, len(counts))
+	for reason, n := range counts {
+		reasons = append(reasons, FailureReason{
+			Reason:  reason,
+			Count:   n,
+			Percent: float64(n) / float64(total) * 100,
+		})
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasons[i].Count != reasons[j].Count {
+			return reasons[i].Count > reasons[j].Count
+		}
+		return reasons[i].Reason < reasons[j].Reason
+	})
+	if limit > 0 && len(reasons) > limit {
+		reasons = reasons[:limit]
+	}
+	return reasons, nil
+}
+
+// NormalizeFailure reduces a failed check's error to a short reason that
+// is the same for every occurrence of the same problem, dropping details
+// such as URLs, addresses and ports.
+func NormalizeFailure(msg string, statusCode int, proxy bool) string {
+	// Dual-stack checks name the family that failed, e.g. "IPv6: dial tcp6 …".
+	for _, family := range []string{"IPv4 and IPv6: ", "IPv4: ", "IPv6: "} {
+		if rest, ok := strings.CutPrefix(msg, family); ok {
+			return family + NormalizeFailure(rest, statusCode, proxy)
+		}
+	}
+
+	lower := strings.ToLower(msg)
+	switch {
+	case proxy:
+		return "Proxy error"
+	case strings.HasPrefix(lower, "auth failure"):
+		return "Auth failure"
+	case strings.HasPrefix(lower, "unexpected status code"):
+		return fmt.Sprintf("HTTP %d", statusCode)
+	case strings.HasPrefix(lower, "keyword "):
+		// Counts vary from check to check; keep the reason stable.
+		if i := strings.Index(msg, ": found "); i >= 0 {
+			return "Keyword" + msg[len("keyword"):i] + ": too few matches"
+		}
+		return "Keyword" + msg[len("keyword"):]
+	case strings.HasPrefix(lower, "unexpected protocol"):
+		return "Wrong protocol"
+	case strings.HasPrefix(lower, "response too small"):
+		return "Response too small"
+	case strings.HasPrefix(lower, "response does not match json schema"):
+		return "Schema mismatch"
+	case strings.HasPrefix(lower, "json schema unusable"):
+		return "Schema unusable"
+	case strings.HasPrefix(lower, "no ping received"):
+		return "Missed heartbeat"
+	case strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded"):
+		return "Timeout"
+	case strings.Contains(lower, "no such host") || strings.Contains(lower, "server misbehaving"):
+		return "DNS lookup failed"
+	case strings.Contains(lower, "connection refused"):
+		return "Connection refused"
+	case strings.Contains(lower, "connection reset"):
+		return "Connection reset"
+	case strings.Contains(lower, "x509") || strings.Contains(lower, "certificate"):
+		return "Certificate error"
+	case strings.Contains(lower, "tls"):
+		return "TLS error"
+	case strings.Contains(lower, "eof"):
+		return "Connection closed"
+	case msg == "":
+		return "Unknown error"
+	}
+
+	// Errors from the HTTP client read `Get "https://…": reason`.
+	if i := strings.LastIndex(msg, "\": "); i >= 0 {
+		msg = msg[i+3:]
+	}
+	if len(msg) > 80 {
+		msg = msg[:80] + "…"
+	}
+	return msg
+}
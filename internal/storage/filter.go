@@ -0,0 +1,49 @@
+package storage
+
+import "strings"
+
+// MonitorFilter scopes a view to some monitors: those with one of IDs or
+// carrying one of Tags. The zero filter matches every monitor.
+type MonitorFilter struct {
+	IDs  []uint
+	Tags []string
+}
+
+// IsZero reports whether f matches every monitor.
+func (f MonitorFilter) IsZero() bool {
+	return len(f.IDs) == 0 && len(f.Tags) == 0
+}
+
+// Match reports whether f lets m through.
+func (f MonitorFilter) Match(m *Monitor) bool {
+	if f.IsZero() {
+		return true
+	}
+	for _, id := range f.IDs {
+		if id == m.ID {
+			return true
+		}
+	}
+	for _, t := range ParseTags(m.Tags) {
+		for _, want := range f.Tags {
+			if t == strings.ToLower(strings.TrimSpace(want)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply returns the monitors f lets through, in their original order.
+func (f MonitorFilter) Apply(monitors []Monitor) []Monitor {
+	if f.IsZero() {
+		return monitors
+	}
+	var matched []Monitor
+	for i := range monitors {
+		if f.Match(&monitors[i]) {
+			matched = append(matched, monitors[i])
+		}
+	}
+	return matched
+}
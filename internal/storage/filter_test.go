@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMonitorFilter(t *testing.T) {
+	monitors := []Monitor{
+		{ID: 1, Name: "api", Tags: "prod,web"},
+		{ID: 2, Name: "db", Tags: "prod"},
+		{ID: 3, Name: "staging", Tags: "Staging"},
+		{ID: 4, Name: "untagged"},
+	}
+	for _, tc := range []struct {
+		name   string
+		filter MonitorFilter
+		want   []uint
+	}{
+		{"zero", MonitorFilter{}, []uint{1, 2, 3, 4}},
+		{"ids", MonitorFilter{IDs: []uint{4, 2}}, []uint{2, 4}},
+		{"tag", MonitorFilter{Tags: []string{"web"}}, []uint{1}},
+		{"tag case and spaces", MonitorFilter{Tags: []string{" STAGING "}}, []uint{3}},
+		{"ids or tags", MonitorFilter{IDs: []uint{4}, Tags: []string{"prod"}}, []uint{1, 2, 4}},
+		{"no match", MonitorFilter{IDs: []uint{9}, Tags: []string{"dev"}}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []uint
+			for _, m := range tc.filter.Apply(monitors) {
+				got = append(got, m.ID)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Apply = %v, want %v", got, tc.want)
+			}
+			for i := range monitors {
+				if matched := tc.filter.Match(&monitors[i]); matched != containsID(tc.want, monitors[i].ID) {
+					t.Errorf("Match(%d) = %v, disagreeing with Apply", monitors[i].ID, matched)
+				}
+			}
+		})
+	}
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
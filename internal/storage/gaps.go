@@ -0,0 +1,17 @@
+package storage
+
+import "time"
+
+func (d *Database) CreateMonitoringGap(g *MonitoringGap) error {
+	return d.db.Create(g).Error
+}
+
+// GetMonitoringGaps returns the gaps of a monitor that overlap
+// [since, until), oldest first.
+func (d *Database) GetMonitoringGaps(monitorID uint, since, until time.Time) ([]MonitoringGap, error) {
+	var gaps []MonitoringGap
+	err := d.db.Where("monitor_id = ? AND ended_at > ? AND started_at < ?", monitorID, since, until).
+		Order("started_at asc").
+		Find(&gaps).Error
+	return gaps, err
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewHeartbeatToken returns a random token for a heartbeat monitor's ping
+// URL.
+func NewHeartbeatToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HeartbeatURL is the placeholder stored in a heartbeat monitor's URL
+// column, which must be unique.
+func HeartbeatURL(token string) string {
+	return "heartbeat://" + token
+}
+
+func (d *Database) GetMonitorByHeartbeatToken(token string) (*Monitor, error) {
+	var m Monitor
+	err := d.db.Where("type = ? AND heartbeat_token = ?", MonitorTypeHeartbeat, token).First(&m).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/jsonschema"
+)
+
+// JSONSchemaSource returns the schema document a monitor's JSONSchema
+// field refers to. The field holds either the schema itself or the path of
+// a file containing it; a leading ~/ is expanded.
+func JSONSchemaSource(v string) (data []byte, path string, err error) {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "{") || v == "true" || v == "false" {
+		return []byte(v), "", nil
+	}
+	path = v
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", err
+		}
+		path = filepath.Join(home, rest)
+	}
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read JSON schema: %w", err)
+	}
+	return data, path, nil
+}
+
+// ValidateJSONSchema checks that v is empty or compiles as a JSON Schema
+// and returns the value to store, with a relative file path made absolute
+// so the schema is found whatever directory the checker runs in.
+func ValidateJSONSchema(v, monitorType string) (string, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if monitorType == MonitorTypeHeartbeat || monitorType == MonitorTypeTCP {
+		return "", fmt.Errorf("a JSON schema can't be used with %s monitors", monitorType)
+	}
+	data, path, err := JSONSchemaSource(v)
+	if err != nil {
+		return "", err
+	}
+	if _, err := jsonschema.Compile(data); err != nil {
+		return "", fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if path != "" && !strings.HasPrefix(v, "~/") {
+		if v, err = filepath.Abs(path); err != nil {
+			return "", err
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	cyclic := filepath.Join(dir, "cyclic.json")
+	for path, data := range map[string]string{
+		good:   `{"type": "object", "required": ["status"]}`,
+		cyclic: `{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+	} {
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	for _, tc := range []struct {
+		name, value, typ string
+		want, err        string
+	}{
+		{name: "empty", value: "  ", want: ""},
+		{name: "inline", value: ` {"type": "object"} `, want: `{"type": "object"}`},
+		{name: "boolean", value: "true", want: "true"},
+		{name: "file", value: good, want: good},
+		{name: "relative file", value: "good.json", want: good},
+		{name: "invalid inline", value: `{"type": "date"}`, err: `unknown type "date"`},
+		{name: "not JSON", value: `{"type":`, err: "not valid JSON"},
+		{name: "ref cycle", value: `{"$ref": "#"}`, err: "refers back to itself"},
+		{name: "ref cycle in file", value: cyclic, err: "refers back to itself"},
+		{name: "missing file", value: filepath.Join(dir, "missing.json"), err: "failed to read JSON schema"},
+		{name: "heartbeat", value: `{}`, typ: MonitorTypeHeartbeat, err: "can't be used with heartbeat monitors"},
+		{name: "tcp", value: `{}`, typ: MonitorTypeTCP, err: "can't be used with tcp monitors"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			typ := tc.typ
+			if typ == "" {
+				typ = MonitorTypeHTTP
+			}
+			got, err := ValidateJSONSchema(tc.value, typ)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("got %q, %v; want an error containing %q", got, err, tc.err)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("got %q, %v; want %q", got, err, tc.want)
+			}
+		})
+	}
+}
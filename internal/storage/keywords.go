@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parts of a response a keyword can be looked for in. A keyword without a
+// target prefix is looked for in the body.
+const (
+	KeywordTargetBody      = "body"
+	KeywordTargetHeaders   = "headers"
+	KeywordTargetRedirects = "redirect-chain"
+)
+
+// keywordRegexPrefix marks a keyword's text as a regular expression.
+const keywordRegexPrefix = "re:"
+
+// KeywordRule is one entry of a monitor's keyword list, written as
+// "<target>:<text>", e.g. "headers:set-cookie: session=" or
+// "redirect-chain#1:https://www.example.com/". Hop is the 1-based redirect
+// the text must be found in, or 0 for any of them. A ">=N" suffix sets Min,
+// the number of non-overlapping matches required; 0 means one is enough.
+// Text starting with "re:" is a regular expression, compiled into Pattern
+// to match case-insensitively; Pattern is nil for plain text.
+type KeywordRule struct {
+	Target  string
+	Hop     int
+	Text    string
+	Min     int
+	Pattern *regexp.Regexp
+}
+
+func (r KeywordRule) String() string {
+	text := r.Text
+	if r.Pattern != nil {
+		text = keywordRegexPrefix + text
+	}
+	if r.Min > 0 {
+		text += ">=" + strconv.Itoa(r.Min)
+	}
+	switch {
+	case r.Target == KeywordTargetBody:
+		return text
+	case r.Hop > 0:
+		return fmt.Sprintf("%s#%d:%s", r.Target, r.Hop, text)
+	}
+	return r.Target + ":" + text
+}
+
+// ParseKeywordRules splits a comma-separated keyword list into rules. A
+// prefix that names no target is part of the text, so plain keywords such
+// as "status: ok" keep working. A trailing ">=N", as in
+// `class="product-card">=20`, requires at least N matches. Regular
+// expressions are compiled here, so an invalid one is rejected when the
+// monitor is saved.
+func ParseKeywordRules(keywords string) ([]KeywordRule, error) {
+	var rules []KeywordRule
+	for _, k := range ParseKeywords(keywords) {
+		rule := KeywordRule{Target: KeywordTargetBody, Text: k}
+		if prefix, text, ok := strings.Cut(k, ":"); ok {
+			target, hop, _ := strings.Cut(strings.ToLower(strings.TrimSpace(prefix)), "#")
+			known := true
+			switch target {
+			case "body":
+				rule.Target = KeywordTargetBody
+			case "header", "headers":
+				rule.Target = KeywordTargetHeaders
+			case "redirect", "redirects", KeywordTargetRedirects:
+				rule.Target = KeywordTargetRedirects
+			default:
+				known = false
+			}
+			if known {
+				if hop != "" {
+					n, err := strconv.Atoi(hop)
+					if err != nil || n < 1 || rule.Target != KeywordTargetRedirects {
+						return nil, fmt.Errorf("invalid keyword target %q: only redirect-chain takes a hop number, starting at 1", prefix)
+					}
+					rule.Hop = n
+				}
+				rule.Text = strings.TrimSpace(text)
+			}
+		}
+		var err error
+		if rule.Text, rule.Min, err = cutMinCount(rule.Text); err != nil {
+			return nil, fmt.Errorf("keyword %q: %w", k, err)
+		}
+		if pattern, ok := cutPrefixFold(rule.Text, keywordRegexPrefix); ok {
+			rule.Text = strings.TrimSpace(pattern)
+			if rule.Text != "" {
+				if rule.Pattern, err = regexp.Compile("(?i)" + rule.Text); err != nil {
+					return nil, fmt.Errorf("keyword %q: %w", k, err)
+				}
+			}
+		}
+		if rule.Text == "" {
+			return nil, fmt.Errorf("keyword %q has no text to look for", k)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring the case of prefix.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
+
+// cutMinCount splits a trailing ">=N" off text. Text whose last ">=" isn't
+// followed by a number only is returned unchanged.
+func cutMinCount(text string) (string, int, error) {
+	i := strings.LastIndex(text, ">=")
+	if i < 0 {
+		return text, 0, nil
+	}
+	count := strings.TrimSpace(text[i+2:])
+	if count == "" || strings.Trim(count, "0123456789") != "" {
+		return text, 0, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("minimum count %q must be a whole number of at least 1", count)
+	}
+	return strings.TrimSpace(text[:i]), n, nil
+}
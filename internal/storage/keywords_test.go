@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseKeywordRules(t *testing.T) {
+	tests := []struct {
+		keywords string
+		want     []KeywordRule // Pattern is compared by its source
+		regex    []bool
+	}{
+		{keywords: "ok, status: ok", want: []KeywordRule{
+			{Target: KeywordTargetBody, Text: "ok"},
+			{Target: KeywordTargetBody, Text: "status: ok"},
+		}},
+		{keywords: "Headers:set-cookie: session=, redirect-chain#2:https://www.example.com/>=1", want: []KeywordRule{
+			{Target: KeywordTargetHeaders, Text: "set-cookie: session="},
+			{Target: KeywordTargetRedirects, Hop: 2, Text: "https://www.example.com/", Min: 1},
+		}},
+		{keywords: `re:order #\d+, body:RE:<li>>=20, headers:re:^location: https://, redirect#1:re:^https://www\.`, want: []KeywordRule{
+			{Target: KeywordTargetBody, Text: `order #\d+`},
+			{Target: KeywordTargetBody, Text: "<li>", Min: 20},
+			{Target: KeywordTargetHeaders, Text: "^location: https://"},
+			{Target: KeywordTargetRedirects, Hop: 1, Text: `^https://www\.`},
+		}, regex: []bool{true, true, true, true}},
+	}
+	for _, tt := range tests {
+		got, err := ParseKeywordRules(tt.keywords)
+		if err != nil {
+			t.Errorf("ParseKeywordRules(%q): %v", tt.keywords, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseKeywordRules(%q) = %+v, want %+v", tt.keywords, got, tt.want)
+			continue
+		}
+		for i, rule := range got {
+			regex := tt.regex != nil && tt.regex[i]
+			if (rule.Pattern != nil) != regex {
+				t.Errorf("ParseKeywordRules(%q)[%d] compiled = %v, want %v", tt.keywords, i, rule.Pattern != nil, regex)
+			}
+			rule.Pattern = nil
+			if rule != tt.want[i] {
+				t.Errorf("ParseKeywordRules(%q)[%d] = %+v, want %+v", tt.keywords, i, rule, tt.want[i])
+			}
+		}
+	}
+
+	// Rules print the way they were written, so saved lists round-trip.
+	rules, _ := ParseKeywordRules(`headers:re:^set-cookie: session=\w+>=1`)
+	if got := rules[0].String(); got != `headers:re:^set-cookie: session=\w+>=1` {
+		t.Errorf("String() = %q", got)
+	}
+	if !rules[0].Pattern.MatchString("Set-Cookie: SESSION=abc") {
+		t.Error("regex keyword matched case-sensitively")
+	}
+
+	invalid := map[string]string{
+		"re:":                   "no text to look for",
+		"re:order (#":           "missing closing )",
+		"headers:re:[a-":        "missing closing ]",
+		"headers#1:x":           "only redirect-chain takes a hop number",
+		"redirect-chain#0:x":    "only redirect-chain takes a hop number",
+		"body:cards>=0":         "must be a whole number of at least 1",
+		"redirect-chain:re:>=2": "no text to look for",
+	}
+	for keywords, want := range invalid {
+		if _, err := ParseKeywordRules(keywords); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseKeywordRules(%q) error = %v, want %q", keywords, err, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Monitor list orders for MonitorQuery.Sort besides the manual order.
+const (
+	MonitorSortName      = "name"
+	MonitorSortLastCheck = "last-check"
+)
+
+// StatusPaused selects disabled monitors in MonitorQuery.Status.
+const StatusPaused = "paused"
+
+// MonitorQuery selects and orders monitors in the database, so listing a
+// few of thousands doesn't load them all. Zero fields don't filter.
+type MonitorQuery struct {
+	// Status is a DisplayStatus value, or StatusPaused.
+	Status  string
+	Enabled bool
+	Tag     string
+	// Sort is MonitorSortName, MonitorSortLastCheck or "" for the manual
+	// order; the most recently checked come first.
+	Sort string
+}
+
+// ValidateMonitorQuery checks a query's status and sort order.
+func ValidateMonitorQuery(q MonitorQuery) error {
+	switch q.Status {
+	case "", "up", "down", "unknown", StatusPending, StatusOutOfSchedule, StatusPaused:
+	default:
+		return fmt.Errorf("invalid status %q: use up, down, unknown, pending, out_of_schedule or paused", q.Status)
+	}
+	switch q.Sort {
+	case "", MonitorSortName, MonitorSortLastCheck:
+	default:
+		return fmt.Errorf("invalid sort order %q: use name or last-check", q.Sort)
+	}
+	return nil
+}
+
+// pendingSQL matches monitors that DisplayStatus reports as pending.
+const pendingSQL = "last_check_at IS NULL AND (current_status = '' OR current_status = 'unknown')"
+
+// ListMonitorsMatching returns the monitors q selects, in q's order.
+func (d *Database) ListMonitorsMatching(q MonitorQuery) ([]Monitor, error) {
+	if err := ValidateMonitorQuery(q); err != nil {
+		return nil, err
+	}
+
+	tx := d.db.Model(&Monitor{})
+	switch q.Status {
+	case "":
+	case StatusPaused:
+		tx = tx.Where("enabled = ?", false)
+	case StatusPending:
+		tx = tx.Where(pendingSQL)
+	default:
+		tx = tx.Where("current_status = ? AND NOT ("+pendingSQL+")", q.Status)
+	}
+	if q.Enabled {
+		tx = tx.Where("enabled = ?", true)
+	}
+	if tag := strings.ToLower(strings.TrimSpace(q.Tag)); tag != "" {
+		tx = whereTag(tx, tag)
+	}
+
+	switch q.Sort {
+	case MonitorSortName:
+		tx = tx.Order("LOWER(name) asc, id asc")
+	case MonitorSortLastCheck:
+		// NULLs sort differently in SQLite and Postgres, so never-checked
+		// monitors are put last explicitly.
+		tx = tx.Order("last_check_at IS NULL, last_check_at desc, id asc")
+	default:
+		tx = tx.Order("position asc, id asc")
+	}
+
+	var monitors []Monitor
+	err := tx.Find(&monitors).Error
+	return monitors, err
+}
+
+// whereTag matches monitors whose comma-separated tags include tag, compared
+// case-insensitively and ignoring spaces around the commas.
+func whereTag(tx *gorm.DB, tag string) *gorm.DB {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(tag)
+	return tx.Where(`(',' || LOWER(REPLACE(tags, ' ', '')) || ',') LIKE ? ESCAPE '\'`, "%,"+escaped+",%")
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListMonitorsMatching(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, d *Database) {
+		now := time.Now()
+		at := func(ago time.Duration) *time.Time {
+			v := now.Add(-ago)
+			return &v
+		}
+		for _, m := range []struct {
+			name, status, tags string
+			lastCheck          *time.Time
+			paused             bool
+		}{
+			{"beta", "up", "Prod, web", at(time.Minute), false},
+			{"Alpha", "down", "prod", at(5 * time.Minute), false},
+			{"gamma", "", "axb", nil, false},
+			{"delta", "up", "a_b", at(10 * time.Minute), true},
+			{"epsilon", "unknown", "", at(2 * time.Minute), false},
+		} {
+			mustCreateMonitor(t, d, "https://"+m.name+".example.com", func(c *Monitor) {
+				c.Name, c.CurrentStatus, c.Tags, c.LastCheckAt = m.name, m.status, m.tags, m.lastCheck
+				c.Enabled = !m.paused
+			})
+		}
+
+		for _, tc := range []struct {
+			name  string
+			query MonitorQuery
+			want  []string
+		}{
+			{"all in manual order", MonitorQuery{}, []string{"beta", "Alpha", "gamma", "delta", "epsilon"}},
+			{"up", MonitorQuery{Status: "up"}, []string{"beta", "delta"}},
+			{"up and enabled", MonitorQuery{Status: "up", Enabled: true}, []string{"beta"}},
+			{"down", MonitorQuery{Status: "down"}, []string{"Alpha"}},
+			{"pending", MonitorQuery{Status: StatusPending}, []string{"gamma"}},
+			{"unknown excludes pending", MonitorQuery{Status: "unknown"}, []string{"epsilon"}},
+			{"paused", MonitorQuery{Status: StatusPaused}, []string{"delta"}},
+			{"tag ignores case and spaces", MonitorQuery{Tag: " PROD "}, []string{"beta", "Alpha"}},
+			{"tag after a space", MonitorQuery{Tag: "web"}, []string{"beta"}},
+			{"tag wildcards are literal", MonitorQuery{Tag: "a_b"}, []string{"delta"}},
+			{"by name", MonitorQuery{Sort: MonitorSortName}, []string{"Alpha", "beta", "delta", "epsilon", "gamma"}},
+			{"by last check", MonitorQuery{Sort: MonitorSortLastCheck}, []string{"beta", "epsilon", "Alpha", "delta", "gamma"}},
+		} {
+			monitors, err := d.ListMonitorsMatching(tc.query)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			var got []string
+			for _, m := range monitors {
+				got = append(got, m.Name)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			}
+		}
+
+		for _, q := range []MonitorQuery{{Status: "degraded"}, {Sort: "uptime"}} {
+			if _, err := d.ListMonitorsMatching(q); err == nil {
+				t.Errorf("query %+v accepted", q)
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaVersion records a migration that has been applied to the database.
+type SchemaVersion struct {
+	Version   int       `gorm:"primarykey" json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// migration is one step of the schema history. up runs in a transaction
+// together with recording the new version.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// models are the tables a brand-new database is created with. They must
+// always describe the schema after the latest migration.
+var models = []interface{}{
+	&Monitor{}, &CheckResult{}, &Incident{}, &Setting{},
+	&ContentSnapshot{}, &ContentChange{}, &MonitoringGap{}, &AuditEntry{},
+	&NotificationDelivery{}, &StatusChange{},
+}
+
+// migrations lists every schema change in order. Versions are never
+// reused or edited once released; append a new one instead.
+//
+// Databases created before versioning existed start at the baseline,
+// which auto-migrates the current models. Since that may already include
+// what later migrations add, migrations must check before they add or drop
+// a column or index.
+var migrations = []migration{
+	{1, "baseline", func(tx *gorm.DB) error {
+		return tx.AutoMigrate(models...)
+	}},
+	{2, "monitor minimum body size", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "MinBodyBytes")
+	}},
+	{3, "notification log", func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&NotificationDelivery{})
+	}},
+	{4, "address family", func(tx *gorm.DB) error {
+		if err := addColumn(tx, &Monitor{}, "AddressFamily"); err != nil {
+			return err
+		}
+		if err := addColumn(tx, &CheckResult{}, "IPv4Time"); err != nil {
+			return err
+		}
+		return addColumn(tx, &CheckResult{}, "IPv6Time")
+	}},
+	{5, "protocol assertion", func(tx *gorm.DB) error {
+		if err := addColumn(tx, &Monitor{}, "RequiredProto"); err != nil {
+			return err
+		}
+		return addColumn(tx, &CheckResult{}, "Protocol")
+	}},
+	{6, "normalized monitor URLs", func(tx *gorm.DB) error {
+		if err := addColumn(tx, &Monitor{}, "NormalizedURL"); err != nil {
+			return err
+		}
+		if !tx.Migrator().HasIndex(&Monitor{}, "NormalizedURL") {
+			if err := tx.Migrator().CreateIndex(&Monitor{}, "NormalizedURL"); err != nil {
+				return err
+			}
+		}
+		// Unscoped: the trash's deleted_at column is only added by
+		// migration 10.
+		var monitors []Monitor
+		if err := tx.Unscoped().Select("id", "url").Find(&monitors).Error; err != nil {
+			return err
+		}
+		for _, m := range monitors {
+			if err := tx.Unscoped().Model(&Monitor{}).Where("id = ?", m.ID).Update("normalized_url", NormalizeURL(m.URL)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{7, "opsgenie priority", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "OpsGeniePriority")
+	}},
+	{8, "json schema", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "JSONSchema")
+	}},
+	{9, "target latency", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "TargetLatencyMs")
+	}},
+	{10, "monitor trash", func(tx *gorm.DB) error {
+		if err := addColumn(tx, &Monitor{}, "DeletedAt"); err != nil {
+			return err
+		}
+		if tx.Migrator().HasIndex(&Monitor{}, "DeletedAt") {
+			return nil
+		}
+		return tx.Migrator().CreateIndex(&Monitor{}, "DeletedAt")
+	}},
+	{11, "notification cooldown", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "NotificationCooldown")
+	}},
+	{12, "expected body hash", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "ExpectedSHA256")
+	}},
+	{13, "banner checks", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "ExpectedBanner")
+	}},
+	{14, "tcp port results", func(tx *gorm.DB) error {
+		return addColumn(tx, &CheckResult{}, "PortResults")
+	}},
+	{15, "oauth2 client credentials", func(tx *gorm.DB) error {
+		for _, field := range []string{"OAuth2TokenURL", "OAuth2ClientID", "OAuth2ClientSecret", "OAuth2Scopes"} {
+			if err := addColumn(tx, &Monitor{}, field); err != nil {
+				return err
+			}
+		}
+		return addColumn(tx, &CheckResult{}, "AuthError")
+	}},
+	{16, "status changes", func(tx *gorm.DB) error {
+		return tx.AutoMigrate(&StatusChange{})
+	}},
+	{17, "latency anomalies", func(tx *gorm.DB) error {
+		return addColumn(tx, &CheckResult{}, "Anomalous")
+	}},
+	{18, "connect timeout", func(tx *gorm.DB) error {
+		return addColumn(tx, &Monitor{}, "ConnectTimeout")
+	}},
+}
+
+// addColumn adds the column for model's field unless it exists.
+func addColumn(tx *gorm.DB, model interface{}, field string) error {
+	if tx.Migrator().HasColumn(model, field) {
+		return nil
+	}
+	return tx.Migrator().AddColumn(model, field)
+}
+
+// latestVersion is the schema version this build expects.
+func latestVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate brings the schema up to date. A brand-new database is created
+// from the models and marked as being at the latest version; an existing
+// one runs the migrations it hasn't applied yet, each in its own
+// transaction.
+func migrate(db *gorm.DB) error {
+	fresh := !db.Migrator().HasTable(&Monitor{})
+	if err := db.AutoMigrate(&SchemaVersion{}); err != nil {
+		return err
+	}
+
+	if fresh {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(models...); err != nil {
+				return err
+			}
+			last := migrations[len(migrations)-1]
+			return tx.Create(&SchemaVersion{Version: last.version, Name: last.name, AppliedAt: time.Now()}).Error
+		})
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if current > latestVersion() {
+		return fmt.Errorf("database schema version %d is newer than this statping supports (%d); upgrade statping", current, latestVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaVersion{Version: m.version, Name: m.name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		slog.Info("applied database migration", "version", m.version, "name", m.name)
+	}
+	return nil
+}
+
+func schemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaVersion{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// SchemaVersion returns the version of the most recent migration applied
+// to the database.
+func (d *Database) SchemaVersion() (int, error) {
+	return schemaVersion(d.db)
+}
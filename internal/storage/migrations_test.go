@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// loadFixture creates a SQLite database at path from the SQL in file.
+func loadFixture(t *testing.T, path, file string) {
+	t.Helper()
+	script, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(script)); err != nil {
+		t.Fatalf("load %s: %v", file, err)
+	}
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "statping.db")
+	loadFixture(t, path, filepath.Join("testdata", "schema_v1.sql"))
+
+	d, err := New(DriverSQLite, path)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	defer d.Close()
+
+	version, err := d.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != latestVersion() {
+		t.Errorf("schema version = %d, want %d", version, latestVersion())
+	}
+	var applied int64
+	if err := d.GetDB().Model(&SchemaVersion{}).Count(&applied).Error; err != nil {
+		t.Fatal(err)
+	}
+	if applied != int64(len(migrations)) {
+		t.Errorf("%d migrations recorded, want %d", applied, len(migrations))
+	}
+
+	monitors, err := d.ListMonitors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monitors) != 3 {
+		t.Fatalf("%d monitors after migrating, want 3", len(monitors))
+	}
+
+	m, err := d.GetMonitor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "Example" || m.CheckInterval != 30 || m.Timeout != 15 || m.ExpectedCodes != "200,204" ||
+		m.Tags != "prod,web" || m.LatencyThreshold != 2000 || m.LatencyAgg != "p95" || m.CurrentStatus != "up" {
+		t.Errorf("monitor 1 changed by migrating: %+v", m)
+	}
+	if want := NormalizeURL(m.URL); m.NormalizedURL != want {
+		t.Errorf("normalized URL = %q, want it backfilled as %q", m.NormalizedURL, want)
+	}
+	if m.MinBodyBytes != 0 || m.ConnectTimeout != 0 || m.ExpectedSHA256 != "" || m.OAuth2TokenURL != "" {
+		t.Errorf("added columns aren't empty: %+v", m)
+	}
+
+	hb, err := d.GetMonitorByHeartbeatToken("abc123")
+	if err != nil {
+		t.Fatalf("heartbeat token lost: %v", err)
+	}
+	if hb.ID != 2 || hb.GracePeriod != 600 || hb.Public {
+		t.Errorf("heartbeat monitor changed by migrating: %+v", hb)
+	}
+	if paused, err := d.GetMonitor(3); err != nil || paused.Enabled {
+		t.Errorf("paused monitor = %+v, %v; want it still paused", paused, err)
+	}
+
+	for _, tc := range []struct {
+		model     interface{}
+		monitorID uint
+		want      int64
+	}{
+		{&CheckResult{}, 1, 2},
+		{&CheckResult{}, 3, 1},
+		{&Incident{}, 1, 1},
+		{&Incident{}, 2, 1},
+		{&AuditEntry{}, 1, 1},
+		{&MonitoringGap{}, 1, 1},
+	} {
+		if n := countRows(t, d, tc.model, tc.monitorID); n != tc.want {
+			t.Errorf("%T rows for monitor %d = %d, want %d", tc.model, tc.monitorID, n, tc.want)
+		}
+	}
+
+	results, err := d.GetRecentCheckResults(1, 1)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("latest result = %v, %v", results, err)
+	}
+	if r := results[0]; r.StatusCode != 503 || r.ResponseTime != 340 || r.ErrorMessage != "unexpected status 503" || r.TLSVersion != "TLS 1.3" {
+		t.Errorf("latest result changed by migrating: %+v", r)
+	}
+
+	open, err := d.GetActiveIncident(2)
+	if err != nil || open == nil || open.ErrorMessage != "no ping for 10m" {
+		t.Errorf("open incident = %+v, %v", open, err)
+	}
+	if d.GetBoolSetting(SettingNotificationsEnabled, true) {
+		t.Error("notifications setting lost")
+	}
+
+	// The migrated tables take rows using the columns added since.
+	cr := &CheckResult{MonitorID: 1, CreatedAt: time.Now(), Success: true, ResponseTime: 90, IPv4Time: 40, Protocol: "h2", Anomalous: true}
+	if err := d.CreateCheckResult(cr); err != nil {
+		t.Errorf("write after migrating: %v", err)
+	}
+	if err := d.CreateStatusChange(&StatusChange{MonitorID: 1, At: time.Now(), FromStatus: "down", ToStatus: "up"}); err != nil {
+		t.Errorf("write to a table added by migrating: %v", err)
+	}
+}
@@ -1,25 +1,135 @@
 package storage
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"gorm.io/gorm"
+)
+
+// Monitor types. HTTP monitors are probed by the checker; heartbeat monitors
+// wait for the monitored job to ping them. Banner monitors connect to a
+// host:port and read the first line the server sends, like an SSH or SMTP
+// greeting. TCP monitors only connect, to one or more ports of a host.
+const (
+	MonitorTypeHTTP      = "http"
+	MonitorTypeHeartbeat = "heartbeat"
+	MonitorTypeBanner    = "banner"
+	MonitorTypeTCP       = "tcp"
+)
+
+// Notification channels a monitor can be routed to.
+const (
+	ChannelDesktop  = "desktop"
+	ChannelMatrix   = "matrix"
+	ChannelWebhook  = "webhook"
+	ChannelOpsGenie = "opsgenie"
+	ChannelTeams    = "teams"
+	ChannelSignal   = "signal"
+
+	// Channels configured by URL rather than settings of their own.
+	ChannelSlack    = "slack"
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+	ChannelNtfy     = "ntfy"
+)
+
+// DefaultOpsGeniePriority is the OpsGenie priority of alerts for monitors
+// that don't set one.
+const DefaultOpsGeniePriority = "P3"
+
+// Incident types. Availability incidents cover a monitor being down;
+// performance incidents cover it responding slower than its latency rule
+// allows while still up.
+const (
+	IncidentAvailability = "availability"
+	IncidentPerformance  = "performance"
+)
+
+// Aggregations a latency rule can apply over its window.
+const (
+	LatencyAvg = "avg"
+	LatencyP95 = "p95"
+)
+
+// HTTP versions a monitor can be pinned to. The empty string lets the
+// client negotiate.
+const (
+	HTTPVersionAuto = ""
+	HTTPVersion1    = "1.1"
+	HTTPVersion2    = "2"
+)
+
+// Address families a monitor can connect over. AddressFamilyBoth checks
+// over IPv4 and IPv6 every cycle and fails if either does.
+const (
+	AddressFamilyAny  = ""
+	AddressFamilyIPv4 = "ipv4"
+	AddressFamilyIPv6 = "ipv6"
+	AddressFamilyBoth = "both"
 )
 
 type Monitor struct {
-	ID               uint          `gorm:"primarykey" json:"id"`
-	CreatedAt        time.Time     `json:"created_at"`
-	UpdatedAt        time.Time     `json:"updated_at"`
-	Name             string        `gorm:"not null" json:"name"`
-	URL              string        `gorm:"not null;uniqueIndex" json:"url"`
-	Enabled          bool          `gorm:"default:true" json:"enabled"`
-	CheckInterval    int           `gorm:"default:60" json:"check_interval"`
-	ExpectedCodes    string        `json:"expected_codes"`
-	Keywords         string        `json:"keywords"`
-	Timeout          int           `gorm:"default:10" json:"timeout"`
-	CurrentStatus    string        `gorm:"default:unknown" json:"current_status"`
-	ConsecutiveFails int           `json:"consecutive_fails"`
-	LastCheckAt      *time.Time    `json:"last_check_at"`
-	CheckResults     []CheckResult `gorm:"foreignKey:MonitorID" json:"-"`
-	Incidents        []Incident    `gorm:"foreignKey:MonitorID" json:"-"`
+	ID                   uint           `gorm:"primarykey" json:"id"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
+	Name                 string         `gorm:"not null" json:"name"`
+	Type                 string         `gorm:"default:http" json:"type"`
+	URL                  string         `gorm:"not null;uniqueIndex" json:"url"`
+	NormalizedURL        string         `gorm:"index" json:"normalized_url"`
+	Enabled              bool           `gorm:"default:true" json:"enabled"`
+	Public               bool           `gorm:"default:true" json:"public"`
+	CheckInterval        int            `gorm:"default:60" json:"check_interval"`
+	ExpectedCodes        string         `json:"expected_codes"`
+	Keywords             string         `json:"keywords"`
+	MinBodyBytes         int            `json:"min_body_bytes"`
+	ExpectedSHA256       string         `json:"expected_sha256"`
+	ExpectedBanner       string         `json:"expected_banner"`
+	JSONSchema           string         `json:"json_schema"`
+	Tags                 string         `json:"tags"`
+	Position             int            `gorm:"default:0;index" json:"position"`
+	SLATarget            float64        `json:"sla_target"`
+	Timeout              int            `gorm:"default:10" json:"timeout"`
+	ConnectTimeout       int            `json:"connect_timeout"`
+	MaxFailures          int            `json:"max_failures"`
+	NotificationCooldown int            `json:"notification_cooldown"`
+	Channels             string         `json:"channels"`
+	OpsGeniePriority     string         `json:"opsgenie_priority"`
+	UserAgent            string         `json:"user_agent"`
+	CheckHeader          bool           `json:"check_header"`
+	DisableKeepAlive     bool           `json:"disable_keep_alive"`
+	HTTPVersion          string         `json:"http_version"`
+	RequiredProto        string         `json:"required_proto"`
+	AddressFamily        string         `json:"address_family"`
+	SkipTLSVerify        bool           `json:"skip_tls_verify"`
+	Proxy                string         `json:"proxy"`
+	OAuth2TokenURL       string         `gorm:"column:oauth2_token_url" json:"oauth2_token_url"`
+	OAuth2ClientID       string         `gorm:"column:oauth2_client_id" json:"oauth2_client_id"`
+	OAuth2ClientSecret   string         `gorm:"column:oauth2_client_secret" json:"-"`
+	OAuth2Scopes         string         `gorm:"column:oauth2_scopes" json:"oauth2_scopes"`
+	WatchContent         bool           `json:"watch_content"`
+	IgnorePatterns       string         `json:"ignore_patterns"`
+	HeartbeatToken       string         `gorm:"index" json:"-"`
+	GracePeriod          int            `json:"grace_period"`
+	LastPingAt           *time.Time     `json:"last_ping_at"`
+	CurrentStatus        string         `gorm:"default:unknown" json:"current_status"`
+	ConsecutiveFails     int            `json:"consecutive_fails"`
+	LastCheckAt          *time.Time     `json:"last_check_at"`
+	Backoff              bool           `json:"backoff"`
+	ActiveHours          string         `json:"active_hours"`
+	LatencyThreshold     int            `json:"latency_threshold"`
+	LatencyWindow        int            `json:"latency_window"`
+	LatencyAgg           string         `json:"latency_aggregation"`
+	TargetLatencyMs      int            `json:"target_latency_ms"`
+	NextCheckAt          *time.Time     `json:"next_check_at"`
+	PausedUntil          *time.Time     `json:"paused_until,omitempty"`
+	CheckResults         []CheckResult  `gorm:"foreignKey:MonitorID" json:"-"`
+	Incidents            []Incident     `gorm:"foreignKey:MonitorID" json:"-"`
 }
 
 type CheckResult struct {
@@ -30,6 +140,37 @@ type CheckResult struct {
 	ResponseTime int64     `json:"response_time"`
 	Success      bool      `json:"success"`
 	ErrorMessage string    `json:"error_message"`
+	ContentHash  string    `json:"content_hash,omitempty"`
+	ProxyError   bool      `gorm:"default:false" json:"proxy_error,omitempty"`
+	AuthError    bool      `gorm:"default:false" json:"auth_error,omitempty"`
+	// Anomalous is set on successful checks whose response time was far
+	// above the monitor's recent baseline.
+	Anomalous  bool   `gorm:"default:false" json:"anomalous,omitempty"`
+	ResolvedIP string `json:"resolved_ip,omitempty"`
+	TLSVersion string `json:"tls_version,omitempty"`
+	TLSCipher  string `json:"tls_cipher,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+
+	// Phase timings in milliseconds. Phases a reused connection skips are
+	// zero, as are all of them for checks recorded before they existed.
+	DNSTime       int64 `json:"dns_time,omitempty"`
+	ConnectTime   int64 `json:"connect_time,omitempty"`
+	TLSTime       int64 `json:"tls_time,omitempty"`
+	FirstByteTime int64 `json:"first_byte_time,omitempty"`
+
+	// Per-family response times in milliseconds, only set for monitors
+	// checking both address families. Zero when that family failed.
+	IPv4Time int64 `gorm:"column:ipv4_time" json:"ipv4_time,omitempty"`
+	IPv6Time int64 `gorm:"column:ipv6_time" json:"ipv6_time,omitempty"`
+
+	// ResponseSnippet and ResponseHeaders show what the server returned
+	// instead of what was expected. They are only stored for failed checks.
+	ResponseSnippet string `json:"response_snippet,omitempty"`
+	ResponseHeaders string `json:"response_headers,omitempty"`
+
+	// PortResults has a line per port of a TCP check, like "443: 12ms" or
+	// "5432: connection refused".
+	PortResults string `json:"port_results,omitempty"`
 }
 
 type Incident struct {
@@ -37,13 +178,701 @@ type Incident struct {
 	CreatedAt        time.Time  `json:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at"`
 	MonitorID        uint       `gorm:"index;not null" json:"monitor_id"`
+	Type             string     `gorm:"default:availability" json:"type"`
 	StartedAt        time.Time  `json:"started_at"`
 	ResolvedAt       *time.Time `json:"resolved_at"`
+	Resolution       string     `json:"resolution,omitempty"`
 	ErrorMessage     string     `json:"error_message"`
 	Notified         bool       `gorm:"default:false" json:"notified"`
 	RecoveryNotified bool       `gorm:"default:false" json:"recovery_notified"`
 }
 
+// ContentSnapshot holds the normalized body from the latest check of a
+// monitor that watches its content, to diff the next one against.
+type ContentSnapshot struct {
+	MonitorID uint      `gorm:"primarykey" json:"monitor_id"`
+	Hash      string    `json:"hash"`
+	Body      string    `json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ContentChange records a check whose normalized body differed from the
+// previous one.
+type ContentChange struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	MonitorID    uint      `gorm:"index;not null" json:"monitor_id"`
+	OldHash      string    `json:"old_hash"`
+	NewHash      string    `json:"new_hash"`
+	BytesChanged int       `json:"bytes_changed"`
+	Summary      string    `json:"summary"`
+}
+
+// MonitoringGap is a stretch of time in which a monitor went unchecked,
+// e.g. because no daemon was running. Uptime counts it as unknown.
+type MonitoringGap struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	MonitorID uint      `gorm:"index;not null" json:"monitor_id"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+// StatusChange records the checker changing a monitor's status. CheckID is
+// the check result that caused it, unless none did, as when active hours
+// end.
+type StatusChange struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	MonitorID  uint      `gorm:"index;not null" json:"monitor_id"`
+	At         time.Time `gorm:"index" json:"at"`
+	FromStatus string    `json:"from"`
+	ToStatus   string    `json:"to"`
+	CheckID    *uint     `json:"check_id,omitempty"`
+}
+
+// AuditEntry records a pause or resume of a monitor: who asked for it and,
+// for a timed pause, until when.
+type AuditEntry struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	MonitorID uint      `gorm:"index;not null" json:"monitor_id"`
+	Action    string    `json:"action"`
+	Source    string    `json:"source"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// NotificationDelivery records one attempt to deliver a notification to an
+// external receiver. A test delivery has no monitor.
+type NotificationDelivery struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+	Channel         string    `json:"channel"`
+	MonitorID       uint      `json:"monitor_id,omitempty"`
+	Event           string    `json:"event"`
+	URL             string    `json:"url"`
+	Attempt         int       `json:"attempt"`
+	StatusCode      int       `json:"status_code"`
+	Latency         int64     `json:"latency"`
+	Success         bool      `json:"success"`
+	Error           string    `json:"error,omitempty"`
+	ResponseSnippet string    `json:"response_snippet,omitempty"`
+}
+
+// DeliveryMuted is the channel of log entries for notifications withheld
+// because notifications were off or snoozed.
+const DeliveryMuted = "muted"
+
+func (NotificationDelivery) TableName() string {
+	return "notification_log"
+}
+
+// Setting is a key/value pair for runtime state that must survive restarts,
+// such as an active snooze.
+type Setting struct {
+	Key       string    `gorm:"primarykey" json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DailyUptime aggregates the check results of a single calendar day in the
+// local time zone. AvgResponseTime only covers successful checks and
+// IncidentMinutes is how long availability incidents were open that day.
+type DailyUptime struct {
+	Date            time.Time `json:"date"`
+	Checks          int64     `json:"checks"`
+	Failures        int64     `json:"failures"`
+	Uptime          float64   `json:"uptime"`
+	AvgResponseTime float64   `json:"avg_response_time"`
+	IncidentMinutes float64   `json:"incident_minutes"`
+}
+
+// CheckBucket aggregates the check results that fall into one fixed-width
+// time bucket. AvgResponseTime only covers successful checks.
+type CheckBucket struct {
+	Start           time.Time `json:"timestamp"`
+	Checks          int64     `json:"checks"`
+	Failures        int64     `json:"failures"`
+	AvgResponseTime float64   `json:"avg_response_time"`
+}
+
+// SeriesPoint summarises the checks in one bucket of a chart series. The
+// response time fields cover successful checks only and are nil when the
+// bucket has none, so empty buckets plot as gaps rather than zeros.
+type SeriesPoint struct {
+	Start           time.Time `json:"timestamp"`
+	Checks          int64     `json:"checks"`
+	Failures        int64     `json:"failures"`
+	AvgResponseTime *float64  `json:"avg_response_time"`
+	MinResponseTime *int64    `json:"min_response_time"`
+	MaxResponseTime *int64    `json:"max_response_time"`
+	P95ResponseTime *int64    `json:"p95_response_time"`
+}
+
+// CheckResultFilter selects a page of check results, newest first. A nil
+// Success matches both outcomes and a zero Limit means no limit.
+type CheckResultFilter struct {
+	MonitorID uint
+	Since     time.Time
+	Success   *bool
+	Limit     int
+	Offset    int
+}
+
+// CheckStats summarizes a monitor's check results over a period.
+// AvgResponseTime only covers successful checks, so it is meaningless
+// unless HasResponseTime.
+type CheckStats struct {
+	Total           int64
+	Successful      int64
+	AvgResponseTime float64
+	Uptime          Uptime
+}
+
+// HasResponseTime reports whether any check succeeded, so that a monitor
+// that never responded isn't mistaken for one that responds in 0ms.
+func (s CheckStats) HasResponseTime() bool {
+	return s.Successful > 0
+}
+
+// MonitorStats aggregates one monitor's check results over a period for
+// views that list every monitor. Response times only cover successful
+// checks and are zero without any.
+type MonitorStats struct {
+	MonitorID       uint    `json:"monitor_id"`
+	Checks          int64   `json:"checks"`
+	Successes       int64   `json:"successes"`
+	AvgResponseTime float64 `json:"avg_response_time"`
+	MaxResponseTime int64   `json:"max_response_time"`
+}
+
+// ResponseTimePercentiles holds nearest-rank percentiles of the response
+// times of successful checks, in milliseconds. Count is the number of
+// successful checks; with none, the percentiles are all zero.
+type ResponseTimePercentiles struct {
+	P50   int64 `json:"p50"`
+	P95   int64 `json:"p95"`
+	P99   int64 `json:"p99"`
+	Count int64 `json:"count"`
+}
+
+// IncidentStats summarizes the incidents that started within a period.
+// Ongoing incidents count up to now; MTTR only covers resolved ones.
+type IncidentStats struct {
+	Count    int64
+	Downtime time.Duration
+	MTTR     time.Duration
+	MTBF     time.Duration
+	Longest  time.Duration
+}
+
+// DeleteResult reports what emptying the trash removed for one monitor.
+type DeleteResult struct {
+	Monitor      Monitor `json:"-"`
+	CheckResults int64   `json:"check_results"`
+	Incidents    int64   `json:"incidents"`
+}
+
+// Summary returns e.g. "Removed monitor 'API' and 3,412 check results".
+func (r *DeleteResult) Summary() string {
+	s := fmt.Sprintf("Removed monitor '%s' and %s check results", r.Monitor.Name, formatCount(r.CheckResults))
+	if r.Incidents > 0 {
+		s += fmt.Sprintf(", %s incidents", formatCount(r.Incidents))
+	}
+	return s
+}
+
+func formatCount(n int64) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+	digits := strconv.FormatInt(n, 10)
+
+	var out []byte
+	for i := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, digits[i])
+	}
+	return string(out)
+}
+
+func (m *Monitor) IsHeartbeat() bool {
+	return m.Type == MonitorTypeHeartbeat
+}
+
+// StatusPending is how a monitor that has never been checked is shown. It
+// is not stored: the checker sets CurrentStatus after the first check,
+// whatever its outcome, so "unknown" afterwards means checks ran but could
+// not tell whether the monitor is up.
+const StatusPending = "pending"
+
+// DisplayStatus is the status to show for m: CurrentStatus, or
+// StatusPending before its first check.
+func (m *Monitor) DisplayStatus() string {
+	if m.LastCheckAt == nil && (m.CurrentStatus == "" || m.CurrentStatus == "unknown") {
+		return StatusPending
+	}
+	return m.CurrentStatus
+}
+
+// Clone returns a copy of m's configuration as a new, unsaved monitor with
+// no status or history. A heartbeat clone gets its own token, since two
+// monitors can't share a ping URL.
+func (m *Monitor) Clone() (*Monitor, error) {
+	c := *m
+	c.ID = 0
+	c.CreatedAt = time.Time{}
+	c.UpdatedAt = time.Time{}
+	c.Position = 0
+	c.CurrentStatus = ""
+	c.ConsecutiveFails = 0
+	c.LastCheckAt = nil
+	c.NextCheckAt = nil
+	c.LastPingAt = nil
+	c.CheckResults = nil
+	c.Incidents = nil
+
+	if c.IsHeartbeat() {
+		token, err := NewHeartbeatToken()
+		if err != nil {
+			return nil, err
+		}
+		c.HeartbeatToken = token
+		c.URL = HeartbeatURL(token)
+	}
+	return &c, nil
+}
+
+// Redacted returns a copy of m without the secrets its configuration
+// holds, for showing to anyone who can read the monitor list. A heartbeat's
+// token is all its ping endpoint asks for, and its URL contains the token;
+// a proxy URL may carry a password.
+func (m *Monitor) Redacted() Monitor {
+	r := *m
+	r.Proxy = RedactProxy(r.Proxy)
+	if r.IsHeartbeat() {
+		r.HeartbeatToken = ""
+		r.URL = HeartbeatURL(redacted)
+		r.NormalizedURL = r.URL
+	}
+	return r
+}
+
+// redacted stands in for a secret, as in url.URL.Redacted.
+const redacted = "xxxxx"
+
+// NotifiesVia reports whether m's alerts go to channel. A monitor without
+// channels notifies through all of them.
+func (m *Monitor) NotifiesVia(channel string) bool {
+	if strings.TrimSpace(m.Channels) == "" {
+		return true
+	}
+	for _, c := range strings.Split(m.Channels, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == channel || strings.HasPrefix(c, channel+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// TeamsWebhooks returns the names of the Teams webhooks m is routed to
+// with "teams:<name>" entries, or all when it goes to every one of them.
+func (m *Monitor) TeamsWebhooks() (names []string, all bool) {
+	if strings.TrimSpace(m.Channels) == "" {
+		return nil, true
+	}
+	for _, c := range strings.Split(m.Channels, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == ChannelTeams {
+			return nil, true
+		}
+		if name, ok := strings.CutPrefix(c, ChannelTeams+":"); ok {
+			names = append(names, name)
+		}
+	}
+	return names, false
+}
+
+// Channels lists every notification channel.
+var Channels = []string{
+	ChannelDesktop, ChannelMatrix, ChannelWebhook, ChannelOpsGenie, ChannelTeams, ChannelSignal,
+	ChannelSlack, ChannelTelegram, ChannelEmail, ChannelNtfy,
+}
+
+// ParseChannels validates a comma-separated channel list and returns it
+// normalized. "teams:<name>" routes to one named Teams webhook.
+func ParseChannels(s string) (string, error) {
+	var out []string
+	for _, c := range strings.Split(s, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if name, ok := strings.CutPrefix(c, ChannelTeams+":"); ok {
+			if !validWebhookName(name) {
+				return "", fmt.Errorf("invalid Teams webhook name %q: use letters, digits, - and _", name)
+			}
+			out = append(out, c)
+			continue
+		}
+		switch c {
+		case "":
+			continue
+		case ChannelDesktop, ChannelMatrix, ChannelWebhook, ChannelOpsGenie, ChannelTeams, ChannelSignal,
+			ChannelSlack, ChannelTelegram, ChannelEmail, ChannelNtfy:
+			out = append(out, c)
+		default:
+			return "", fmt.Errorf("unknown notification channel %q: use %s or %s:<name>", c, strings.Join(Channels, ", "), ChannelTeams)
+		}
+	}
+	return strings.Join(out, ","), nil
+}
+
+// TeamsWebhook is one named entry of the teams-webhooks setting.
+type TeamsWebhook struct {
+	Name string
+	URL  string
+}
+
+// ParseTeamsWebhooks parses the teams-webhooks setting, a comma-separated
+// list of "<name>=<url>" entries. An entry without a name is called
+// "default".
+func ParseTeamsWebhooks(s string) ([]TeamsWebhook, error) {
+	var hooks []TeamsWebhook
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rawURL := "default", entry
+		if n, u, ok := strings.Cut(entry, "="); ok && !strings.Contains(n, "://") {
+			name, rawURL = strings.ToLower(strings.TrimSpace(n)), strings.TrimSpace(u)
+		}
+		if !validWebhookName(name) {
+			return nil, fmt.Errorf("invalid Teams webhook name %q: use letters, digits, - and _", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("Teams webhook %q is listed twice", name)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid URL for Teams webhook %q: %s", name, rawURL)
+		}
+		seen[name] = true
+		hooks = append(hooks, TeamsWebhook{Name: name, URL: rawURL})
+	}
+	return hooks, nil
+}
+
+func validWebhookName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateHTTPVersion accepts "", "auto", "1.1" and "2" and returns the
+// value to store.
+func ValidateHTTPVersion(v string) (string, error) {
+	switch v {
+	case HTTPVersionAuto, "auto":
+		return HTTPVersionAuto, nil
+	case HTTPVersion1, "1", "http/1.1":
+		return HTTPVersion1, nil
+	case HTTPVersion2, "h2", "http/2":
+		return HTTPVersion2, nil
+	}
+	return "", fmt.Errorf("invalid HTTP version %q: use auto, 1.1 or 2", v)
+}
+
+// ValidateRequiredProto accepts "", "1.1" and "2" in the forms
+// ValidateHTTPVersion does, or the protocol as Go reports it, and returns
+// the response protocol to require, e.g. "HTTP/2.0". A monitor pinned to a
+// different HTTP version could never meet it, and heartbeat monitors make
+// no request at all.
+func ValidateRequiredProto(v, httpVersion, monitorType string) (string, error) {
+	var proto string
+	switch strings.ToUpper(v) {
+	case "", "ANY":
+		return "", nil
+	case HTTPVersion1, "HTTP/1.1":
+		proto = "HTTP/1.1"
+	case HTTPVersion2, "2.0", "H2", "HTTP/2", "HTTP/2.0":
+		proto = "HTTP/2.0"
+	default:
+		return "", fmt.Errorf("invalid required protocol %q: use HTTP/1.1 or HTTP/2.0", v)
+	}
+	if monitorType == MonitorTypeHeartbeat {
+		return "", fmt.Errorf("heartbeat monitors make no request to require a protocol of")
+	}
+	if httpVersion != HTTPVersionAuto && "HTTP/"+httpVersion != strings.TrimSuffix(proto, ".0") {
+		return "", fmt.Errorf("monitor is pinned to HTTP/%s, so it can never get %s", httpVersion, proto)
+	}
+	return proto, nil
+}
+
+// ValidateAddressFamily accepts "", "any", "ipv4", "ipv6" and "both" and
+// returns the value to store.
+func ValidateAddressFamily(v string) (string, error) {
+	switch strings.ToLower(v) {
+	case AddressFamilyAny, "any":
+		return AddressFamilyAny, nil
+	case AddressFamilyIPv4, "4", "v4":
+		return AddressFamilyIPv4, nil
+	case AddressFamilyIPv6, "6", "v6":
+		return AddressFamilyIPv6, nil
+	case AddressFamilyBoth, "dual", "dual-stack":
+		return AddressFamilyBoth, nil
+	}
+	return "", fmt.Errorf("invalid address family %q: use any, ipv4, ipv6 or both", v)
+}
+
+// ValidateOpsGeniePriority accepts "" and P1 to P5 in any case and
+// returns the value to store. The empty string means
+// DefaultOpsGeniePriority.
+func ValidateOpsGeniePriority(v string) (string, error) {
+	p := strings.ToUpper(strings.TrimSpace(v))
+	if p == "" {
+		return "", nil
+	}
+	if len(p) == 2 && p[0] == 'P' && p[1] >= '1' && p[1] <= '5' {
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid OpsGenie priority %q: use P1 to P5", v)
+}
+
+// ValidateLatencyAgg accepts "", "avg" and "p95" and returns the value to
+// store. The empty string means p95.
+func ValidateLatencyAgg(v string) (string, error) {
+	switch strings.ToLower(v) {
+	case "", LatencyP95, "95":
+		return LatencyP95, nil
+	case LatencyAvg, "average", "mean":
+		return LatencyAvg, nil
+	}
+	return "", fmt.Errorf("invalid latency aggregation %q: use avg or p95", v)
+}
+
+// DefaultLatencyWindow is the window of a latency rule that does not set
+// one.
+const DefaultLatencyWindow = 15 * time.Minute
+
+// HasLatencyRule reports whether m alerts on slow responses.
+func (m *Monitor) HasLatencyRule() bool {
+	return m.LatencyThreshold > 0 && !m.IsHeartbeat()
+}
+
+// LatencyRuleWindow returns the window m's latency rule aggregates over.
+func (m *Monitor) LatencyRuleWindow() time.Duration {
+	if m.LatencyWindow <= 0 {
+		return DefaultLatencyWindow
+	}
+	return time.Duration(m.LatencyWindow) * time.Second
+}
+
+// LatencyRule describes m's latency rule, e.g. "p95 > 2000ms over 15m", or
+// returns "" when it has none.
+func (m *Monitor) LatencyRule() string {
+	if !m.HasLatencyRule() {
+		return ""
+	}
+	agg, _ := ValidateLatencyAgg(m.LatencyAgg)
+	window := strings.TrimSuffix(m.LatencyRuleWindow().String(), "0s")
+	if strings.HasSuffix(window, "h0m") {
+		window = strings.TrimSuffix(window, "0m")
+	}
+	return fmt.Sprintf("%s > %dms over %s", agg, m.LatencyThreshold, window)
+}
+
+// DefaultTargetLatency is the target response time, in milliseconds, of a
+// monitor that doesn't set one.
+const DefaultTargetLatency = 500
+
+// LatencyThresholds are the response times, in milliseconds, the UIs
+// colour latency against. They scale with the monitor's target: fast is
+// under 40% of it, slow over twice it, and the p99 is held to four times
+// it.
+type LatencyThresholds struct {
+	Fast   int64
+	Target int64
+	Slow   int64
+	Tail   int64
+}
+
+// LatencyThresholds returns the thresholds for m's target latency, or for
+// DefaultTargetLatency when it has none.
+func (m *Monitor) LatencyThresholds() LatencyThresholds {
+	target := int64(m.TargetLatencyMs)
+	if target <= 0 {
+		target = DefaultTargetLatency
+	}
+	return LatencyThresholds{Fast: target * 2 / 5, Target: target, Slow: 2 * target, Tail: 4 * target}
+}
+
+// NoCooldown as a monitor's NotificationCooldown sends a notification for every
+// down transition.
+const NoCooldown = -1
+
+// Cooldown is the least time between two down notifications for m:
+// NotificationCooldown seconds, the global default when it is 0, or none.
+func (m *Monitor) Cooldown() time.Duration {
+	switch {
+	case m.NotificationCooldown == NoCooldown:
+		return 0
+	case m.NotificationCooldown > 0:
+		return time.Duration(m.NotificationCooldown) * time.Second
+	}
+	return config.NotificationCooldown * time.Second
+}
+
+// ValidateCooldown checks a monitor's notification cooldown.
+func ValidateCooldown(seconds int) error {
+	if seconds < NoCooldown {
+		return fmt.Errorf("notification cooldown must be a number of seconds, 0 for the default or -1 for none")
+	}
+	return nil
+}
+
+// ValidateTargetLatency checks a monitor's target response time. Heartbeat
+// monitors don't make requests, so they have no response time.
+func ValidateTargetLatency(ms int, monitorType string) error {
+	if ms < 0 {
+		return fmt.Errorf("target latency must not be negative")
+	}
+	if ms > 0 && monitorType == MonitorTypeHeartbeat {
+		return fmt.Errorf("heartbeat monitors have no response time to set a target for")
+	}
+	return nil
+}
+
+// ValidateConnectTimeout checks a monitor's connect timeout, in seconds,
+// against its total timeout. 0 leaves connecting bounded by the total alone.
+func ValidateConnectTimeout(seconds, timeout int, monitorType string) error {
+	if seconds < 0 {
+		return fmt.Errorf("connect timeout must not be negative")
+	}
+	if seconds > 0 && monitorType == MonitorTypeHeartbeat {
+		return fmt.Errorf("heartbeat monitors don't connect anywhere")
+	}
+	if timeout <= 0 {
+		timeout = config.DefaultTimeout
+	}
+	if seconds > timeout {
+		return fmt.Errorf("connect timeout (%ds) can't be longer than the timeout (%ds)", seconds, timeout)
+	}
+	return nil
+}
+
+// ValidateMinBodyBytes checks a monitor's minimum response size. Heartbeat
+// monitors are pinged rather than requested, so they have no response to
+// measure.
+func ValidateMinBodyBytes(n int, monitorType string) error {
+	if n < 0 {
+		return fmt.Errorf("minimum body size must not be negative")
+	}
+	if n > 0 && monitorType == MonitorTypeHeartbeat {
+		return fmt.Errorf("heartbeat monitors have no response body to check a minimum size against")
+	}
+	if n > 0 && monitorType == MonitorTypeTCP {
+		return fmt.Errorf("tcp monitors only connect, so they have no response body to check a minimum size against")
+	}
+	return nil
+}
+
+// SHA256Auto asks for a monitor's expected body hash to be pinned from
+// the response it currently gets.
+const SHA256Auto = "auto"
+
+// ValidateSHA256 checks a monitor's expected body hash, a SHA-256 in hex,
+// and returns it lower-cased. Empty turns the check off. Heartbeat monitors
+// have no response body to hash.
+func ValidateSHA256(v, monitorType string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v == "" {
+		return "", nil
+	}
+	if monitorType == MonitorTypeHeartbeat {
+		return "", fmt.Errorf("heartbeat monitors have no response body to hash")
+	}
+	if monitorType == MonitorTypeTCP {
+		return "", fmt.Errorf("tcp monitors only connect, so they have no response body to hash")
+	}
+	if len(v) != 64 || strings.Trim(v, "0123456789abcdef") != "" {
+		return "", fmt.Errorf("invalid SHA-256 %q: expected 64 hex characters", v)
+	}
+	return v, nil
+}
+
+// ProxyDirect as a monitor's proxy bypasses the global and environment
+// proxy settings.
+const ProxyDirect = "direct"
+
+// ValidateProxy checks a proxy setting: empty, ProxyDirect, or an http,
+// https, socks5 or socks5h URL.
+func ValidateProxy(v string) error {
+	if v == "" || v == ProxyDirect {
+		return nil
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL %q: %w", v, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("invalid proxy URL %q: scheme must be http, https, socks5 or socks5h", v)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: missing host", v)
+	}
+	return nil
+}
+
+// RedactProxy hides the password in a proxy URL so it can be shown.
+func RedactProxy(v string) string {
+	if v == "" || v == ProxyDirect {
+		return v
+	}
+	if u, err := url.Parse(v); err == nil {
+		return u.Redacted()
+	}
+	return v
+}
+
+// ParseCORSOrigins parses the cors-origins setting, a comma-separated list
+// of origins like https://wiki.example.com, or * for any origin. An empty
+// setting disables CORS.
+func ParseCORSOrigins(v string) ([]string, error) {
+	var origins []string
+	for _, o := range strings.Split(v, ",") {
+		o = strings.TrimSpace(o)
+		if o == "" {
+			continue
+		}
+		if o == "*" {
+			origins = append(origins, o)
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid origin %q: use scheme://host[:port], e.g. https://wiki.example.com, or *", o)
+		}
+		origins = append(origins, strings.ToLower(u.Scheme+"://"+u.Host))
+	}
+	return origins, nil
+}
+
+// IsPerformance reports whether i was opened by a latency rule rather than
+// by the monitor going down.
+func (i *Incident) IsPerformance() bool {
+	return i.Type == IncidentPerformance
+}
+
 func (i *Incident) IsResolved() bool {
 	return i.ResolvedAt != nil
 }
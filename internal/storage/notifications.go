@@ -0,0 +1,13 @@
+package storage
+
+func (d *Database) CreateNotificationDelivery(n *NotificationDelivery) error {
+	return d.db.Create(n).Error
+}
+
+// GetNotificationDeliveries returns the most recent delivery attempts on
+// channel, newest first.
+func (d *Database) GetNotificationDeliveries(channel string, limit int) ([]NotificationDelivery, error) {
+	var deliveries []NotificationDelivery
+	err := d.db.Where("channel = ?", channel).Order("id desc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// HasOAuth2 reports whether m's checks authenticate with an OAuth2 token
+// obtained through the client credentials grant.
+func (m *Monitor) HasOAuth2() bool {
+	return m.OAuth2TokenURL != ""
+}
+
+// ClearOAuth2 removes m's OAuth2 configuration, secret included.
+func (m *Monitor) ClearOAuth2() {
+	m.OAuth2TokenURL = ""
+	m.OAuth2ClientID = ""
+	m.OAuth2ClientSecret = ""
+	m.OAuth2Scopes = ""
+}
+
+// ValidateOAuth2 checks a monitor's OAuth2 client credentials settings.
+// They are all empty or all set, apart from the scopes, which are optional.
+func ValidateOAuth2(tokenURL, clientID, clientSecret, monitorType string) error {
+	if tokenURL == "" && clientID == "" && clientSecret == "" {
+		return nil
+	}
+	if monitorType != MonitorTypeHTTP && monitorType != "" {
+		return fmt.Errorf("OAuth2 only applies to http monitors")
+	}
+	if tokenURL == "" {
+		return fmt.Errorf("an OAuth2 token URL is required")
+	}
+	u, err := url.Parse(tokenURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid OAuth2 token URL %q: use an http or https URL", tokenURL)
+	}
+	if clientID == "" {
+		return fmt.Errorf("an OAuth2 client ID is required")
+	}
+	if clientSecret == "" {
+		return fmt.Errorf("an OAuth2 client secret is required")
+	}
+	return nil
+}
+
+// NormalizeScopes turns a comma or space separated list of OAuth2 scopes
+// into the space separated form the token endpoint expects.
+func NormalizeScopes(scopes string) string {
+	return strings.Join(strings.FieldsFunc(scopes, func(r rune) bool {
+		return r == ',' || r == ' '
+	}), " ")
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audit actions and the sources that trigger them.
+const (
+	AuditPause  = "pause"
+	AuditResume = "resume"
+
+	AuditSourceCLI     = "cli"
+	AuditSourceAPI     = "api"
+	AuditSourceExpired = "expired"
+)
+
+// PauseMonitor pauses a monitor and records who did it. A non-nil until
+// makes the pause expire at that time; see ResumeExpiredPauses. detail is
+// an optional note, such as the reason given by a deploy pipeline.
+func (d *Database) PauseMonitor(id uint, until *time.Time, source, detail string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return pauseMonitor(tx, id, until, source, detail)
+	})
+}
+
+func pauseMonitor(tx *gorm.DB, id uint, until *time.Time, source, detail string) error {
+	err := tx.Model(&Monitor{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"enabled":      false,
+		"paused_until": until,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if until != nil {
+		if detail != "" {
+			detail += ", "
+		}
+		detail += "until " + until.Format(time.RFC3339)
+	}
+	return tx.Create(&AuditEntry{MonitorID: id, Action: AuditPause, Source: source, Detail: detail}).Error
+}
+
+// ResumeMonitor resumes a paused monitor and records who did it.
+func (d *Database) ResumeMonitor(id uint, source, detail string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return resumeMonitor(tx, id, source, detail)
+	})
+}
+
+func resumeMonitor(tx *gorm.DB, id uint, source, detail string) error {
+	err := tx.Model(&Monitor{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"enabled":      true,
+		"paused_until": nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(&AuditEntry{MonitorID: id, Action: AuditResume, Source: source, Detail: detail}).Error
+}
+
+// ResumeExpiredPauses resumes every monitor whose timed pause ended before
+// now and returns their IDs.
+func (d *Database) ResumeExpiredPauses(now time.Time) ([]uint, error) {
+	var ids []uint
+	err := d.db.Model(&Monitor{}).
+		Where("enabled = ? AND paused_until IS NOT NULL AND paused_until <= ?", false, now).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err := d.ResumeMonitor(id, AuditSourceExpired, ""); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// GetAuditLog returns a monitor's most recent pause and resume entries,
+// newest first.
+func (d *Database) GetAuditLog(monitorID uint, limit int) ([]AuditEntry, error) {
+	var entries []AuditEntry
+	err := d.db.Where("monitor_id = ?", monitorID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusOutOfSchedule is the status of a monitor outside its active hours.
+const StatusOutOfSchedule = "out_of_schedule"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule is a monitor's active hours: the days of the week it is checked
+// on and the time of day window, in a time zone. A window whose end is
+// before its start runs past midnight into the next day.
+type Schedule struct {
+	Days     [7]bool
+	Start    int // minutes after midnight
+	End      int // minutes after midnight, 1440 for the end of the day
+	Location *time.Location
+}
+
+// ParseSchedule parses active hours such as "mon-fri 09:00-18:00
+// Europe/Berlin". Each part is optional: days default to every day, the
+// window to the whole day and the zone to local time. An empty string
+// means no schedule and returns nil.
+func ParseSchedule(s string) (*Schedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	sched := &Schedule{End: 24 * 60, Location: time.Local}
+	var haveDays, haveTime, haveZone bool
+	for _, f := range fields {
+		switch {
+		case !haveTime && (strings.Contains(f, ":") || f[0] >= '0' && f[0] <= '9'):
+			start, end, ok := strings.Cut(f, "-")
+			if !ok {
+				return nil, fmt.Errorf("invalid time window %q (use e.g. 09:00-18:00)", f)
+			}
+			var err error
+			if sched.Start, err = parseClock(start); err != nil {
+				return nil, err
+			}
+			if sched.End, err = parseClock(end); err != nil {
+				return nil, err
+			}
+			if sched.Start == sched.End {
+				return nil, fmt.Errorf("time window %q is empty", f)
+			}
+			haveTime = true
+		case !haveDays && isDayList(f):
+			for _, part := range strings.Split(strings.ToLower(f), ",") {
+				from, to, isRange := strings.Cut(part, "-")
+				first, last := dayIndex(from), dayIndex(from)
+				if isRange {
+					last = dayIndex(to)
+				}
+				if first < 0 || last < 0 {
+					return nil, fmt.Errorf("invalid days %q (use e.g. mon-fri or sat,sun)", f)
+				}
+				for d := first; ; d = (d + 1) % 7 {
+					sched.Days[d] = true
+					if d == last {
+						break
+					}
+				}
+			}
+			haveDays = true
+		case !haveZone:
+			loc, err := time.LoadLocation(f)
+			if err != nil {
+				return nil, fmt.Errorf("unknown time zone %q", f)
+			}
+			sched.Location = loc
+			haveZone = true
+		default:
+			return nil, fmt.Errorf("unexpected %q in active hours", f)
+		}
+	}
+	if !haveDays {
+		sched.Days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return sched, nil
+}
+
+// ValidateSchedule checks active hours entered by the user and returns
+// them normalized.
+func ValidateSchedule(s string) (string, error) {
+	sched, err := ParseSchedule(s)
+	if err != nil || sched == nil {
+		return "", err
+	}
+	return sched.String(), nil
+}
+
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hour, err1 := strconv.Atoi(h)
+	min, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hour < 0 || min < 0 || min > 59 || hour > 24 || (hour == 24 && min != 0) {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return hour*60 + min, nil
+}
+
+func isDayList(s string) bool {
+	for _, r := range strings.ToLower(s) {
+		if (r < 'a' || r > 'z') && r != ',' && r != '-' {
+			return false
+		}
+	}
+	parts := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return r == ',' || r == '-' })
+	return len(parts) > 0 && dayIndex(parts[0]) >= 0
+}
+
+func dayIndex(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) < 3 {
+		return -1
+	}
+	for i, name := range weekdayNames {
+		if s[:3] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// String formats the schedule in the form ParseSchedule accepts.
+func (s *Schedule) String() string {
+	var parts []string
+	if days := s.dayString(); days != "" {
+		parts = append(parts, days)
+	}
+	if s.Start != 0 || s.End != 24*60 {
+		parts = append(parts, fmt.Sprintf("%02d:%02d-%02d:%02d", s.Start/60, s.Start%60, s.End/60, s.End%60))
+	}
+	if s.Location != time.Local {
+		parts = append(parts, s.Location.String())
+	}
+	if len(parts) == 0 {
+		return "daily"
+	}
+	return strings.Join(parts, " ")
+}
+
+// dayString lists the active days, collapsing runs such as mon-fri. It is
+// empty when every day is active.
+func (s *Schedule) dayString() string {
+	all := true
+	for _, d := range s.Days {
+		all = all && d
+	}
+	if all {
+		return ""
+	}
+
+	// Start from Monday so the usual work week reads mon-fri.
+	order := []int{1, 2, 3, 4, 5, 6, 0}
+	var runs []string
+	for i := 0; i < len(order); i++ {
+		if !s.Days[order[i]] {
+			continue
+		}
+		j := i
+		for j+1 < len(order) && s.Days[order[j+1]] {
+			j++
+		}
+		if j > i {
+			runs = append(runs, weekdayNames[order[i]]+"-"+weekdayNames[order[j]])
+		} else {
+			runs = append(runs, weekdayNames[order[i]])
+		}
+		i = j
+	}
+	return strings.Join(runs, ",")
+}
+
+// window returns the active window that starts on the given day, if the
+// day is active.
+func (s *Schedule) window(day time.Time) (start, end time.Time, ok bool) {
+	if !s.Days[day.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := day.Date()
+	start = time.Date(y, m, d, s.Start/60, s.Start%60, 0, 0, s.Location)
+	endDay := d
+	if s.End <= s.Start {
+		endDay++
+	}
+	end = time.Date(y, m, endDay, s.End/60, s.End%60, 0, 0, s.Location)
+	return start, end, true
+}
+
+// windows calls fn for every active window that overlaps [from, to), in
+// order, until fn returns false.
+func (s *Schedule) windows(from, to time.Time, fn func(start, end time.Time) bool) {
+	y, m, d := from.In(s.Location).Date()
+	// A window from the previous day may run past midnight into from.
+	day := time.Date(y, m, d-1, 0, 0, 0, 0, s.Location)
+	for !day.After(to) {
+		if start, end, ok := s.window(day); ok && end.After(from) && start.Before(to) {
+			if !fn(start, end) {
+				return
+			}
+		}
+		y, m, d = day.Date()
+		day = time.Date(y, m, d+1, 0, 0, 0, 0, s.Location)
+	}
+}
+
+// Active reports whether t falls inside the active hours.
+func (s *Schedule) Active(t time.Time) bool {
+	active := false
+	s.windows(t, t.Add(time.Nanosecond), func(start, end time.Time) bool {
+		active = true
+		return false
+	})
+	return active
+}
+
+// ActiveDuration returns how much of [from, to) falls inside the active
+// hours.
+func (s *Schedule) ActiveDuration(from, to time.Time) time.Duration {
+	var total time.Duration
+	s.windows(from, to, func(start, end time.Time) bool {
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		total += end.Sub(start)
+		return true
+	})
+	return total
+}
+
+// CurrentStart returns when the active window containing t started, or the
+// zero time if t is outside the active hours.
+func (s *Schedule) CurrentStart(t time.Time) time.Time {
+	var begin time.Time
+	s.windows(t, t.Add(time.Nanosecond), func(start, end time.Time) bool {
+		begin = start
+		return false
+	})
+	return begin
+}
+
+// NextStart returns when the next active window after t opens. It is the
+// zero time if no day is active.
+func (s *Schedule) NextStart(t time.Time) time.Time {
+	var next time.Time
+	s.windows(t, t.AddDate(0, 0, 8), func(start, end time.Time) bool {
+		if start.After(t) {
+			next = start
+			return false
+		}
+		return true
+	})
+	return next
+}
+
+// ActiveSchedule returns m's active hours, or nil when it is checked
+// around the clock. Invalid stored hours are ignored.
+func (m *Monitor) ActiveSchedule() *Schedule {
+	sched, err := ParseSchedule(m.ActiveHours)
+	if err != nil {
+		return nil
+	}
+	return sched
+}
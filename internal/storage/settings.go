@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Keys of settings shared between packages.
+const (
+	SettingNotificationsEnabled = "notifications.enabled"
+	SettingSnoozedUntil         = "notifications.snoozed_until"
+	SettingNotifyFirstCheck     = "notifications.first_check"
+	SettingNotifyResumed        = "notifications.monitoring_resumed"
+	SettingNotifyAnomaly        = "notifications.anomaly"
+	SettingAnomalySigma         = "checks.anomaly_sigma"
+	SettingAnomalyBaseline      = "checks.anomaly_baseline"
+	SettingCheckerHeartbeat     = "checker.heartbeat"
+	SettingDefaultUserAgent     = "checks.user_agent"
+	SettingProxy                = "checks.proxy"
+	SettingBaseURL              = "web.base_url"
+	SettingCORSOrigins          = "web.cors_origins"
+	SettingCriticalGroups       = "tray.critical_groups"
+	SettingAutoStartMode        = "autostart.mode"
+	SettingAutoStartKeepAlive   = "autostart.keep_alive"
+	SettingInfluxURL            = "influx.url"
+	SettingInfluxOrg            = "influx.org"
+	SettingInfluxBucket         = "influx.bucket"
+	SettingInfluxToken          = "influx.token"
+	SettingMatrixHomeserver     = "matrix.homeserver"
+	SettingMatrixToken          = "matrix.token"
+	SettingMatrixRoom           = "matrix.room"
+	SettingWebhookURL           = "webhook.url"
+	SettingWebhookSecret        = "webhook.secret"
+	SettingOpsGenieAPIKey       = "opsgenie.api_key"
+	SettingOpsGenieRegion       = "opsgenie.region"
+	SettingTeamsWebhooks        = "teams.webhooks"
+	SettingSignalCLI            = "signal.cli_path"
+	SettingSignalAccount        = "signal.account"
+	SettingSignalRecipients     = "signal.recipients"
+	SettingSignalRPCURL         = "signal.rpc_url"
+	SettingNotifyURLs           = "notify.urls"
+	SettingInternalMetrics      = "metrics.internal"
+)
+
+// GetSetting returns the value stored under key and whether it exists.
+func (d *Database) GetSetting(key string) (string, bool, error) {
+	var s Setting
+	err := d.db.Where("key = ?", key).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return s.Value, true, nil
+}
+
+func (d *Database) SetSetting(key, value string) error {
+	return d.db.Save(&Setting{Key: key, Value: value}).Error
+}
+
+func (d *Database) DeleteSetting(key string) error {
+	return d.db.Where("key = ?", key).Delete(&Setting{}).Error
+}
+
+// GetStringSetting returns the value stored under key, or def if it is
+// missing or unreadable.
+func (d *Database) GetStringSetting(key, def string) string {
+	v, ok, err := d.GetSetting(key)
+	if err != nil || !ok {
+		return def
+	}
+	return v
+}
+
+// GetBoolSetting returns the bool stored under key, or def if it is missing
+// or unreadable.
+func (d *Database) GetBoolSetting(key string, def bool) bool {
+	v, ok, err := d.GetSetting(key)
+	if err != nil || !ok {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+func (d *Database) SetBoolSetting(key string, value bool) error {
+	return d.SetSetting(key, strconv.FormatBool(value))
+}
+
+// GetIntSetting returns the int stored under key, or def if it is missing
+// or unreadable.
+func (d *Database) GetIntSetting(key string, def int) int {
+	v, ok, err := d.GetSetting(key)
+	if err != nil || !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+func (d *Database) SetIntSetting(key string, value int) error {
+	return d.SetSetting(key, strconv.Itoa(value))
+}
+
+// GetDurationSetting returns the duration stored under key, or def if it is
+// missing or unreadable.
+func (d *Database) GetDurationSetting(key string, def time.Duration) time.Duration {
+	v, ok, err := d.GetSetting(key)
+	if err != nil || !ok {
+		return def
+	}
+	dur, err := time.ParseDuration(v)
+	if err != nil {
+		return def
+	}
+	return dur
+}
+
+func (d *Database) SetDurationSetting(key string, value time.Duration) error {
+	return d.SetSetting(key, value.String())
+}
+
+// GetTimeSetting returns the time stored under key, or the zero time if it
+// is missing or unreadable.
+func (d *Database) GetTimeSetting(key string) time.Time {
+	v, ok, err := d.GetSetting(key)
+	if err != nil || !ok {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// SetTimeSetting stores t under key. A zero time deletes the setting.
+func (d *Database) SetTimeSetting(key string, t time.Time) error {
+	if t.IsZero() {
+		return d.DeleteSetting(key)
+	}
+	return d.SetSetting(key, t.Format(time.RFC3339))
+}
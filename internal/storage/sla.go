@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// SLAReport compares a monitor's time-weighted uptime over one reporting
+// period against its SLA target.
+type SLAReport struct {
+	Target      float64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Uptime      float64
+	// AllowedDowntime is the error budget for the whole period; Downtime is
+	// what has been used so far.
+	AllowedDowntime time.Duration
+	Downtime        time.Duration
+	Unknown         time.Duration
+}
+
+// BudgetRemaining returns the downtime still allowed this period. It is
+// negative once the budget is exhausted.
+func (r *SLAReport) BudgetRemaining() time.Duration {
+	return r.AllowedDowntime - r.Downtime
+}
+
+func (r *SLAReport) Exhausted() bool {
+	return r.BudgetRemaining() < 0
+}
+
+// Met reports whether the uptime so far meets the target.
+func (r *SLAReport) Met() bool {
+	return r.Uptime >= r.Target
+}
+
+// BudgetSummary describes the remaining error budget, e.g. "1h 2m left"
+// or "exhausted by 14m".
+func (r *SLAReport) BudgetSummary() string {
+	left := r.BudgetRemaining()
+	if left < 0 {
+		return "exhausted by " + formatMinutes(-left)
+	}
+	return formatMinutes(left) + " left"
+}
+
+func formatMinutes(d time.Duration) string {
+	m := int(d.Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", m/60, m%60)
+}
+
+// SLAPeriod returns the monthly reporting period containing t, shifted by
+// offset months (-1 for the previous month).
+func SLAPeriod(t time.Time, offset int) (start, end time.Time) {
+	start = time.Date(t.Year(), t.Month()+time.Month(offset), 1, 0, 0, 0, 0, t.Location())
+	end = start.AddDate(0, 1, 0)
+	return start, end
+}
+
+// GetSLAReport returns the SLA report of a monitor for the period
+// [start, end). A period that hasn't ended yet is measured up to now. It
+// returns nil for monitors without an SLA target.
+func (d *Database) GetSLAReport(m *Monitor, start, end time.Time) (*SLAReport, error) {
+	if m.SLATarget <= 0 {
+		return nil, nil
+	}
+
+	until := end
+	if now := time.Now(); now.Before(until) {
+		until = now
+	}
+
+	uptime, err := d.GetUptimeBetween(m.ID, start, until)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only active hours count towards the SLA.
+	period := end.Sub(start)
+	if sched := m.ActiveSchedule(); sched != nil {
+		period = sched.ActiveDuration(start, end)
+	}
+
+	return &SLAReport{
+		Target:          m.SLATarget,
+		PeriodStart:     start,
+		PeriodEnd:       end,
+		Uptime:          uptime.Percent(),
+		AllowedDowntime: time.Duration(float64(period) * (100 - m.SLATarget) / 100),
+		Downtime:        uptime.Down,
+		Unknown:         uptime.Unknown,
+	}, nil
+}
+
+// GetCurrentSLAReport returns the SLA report for the current month.
+func (d *Database) GetCurrentSLAReport(m *Monitor) (*SLAReport, error) {
+	start, end := SLAPeriod(time.Now(), 0)
+	return d.GetSLAReport(m, start, end)
+}
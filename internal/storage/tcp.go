@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ValidateTCPTarget checks the host and comma-separated ports a TCP monitor
+// connects to, like example.com:80,443, and returns it normalized with
+// duplicate ports removed.
+func ValidateTCPTarget(target string) (string, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		return "", fmt.Errorf("invalid tcp target %q: use host:port without a scheme, e.g. example.com:80,443", target)
+	}
+	host, portList, err := net.SplitHostPort(target)
+	if err != nil || host == "" {
+		return "", fmt.Errorf("invalid tcp target %q: use host:port, e.g. example.com:80,443", target)
+	}
+	var ports []string
+	seen := make(map[int]bool)
+	for _, p := range strings.Split(portList, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("invalid port %q in tcp target %q", strings.TrimSpace(p), target)
+		}
+		if !seen[n] {
+			seen[n] = true
+			ports = append(ports, strconv.Itoa(n))
+		}
+	}
+	return net.JoinHostPort(host, strings.Join(ports, ",")), nil
+}
+
+// IsTCP reports whether m is a TCP monitor.
+func (m *Monitor) IsTCP() bool {
+	return m.Type == MonitorTypeTCP
+}
+
+// TCPPorts splits a TCP monitor's target into its host and ports.
+func (m *Monitor) TCPPorts() (string, []string) {
+	host, portList, err := net.SplitHostPort(m.URL)
+	if err != nil {
+		return m.URL, nil
+	}
+	return host, strings.Split(portList, ",")
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+var dbWriteDuration = telemetry.NewHistogram("statping_db_write_duration_seconds",
+	"Time taken by database inserts, updates, deletes and raw statements, including waiting for the lock.",
+	0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 1, 5)
+
+const writeStartKey = "statping:write_start"
+
+// instrumentWrites times every write through GORM's callbacks.
+func instrumentWrites(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		if telemetry.Enabled() {
+			tx.InstanceSet(writeStartKey, time.Now())
+		}
+	}
+	after := func(tx *gorm.DB) {
+		if start, ok := tx.InstanceGet(writeStartKey); ok {
+			dbWriteDuration.Since(start.(time.Time))
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("statping:create_start", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("statping:create_end", after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("statping:update_start", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("statping:update_end", after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("statping:delete_start", before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("statping:delete_end", after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("statping:raw_start", before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("statping:raw_end", after)
+}
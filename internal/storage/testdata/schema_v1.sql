@@ -0,0 +1,41 @@
+-- A database at schema version 1, the baseline, as created by the release
+-- that introduced versioning, with a few rows in each table. It is
+-- upgraded by TestMigrateFromBaseline.
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE `schema_version` (`version` integer PRIMARY KEY AUTOINCREMENT,`name` text,`applied_at` datetime);
+INSERT INTO schema_version VALUES(1,'baseline','2026-01-02 03:04:05+00:00');
+CREATE TABLE `monitors` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`name` text NOT NULL,`type` text DEFAULT "http",`url` text NOT NULL,`enabled` numeric DEFAULT true,`public` numeric DEFAULT true,`check_interval` integer DEFAULT 60,`expected_codes` text,`keywords` text,`tags` text,`position` integer DEFAULT 0,`sla_target` real,`timeout` integer DEFAULT 10,`max_failures` integer,`channels` text,`user_agent` text,`check_header` numeric,`disable_keep_alive` numeric,`http_version` text,`skip_tls_verify` numeric,`proxy` text,`watch_content` numeric,`ignore_patterns` text,`heartbeat_token` text,`grace_period` integer,`last_ping_at` datetime,`current_status` text DEFAULT "unknown",`consecutive_fails` integer,`last_check_at` datetime,`backoff` numeric,`active_hours` text,`latency_threshold` integer,`latency_window` integer,`latency_agg` text,`next_check_at` datetime,`paused_until` datetime);
+INSERT INTO monitors VALUES(1,'2026-01-02 03:04:05+00:00','2026-01-02 03:04:05+00:00','Example','http','HTTPS://Example.com:443/status/',1,1,30,'200,204',NULL,'prod,web',0,NULL,15,3,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,'up',0,'2026-01-03 00:00:00+00:00',NULL,NULL,2000,900,'p95',NULL,NULL);
+INSERT INTO monitors VALUES(2,'2026-01-02 03:04:05+00:00','2026-01-02 03:04:05+00:00','Nightly backup','heartbeat','heartbeat://abc123',1,0,86400,'',NULL,'',0,NULL,10,0,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,'abc123',600,NULL,'down',2,NULL,NULL,NULL,0,0,'',NULL,NULL);
+INSERT INTO monitors VALUES(3,'2026-01-02 03:04:05+00:00','2026-01-02 03:04:05+00:00','Postgres','tcp','db.internal:5432',0,1,60,'',NULL,'db',0,NULL,5,0,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,NULL,'unknown',0,NULL,NULL,NULL,0,0,'',NULL,NULL);
+CREATE TABLE `check_results` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`monitor_id` integer NOT NULL,`status_code` integer,`response_time` integer,`success` numeric,`error_message` text,`content_hash` text,`proxy_error` numeric DEFAULT false,`resolved_ip` text,`tls_version` text,`tls_cipher` text,`dns_time` integer,`connect_time` integer,`tls_time` integer,`first_byte_time` integer,`response_snippet` text,`response_headers` text,CONSTRAINT `fk_monitors_check_results` FOREIGN KEY (`monitor_id`) REFERENCES `monitors`(`id`));
+INSERT INTO check_results VALUES(1,'2026-01-02 23:59:00+00:00',1,200,120,1,'',NULL,0,'93.184.216.34','TLS 1.3',NULL,5,20,NULL,NULL,NULL,NULL);
+INSERT INTO check_results VALUES(2,'2026-01-03 00:00:00+00:00',1,503,340,0,'unexpected status 503',NULL,0,'93.184.216.34','TLS 1.3',NULL,4,19,NULL,NULL,NULL,NULL);
+INSERT INTO check_results VALUES(3,'2026-01-03 00:00:00+00:00',3,0,0,0,'connection refused',NULL,0,'',NULL,NULL,0,0,NULL,NULL,NULL,NULL);
+CREATE TABLE `incidents` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`monitor_id` integer NOT NULL,`type` text DEFAULT "availability",`started_at` datetime,`resolved_at` datetime,`resolution` text,`error_message` text,`notified` numeric DEFAULT false,`recovery_notified` numeric DEFAULT false,CONSTRAINT `fk_monitors_incidents` FOREIGN KEY (`monitor_id`) REFERENCES `monitors`(`id`));
+INSERT INTO incidents VALUES(1,'2026-01-03 00:00:00+00:00','2026-01-03 00:05:00+00:00',1,'availability','2026-01-03 00:00:00+00:00','2026-01-03 00:05:00+00:00','','unexpected status 503',1,1);
+INSERT INTO incidents VALUES(2,'2026-01-03 01:00:00+00:00','2026-01-03 01:00:00+00:00',2,'availability','2026-01-03 01:00:00+00:00',NULL,'','no ping for 10m',1,0);
+CREATE TABLE `settings` (`key` text,`value` text,`updated_at` datetime,PRIMARY KEY (`key`));
+INSERT INTO settings VALUES('notifications.enabled','false','2026-01-02 03:04:05+00:00');
+CREATE TABLE `content_snapshots` (`monitor_id` integer PRIMARY KEY AUTOINCREMENT,`hash` text,`body` text,`updated_at` datetime);
+CREATE TABLE `content_changes` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`monitor_id` integer NOT NULL,`old_hash` text,`new_hash` text,`bytes_changed` integer,`summary` text);
+CREATE TABLE `monitoring_gaps` (`id` integer PRIMARY KEY AUTOINCREMENT,`monitor_id` integer NOT NULL,`started_at` datetime,`ended_at` datetime);
+INSERT INTO monitoring_gaps VALUES(1,1,'2026-01-02 12:00:00+00:00','2026-01-02 15:00:00+00:00');
+CREATE TABLE `audit_entries` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`monitor_id` integer NOT NULL,`action` text,`source` text,`detail` text);
+INSERT INTO audit_entries VALUES(1,'2026-01-02 03:04:05+00:00',1,'created','cli','');
+INSERT INTO sqlite_sequence VALUES('schema_version',1);
+INSERT INTO sqlite_sequence VALUES('monitors',3);
+INSERT INTO sqlite_sequence VALUES('check_results',3);
+INSERT INTO sqlite_sequence VALUES('incidents',2);
+INSERT INTO sqlite_sequence VALUES('audit_entries',1);
+INSERT INTO sqlite_sequence VALUES('monitoring_gaps',1);
+CREATE INDEX `idx_monitors_heartbeat_token` ON `monitors`(`heartbeat_token`);
+CREATE INDEX `idx_monitors_position` ON `monitors`(`position`);
+CREATE UNIQUE INDEX `idx_monitors_url` ON `monitors`(`url`);
+CREATE INDEX `idx_check_results_monitor_id` ON `check_results`(`monitor_id`);
+CREATE INDEX `idx_incidents_monitor_id` ON `incidents`(`monitor_id`);
+CREATE INDEX `idx_content_changes_monitor_id` ON `content_changes`(`monitor_id`);
+CREATE INDEX `idx_monitoring_gaps_monitor_id` ON `monitoring_gaps`(`monitor_id`);
+CREATE INDEX `idx_audit_entries_monitor_id` ON `audit_entries`(`monitor_id`);
+COMMIT;
@@ -0,0 +1,125 @@
+package storage
+
+import "time"
+
+// StatusSpan is a stretch of time a monitor spent in one status.
+type StatusSpan struct {
+	Status string    `json:"status"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+// Duration is how long the span lasted.
+func (s StatusSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+func (d *Database) CreateStatusChange(c *StatusChange) error {
+	return d.db.Create(c).Error
+}
+
+// GetStatusChanges returns a monitor's status changes since the given
+// time, oldest first.
+func (d *Database) GetStatusChanges(monitorID uint, since time.Time) ([]StatusChange, error) {
+	var changes []StatusChange
+	err := d.db.Where("monitor_id = ? AND at >= ?", monitorID, since).
+		Order("at asc, id asc").
+		Find(&changes).Error
+	return changes, err
+}
+
+// GetStatusTimeline returns the spans a monitor spent in each status from
+// since, or from when it was created if that is later, until now, oldest
+// first. Without a change before the window the monitor is taken to have
+// been in the status its first change left, or, with no changes at all,
+// the status it has now.
+func (d *Database) GetStatusTimeline(monitorID uint, since time.Time) ([]StatusSpan, error) {
+	m, err := d.GetMonitor(monitorID)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Now()
+	if m.CreatedAt.After(since) {
+		since = m.CreatedAt
+	}
+
+	var prev []StatusChange
+	err = d.db.Where("monitor_id = ? AND at < ?", monitorID, since).
+		Order("at desc, id desc").
+		Limit(1).
+		Find(&prev).Error
+	if err != nil {
+		return nil, err
+	}
+	changes, err := d.GetStatusChanges(monitorID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	status := m.DisplayStatus()
+	switch {
+	case len(prev) > 0:
+		status = prev[0].ToStatus
+	case len(changes) > 0:
+		status = changes[0].FromStatus
+	}
+
+	var spans []StatusSpan
+	start := since
+	for _, c := range changes {
+		if c.ToStatus == status {
+			continue
+		}
+		if c.At.After(start) {
+			spans = append(spans, StatusSpan{Status: status, Start: start, End: c.At})
+		}
+		status, start = c.ToStatus, c.At
+	}
+	if until.After(start) {
+		spans = append(spans, StatusSpan{Status: status, Start: start, End: until})
+	}
+	return spans, nil
+}
+
+// TimelineBuckets divides [since, until) into n equal buckets and returns
+// the status each should be drawn in: "down" if the monitor was down at
+// any point in it, so short outages stay visible, otherwise the status it
+// spent the most time in. Buckets no span covers are "".
+func TimelineBuckets(spans []StatusSpan, since, until time.Time, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	buckets := make([]string, n)
+	if !until.After(since) {
+		return buckets
+	}
+	width := until.Sub(since) / time.Duration(n)
+	for i := range buckets {
+		from := since.Add(time.Duration(i) * width)
+		to := from.Add(width)
+		spent := make(map[string]time.Duration)
+		for _, s := range spans {
+			start, end := s.Start, s.End
+			if start.Before(from) {
+				start = from
+			}
+			if end.After(to) {
+				end = to
+			}
+			if end.After(start) {
+				spent[s.Status] += end.Sub(start)
+			}
+		}
+		if spent["down"] > 0 {
+			buckets[i] = "down"
+			continue
+		}
+		var longest time.Duration
+		for status, d := range spent {
+			if d > longest || (d == longest && status < buckets[i]) {
+				buckets[i], longest = status, d
+			}
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatusTimeline(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, d *Database) {
+		now := time.Now().Truncate(time.Second)
+		m := mustCreateMonitor(t, d, "https://example.com", func(m *Monitor) {
+			m.CreatedAt = now.Add(-2 * time.Hour)
+			m.CurrentStatus = "up"
+		})
+		for _, c := range []StatusChange{
+			{At: now.Add(-90 * time.Minute), FromStatus: "unknown", ToStatus: "up"},
+			{At: now.Add(-30 * time.Minute), FromStatus: "up", ToStatus: "down"},
+			{At: now.Add(-10 * time.Minute), FromStatus: "down", ToStatus: "up"},
+		} {
+			c.MonitorID = m.ID
+			if err := d.CreateStatusChange(&c); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		type span struct {
+			status     string
+			start, end time.Duration
+		}
+		check := func(since time.Time, want []span) {
+			t.Helper()
+			spans, err := d.GetStatusTimeline(m.ID, since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(spans) != len(want) {
+				t.Fatalf("timeline since %s = %+v, want %d spans", now.Sub(since), spans, len(want))
+			}
+			for i, s := range spans {
+				w := want[i]
+				// The last span ends at the time of the query.
+				end := now.Add(w.end)
+				if i == len(spans)-1 {
+					end = s.End
+				}
+				if s.Status != w.status || !s.Start.Equal(now.Add(w.start)) || !s.End.Equal(end) {
+					t.Errorf("span %d = %s %s to %s, want %s %s to %s", i,
+						s.Status, s.Start.Sub(now), s.End.Sub(now), w.status, w.start, w.end)
+				}
+			}
+		}
+
+		// The window starts up, carried over from the change before it.
+		check(now.Add(-time.Hour), []span{
+			{"up", -time.Hour, -30 * time.Minute},
+			{"down", -30 * time.Minute, -10 * time.Minute},
+			{"up", -10 * time.Minute, 0},
+		})
+		// A window reaching back past its creation starts when it was created.
+		check(now.Add(-3*time.Hour), []span{
+			{"unknown", -2 * time.Hour, -90 * time.Minute},
+			{"up", -90 * time.Minute, -30 * time.Minute},
+			{"down", -30 * time.Minute, -10 * time.Minute},
+			{"up", -10 * time.Minute, 0},
+		})
+		// Without changes in or before the window, it spent it all as it is.
+		check(now.Add(-5*time.Minute), []span{{"up", -5 * time.Minute, 0}})
+	})
+}
+
+func TestTimelineBuckets(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return since.Add(time.Duration(minutes) * time.Minute) }
+	spans := []StatusSpan{
+		{Status: "up", Start: at(10), End: at(31)},
+		{Status: "down", Start: at(31), End: at(32)},
+		{Status: "up", Start: at(32), End: at(45)},
+		{Status: "paused", Start: at(45), End: at(60)},
+	}
+
+	got := TimelineBuckets(spans, since, at(60), 6)
+	// A minute's outage colors its whole bucket, and a bucket split evenly
+	// takes the first status by name.
+	want := []string{"", "up", "up", "down", "paused", "paused"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buckets = %q, want %q", got, want)
+	}
+	if got := TimelineBuckets(spans, at(60), at(60), 4); !reflect.DeepEqual(got, []string{"", "", "", ""}) {
+		t.Errorf("buckets of an empty window = %q", got)
+	}
+}
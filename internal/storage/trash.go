@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashedMonitors returns the monitors DeleteMonitor moved to the trash,
+// most recently deleted first.
+func (d *Database) TrashedMonitors() ([]Monitor, error) {
+	var monitors []Monitor
+	err := d.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&monitors).Error
+	return monitors, err
+}
+
+// RestoreMonitor takes a monitor out of the trash.
+func (d *Database) RestoreMonitor(id uint) (*Monitor, error) {
+	var m Monitor
+	err := d.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&m).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMonitorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := d.db.Unscoped().Model(&m).Update("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+	m.DeletedAt = gorm.DeletedAt{}
+	return &m, nil
+}
+
+// EmptyTrash permanently deletes the monitors that were moved to the trash
+// before cutoff, together with their history, pause log and notification
+// log entries. A zero cutoff empties the whole trash.
+func (d *Database) EmptyTrash(cutoff time.Time) ([]DeleteResult, error) {
+	q := d.db.Unscoped().Where("deleted_at IS NOT NULL")
+	if !cutoff.IsZero() {
+		q = q.Where("deleted_at < ?", cutoff)
+	}
+	var monitors []Monitor
+	if err := q.Order("deleted_at").Find(&monitors).Error; err != nil {
+		return nil, err
+	}
+
+	var results []DeleteResult
+	for _, m := range monitors {
+		result := DeleteResult{Monitor: m}
+		err := d.db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Where("monitor_id = ?", m.ID).Delete(&CheckResult{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.CheckResults = res.RowsAffected
+
+			res = tx.Where("monitor_id = ?", m.ID).Delete(&Incident{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Incidents = res.RowsAffected
+
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&ContentChange{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&ContentSnapshot{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&MonitoringGap{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&StatusChange{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&AuditEntry{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("monitor_id = ?", m.ID).Delete(&NotificationDelivery{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Delete(&Monitor{}, m.ID).Error
+		})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ankityadav/statping/internal/config"
+	"gorm.io/gorm"
+)
+
+// Uptime is a time-weighted availability measurement. Each check is taken
+// to represent the monitor's state until the next check, but for at most
+// twice the check interval, or twice the longest backed-off interval for a
+// failed check of a monitor with backoff. Time not covered by any check,
+// e.g. while the daemon wasn't running, is counted as Unknown rather than
+// up, as is any time inside a recorded MonitoringGap. Checks that failed
+// because of the proxy or the OAuth2 token endpoint say nothing about the
+// target and are skipped. Time outside a monitor's active hours is counted
+// separately as OutOfSchedule.
+type Uptime struct {
+	Up            time.Duration
+	Down          time.Duration
+	Unknown       time.Duration
+	OutOfSchedule time.Duration
+	Checks        int64
+	Successful    int64
+}
+
+// Percent returns the share of known time the monitor was up.
+func (u Uptime) Percent() float64 {
+	known := u.Up + u.Down
+	if known == 0 {
+		return 0
+	}
+	return float64(u.Up) / float64(known) * 100
+}
+
+// CheckRatio returns the plain successful/total check ratio, kept for
+// comparison with the time-weighted Percent.
+func (u Uptime) CheckRatio() float64 {
+	if u.Checks == 0 {
+		return 0
+	}
+	return float64(u.Successful) / float64(u.Checks) * 100
+}
+
+type uptimeTracker struct {
+	since       time.Time
+	maxSpan     time.Duration
+	maxDownSpan time.Duration
+	started     bool
+	last        time.Time
+	lastUp      bool
+	gaps        []MonitoringGap
+	schedule    *Schedule
+	result      Uptime
+}
+
+func newUptimeTracker(since time.Time, m *Monitor) *uptimeTracker {
+	interval := time.Duration(m.CheckInterval) * time.Second
+	if interval <= 0 {
+		interval = 60 * time.Second
+	}
+	t := &uptimeTracker{
+		since:       since,
+		maxSpan:     2 * interval,
+		maxDownSpan: 2 * interval,
+		schedule:    m.ActiveSchedule(),
+	}
+	if m.Backoff {
+		// Failing checks are spaced out, so each one stands for longer.
+		t.maxDownSpan = 2 * interval * config.MaxBackoffFactor
+	}
+	return t
+}
+
+// add records a check. Checks must be added oldest first; a check from
+// before since only seeds the state at the start of the window.
+func (t *uptimeTracker) add(at time.Time, success bool) {
+	if !at.Before(t.since) {
+		t.result.Checks++
+		if success {
+			t.result.Successful++
+		}
+		t.cover(at)
+	}
+	t.started = true
+	t.last = at
+	t.lastUp = success
+}
+
+// cover attributes the time between the previous check and until.
+func (t *uptimeTracker) cover(until time.Time) {
+	start := t.since
+	if t.started && t.last.After(start) {
+		start = t.last
+	}
+	if !until.After(start) {
+		return
+	}
+
+	coveredEnd := start
+	if t.started {
+		span := t.maxSpan
+		if !t.lastUp {
+			span = t.maxDownSpan
+		}
+		coveredEnd = t.last.Add(span)
+		if coveredEnd.After(until) {
+			coveredEnd = until
+		}
+	}
+
+	var known time.Duration
+	if coveredEnd.After(start) {
+		known = t.active(start, coveredEnd) - t.gapOverlap(start, coveredEnd)
+		if t.lastUp {
+			t.result.Up += known
+		} else {
+			t.result.Down += known
+		}
+	}
+	off := until.Sub(start) - t.active(start, until)
+	t.result.OutOfSchedule += off
+	t.result.Unknown += until.Sub(start) - off - known
+}
+
+// active returns how much of [from, to) is inside the monitor's active
+// hours.
+func (t *uptimeTracker) active(from, to time.Time) time.Duration {
+	if t.schedule == nil {
+		return to.Sub(from)
+	}
+	return t.schedule.ActiveDuration(from, to)
+}
+
+// gapOverlap returns how much of the active time in [from, to) falls inside
+// recorded gaps.
+func (t *uptimeTracker) gapOverlap(from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, g := range t.gaps {
+		s, e := g.StartedAt, g.EndedAt
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		if e.After(s) {
+			total += t.active(s, e)
+		}
+	}
+	return total
+}
+
+func (t *uptimeTracker) finish(until time.Time) Uptime {
+	t.cover(until)
+	return t.result
+}
+
+// ComputeUptime calculates the time-weighted uptime of m between since and
+// until from already loaded check results in any order.
+func ComputeUptime(results []CheckResult, m *Monitor, since, until time.Time) Uptime {
+	sorted := make([]CheckResult, len(results))
+	copy(sorted, results)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	t := newUptimeTracker(since, m)
+	for _, r := range sorted {
+		if r.CreatedAt.After(until) {
+			break
+		}
+		if r.ProxyError || r.AuthError {
+			continue
+		}
+		t.add(r.CreatedAt, r.Success)
+	}
+	return t.finish(until)
+}
+
+// GetUptime computes the time-weighted uptime of a monitor from since until
+// now.
+func (d *Database) GetUptime(monitorID uint, since time.Time) (Uptime, error) {
+	return d.GetUptimeBetween(monitorID, since, time.Now())
+}
+
+// GetUptimeBetween computes the time-weighted uptime of a monitor over
+// [since, until), streaming check results in batches.
+func (d *Database) GetUptimeBetween(monitorID uint, since, until time.Time) (Uptime, error) {
+	monitor, err := d.GetMonitor(monitorID)
+	if err != nil {
+		return Uptime{}, err
+	}
+	t := newUptimeTracker(since, monitor)
+	if t.gaps, err = d.GetMonitoringGaps(monitorID, since, until); err != nil {
+		return Uptime{}, err
+	}
+
+	// The last check before the window tells us the state at its start.
+	var prev []CheckResult
+	err = d.db.Select("id, created_at, success").
+		Where("monitor_id = ? AND created_at < ? AND proxy_error = ? AND auth_error = ?", monitorID, since, false, false).
+		Order("created_at desc").
+		Limit(1).
+		Find(&prev).Error
+	if err != nil {
+		return Uptime{}, err
+	}
+	if len(prev) > 0 {
+		t.add(prev[0].CreatedAt, prev[0].Success)
+	}
+
+	var batch []CheckResult
+	err = d.db.Select("id, created_at, success").
+		Where("monitor_id = ? AND created_at >= ? AND created_at < ? AND proxy_error = ? AND auth_error = ?", monitorID, since, until, false, false).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+			for _, r := range batch {
+				t.add(r.CreatedAt, r.Success)
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return Uptime{}, err
+	}
+	return t.finish(until), nil
+}
+
+// GetUptimeForMonitors computes the time-weighted uptime of each of ids from
+// since until now with a fixed number of queries, for views that show every
+// monitor at once. Unknown IDs are left out of the result.
+func (d *Database) GetUptimeForMonitors(ids []uint, since time.Time) (map[uint]Uptime, error) {
+	if len(ids) == 0 {
+		return map[uint]Uptime{}, nil
+	}
+	until := time.Now()
+
+	var monitors []Monitor
+	if err := d.db.Where("id IN ?", ids).Find(&monitors).Error; err != nil {
+		return nil, err
+	}
+	trackers := make(map[uint]*uptimeTracker, len(monitors))
+	for i := range monitors {
+		trackers[monitors[i].ID] = newUptimeTracker(since, &monitors[i])
+	}
+
+	var gaps []MonitoringGap
+	err := d.db.Where("monitor_id IN ? AND ended_at > ? AND started_at < ?", ids, since, until).
+		Order("started_at asc").
+		Find(&gaps).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range gaps {
+		if t, ok := trackers[g.MonitorID]; ok {
+			t.gaps = append(t.gaps, g)
+		}
+	}
+
+	// The last check before the window, per monitor, seeds its state.
+	var prev []CheckResult
+	err = d.db.Raw(`SELECT c.monitor_id, c.created_at, c.success FROM check_results c
+		JOIN (SELECT monitor_id, MAX(created_at) AS created_at FROM check_results
+			WHERE monitor_id IN ? AND created_at < ? AND proxy_error = ? AND auth_error = ?
+			GROUP BY monitor_id) p
+		ON c.monitor_id = p.monitor_id AND c.created_at = p.created_at
+		WHERE c.proxy_error = ? AND c.auth_error = ?`, ids, since, false, false, false, false).
+		Scan(&prev).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range prev {
+		if t, ok := trackers[r.MonitorID]; ok && !t.started {
+			t.add(r.CreatedAt, r.Success)
+		}
+	}
+
+	var batch []CheckResult
+	err = d.db.Select("id, monitor_id, created_at, success").
+		Where("monitor_id IN ? AND created_at >= ? AND created_at < ? AND proxy_error = ? AND auth_error = ?", ids, since, until, false, false).
+		FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, n int) error {
+			for _, r := range batch {
+				if t, ok := trackers[r.MonitorID]; ok {
+					t.add(r.CreatedAt, r.Success)
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	uptimes := make(map[uint]Uptime, len(trackers))
+	for id, t := range trackers {
+		uptimes[id] = t.finish(until)
+	}
+	return uptimes, nil
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"net/url"
+	"strings"
+)
+
+// NormalizeURL returns the form of a monitor URL used to find duplicates:
+// scheme and host lower-cased, the scheme's default port dropped, an empty
+// path written as "/" and any fragment removed. Other paths keep their
+// trailing slash, since servers may answer /docs and /docs/ differently.
+// Monitors keep the URL they were added with for display and checking.
+func NormalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return raw
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
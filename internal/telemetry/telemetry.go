@@ -0,0 +1,232 @@
+// Package telemetry measures statping itself: how long checks take, how
+// many were skipped, how many notifications are in flight and how long
+// database writes take. Nothing is recorded until Enable is called, so
+// instrumented code costs an atomic load while it is off.
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	enabled atomic.Bool
+
+	registryMu sync.Mutex
+	registry   []metric
+)
+
+// Enable starts recording.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled reports whether metrics are being recorded.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+	snapshot() Sample
+}
+
+func register(m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, m)
+}
+
+func metrics() []metric {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	out := append([]metric(nil), registry...)
+	sort.Slice(out, func(i, j int) bool { return out[i].name() < out[j].name() })
+	return out
+}
+
+// Counter only goes up.
+type Counter struct {
+	n, help string
+	v       atomic.Uint64
+}
+
+// NewCounter registers a counter.
+func NewCounter(name, help string) *Counter {
+	c := &Counter{n: name, help: help}
+	register(c)
+	return c
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(n uint64) {
+	if enabled.Load() {
+		c.v.Add(n)
+	}
+}
+
+func (c *Counter) name() string { return c.n }
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", c.n, c.help, c.n, c.n, c.v.Load())
+}
+
+func (c *Counter) snapshot() Sample {
+	return Sample{Name: c.n, Help: c.help, Kind: "counter", Value: float64(c.v.Load())}
+}
+
+// Gauge goes up and down.
+type Gauge struct {
+	n, help string
+	v       atomic.Int64
+}
+
+// NewGauge registers a gauge.
+func NewGauge(name, help string) *Gauge {
+	g := &Gauge{n: name, help: help}
+	register(g)
+	return g
+}
+
+func (g *Gauge) Add(d int64) {
+	if enabled.Load() {
+		g.v.Add(d)
+	}
+}
+
+func (g *Gauge) Set(v int64) {
+	if enabled.Load() {
+		g.v.Store(v)
+	}
+}
+
+func (g *Gauge) name() string { return g.n }
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", g.n, g.help, g.n, g.n, g.v.Load())
+}
+
+func (g *Gauge) snapshot() Sample {
+	return Sample{Name: g.n, Help: g.help, Kind: "gauge", Value: float64(g.v.Load())}
+}
+
+// Histogram counts durations into buckets, in seconds.
+type Histogram struct {
+	n, help string
+	bounds  []float64
+
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with the given upper bounds in
+// seconds, in increasing order.
+func NewHistogram(name, help string, bounds ...float64) *Histogram {
+	h := &Histogram{n: name, help: help, bounds: bounds, counts: make([]uint64, len(bounds))}
+	register(h)
+	return h
+}
+
+// Observe records d.
+func (h *Histogram) Observe(d time.Duration) {
+	if !enabled.Load() {
+		return
+	}
+	s := d.Seconds()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, b := range h.bounds {
+		if s <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += s
+}
+
+// Since observes the time since start. It is meant for defer.
+func (h *Histogram) Since(start time.Time) {
+	h.Observe(time.Since(start))
+}
+
+func (h *Histogram) name() string { return h.n }
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.n, h.help, h.n)
+	var cumulative uint64
+	for i, b := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.n, formatFloat(b), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.n, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n%s_count %d\n", h.n, formatFloat(h.sum), h.n, h.count)
+}
+
+func (h *Histogram) snapshot() Sample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return Sample{Name: h.n, Help: h.help, Kind: "histogram", Value: h.sum, Count: h.count}
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Sample is a metric's current value. For a histogram Value is the sum of
+// the observations and Count their number.
+type Sample struct {
+	Name  string  `json:"name"`
+	Help  string  `json:"help"`
+	Kind  string  `json:"kind"`
+	Value float64 `json:"value"`
+	Count uint64  `json:"count,omitempty"`
+}
+
+// String formats s for people, with a histogram's mean.
+func (s Sample) String() string {
+	if s.Kind != "histogram" {
+		return s.Name + " " + formatFloat(s.Value)
+	}
+	if s.Count == 0 {
+		return s.Name + " no observations"
+	}
+	mean := time.Duration(s.Value / float64(s.Count) * float64(time.Second))
+	return fmt.Sprintf("%s %d observations, mean %s", s.Name, s.Count, mean.Round(time.Microsecond))
+}
+
+// Snapshot returns every metric's current value, sorted by name.
+func Snapshot() []Sample {
+	var out []Sample
+	for _, m := range metrics() {
+		out = append(out, m.snapshot())
+	}
+	return out
+}
+
+// WritePrometheus writes every metric in the Prometheus text format.
+func WritePrometheus(w io.Writer) {
+	var b strings.Builder
+	for _, m := range metrics() {
+		m.write(&b)
+	}
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,48 @@
+// Package textutil holds string helpers shared by the terminal and tray
+// user interfaces.
+package textutil
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+)
+
+const ellipsis = "…"
+
+// Truncate shortens s to at most maxWidth terminal cells, ending it with an
+// ellipsis when something was cut. It works on grapheme clusters and their
+// display width, so multi-byte and double-width characters, flags and
+// joined emoji are never split.
+func Truncate(s string, maxWidth int) string {
+	if lipgloss.Width(s) <= maxWidth {
+		return s
+	}
+	if maxWidth <= 0 {
+		return ""
+	}
+
+	limit := maxWidth - lipgloss.Width(ellipsis)
+	var b strings.Builder
+	width := 0
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		cluster := g.Str()
+		w := lipgloss.Width(cluster)
+		if width+w > limit {
+			break
+		}
+		b.WriteString(cluster)
+		width += w
+	}
+	return b.String() + ellipsis
+}
+
+// PadRight pads s with spaces to width terminal cells.
+func PadRight(s string, width int) string {
+	if w := lipgloss.Width(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
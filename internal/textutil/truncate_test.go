@@ -0,0 +1,59 @@
+package textutil
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		s        string
+		maxWidth int
+		want     string
+	}{
+		{"fits", "api.example.com", 20, "api.example.com"},
+		{"exact", "api.example.com", 15, "api.example.com"},
+		{"ascii", "https://api.example.com/health", 12, "https://api…"},
+		{"zero", "api.example.com", 0, ""},
+		{"idn", "https://bücher.example/straße", 18, "https://bücher.ex…"},
+		{"cjk", "監視サービス", 7, "監視サ…"},
+		{"cjk odd cut", "監視サービス", 6, "監視…"},
+		{"emoji", "🚀 launch api", 5, "🚀 l…"},
+		{"flag", "🇯🇵🇩🇪🇫🇷", 5, "🇯🇵🇩🇪…"},
+		{"flag not split", "🇯🇵🇩🇪🇫🇷", 4, "🇯🇵…"},
+		{"zwj family", "👨‍👩‍👧 family status", 4, "👨‍👩‍👧 …"},
+		{"zwj not split", "👨‍👩‍👧👨‍👩‍👧", 3, "👨‍👩‍👧…"},
+		{"combining mark", "café menu", 5, "café…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.maxWidth)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.maxWidth, got, tt.want)
+			}
+			if w := lipgloss.Width(got); w > tt.maxWidth {
+				t.Errorf("Truncate(%q, %d) is %d cells wide", tt.s, tt.maxWidth, w)
+			}
+		})
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"up", 4, "up  "},
+		{"監視", 6, "監視  "},
+		{"🇯🇵", 3, "🇯🇵 "},
+		{"too long", 3, "too long"},
+	}
+	for _, tt := range tests {
+		if got := PadRight(tt.s, tt.width); got != tt.want {
+			t.Errorf("PadRight(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+	}
+}
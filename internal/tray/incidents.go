@@ -0,0 +1,185 @@
+package tray
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/getlantern/systray"
+)
+
+const maxIncidentItems = 5
+
+// incidentMenu is a fixed pool of menu items showing the most recent open
+// incidents. Slots are reassigned on every refresh, so the monitor a slot
+// points at is tracked separately and guarded by TrayApp.mu.
+type incidentMenu struct {
+	none       *systray.MenuItem
+	items      []*systray.MenuItem
+	monitorIDs []uint
+}
+
+func (t *TrayApp) newIncidentMenu() *incidentMenu {
+	header := systray.AddMenuItem("── Open Incidents ──", "")
+	header.Disable()
+
+	im := &incidentMenu{
+		none:       systray.AddMenuItem("No open incidents", ""),
+		items:      make([]*systray.MenuItem, maxIncidentItems),
+		monitorIDs: make([]uint, maxIncidentItems),
+	}
+	im.none.Disable()
+
+	for i := range im.items {
+		im.items[i] = systray.AddMenuItem("", "Open monitor details")
+		im.items[i].Hide()
+		go t.forwardIncidentClicks(im, i)
+	}
+	return im
+}
+
+func (t *TrayApp) forwardIncidentClicks(im *incidentMenu, slot int) {
+	for {
+		select {
+		case <-im.items[slot].ClickedCh:
+		case <-t.stopChan:
+			return
+		}
+
+		t.mu.RLock()
+		id := im.monitorIDs[slot]
+		t.mu.RUnlock()
+		if id == 0 {
+			continue
+		}
+
+		select {
+		case t.actions <- monitorAction{monitorID: id, kind: actionDetails}:
+		case <-t.stopChan:
+			return
+		}
+	}
+}
+
+// refreshIncidents reloads the open incidents from the database.
+func (t *TrayApp) refreshIncidents() {
+	incidents, err := t.db.GetAllRecentIncidents(50)
+	if err != nil {
+		slog.Error("failed to load incidents", "error", err)
+		return
+	}
+
+	var open []storage.Incident
+	for _, inc := range incidents {
+		if !inc.IsResolved() {
+			open = append(open, inc)
+		}
+		if len(open) == maxIncidentItems {
+			break
+		}
+	}
+
+	t.mu.Lock()
+	t.openIncidents = open
+	t.mu.Unlock()
+
+	t.renderIncidents()
+}
+
+// renderIncidents updates the incident labels from the cached incidents so
+// that durations keep ticking between refreshes.
+func (t *TrayApp) renderIncidents() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	im := t.mIncidents
+	if im == nil {
+		return
+	}
+
+	names := make(map[uint]string, len(t.monitors))
+	for _, mon := range t.monitors {
+		names[mon.ID] = menuName(mon.Name)
+	}
+
+	for i, item := range im.items {
+		if i >= len(t.openIncidents) {
+			im.monitorIDs[i] = 0
+			item.Hide()
+			continue
+		}
+
+		inc := t.openIncidents[i]
+		name, ok := names[inc.MonitorID]
+		if !ok {
+			name = fmt.Sprintf("Monitor %d", inc.MonitorID)
+		}
+		im.monitorIDs[i] = inc.MonitorID
+		state := "down"
+		if inc.IsPerformance() {
+			state = "slow"
+		}
+		item.SetTitle(fmt.Sprintf("%s — %s %s", name, state, formatShortDuration(inc.Duration())))
+		item.SetTooltip(inc.ErrorMessage)
+		item.Show()
+	}
+
+	if len(t.openIncidents) == 0 {
+		im.none.Show()
+	} else {
+		im.none.Hide()
+	}
+}
+
+// renderLastCheck updates the "Last checked / next in" status line from the
+// latest result of each enabled monitor and the checker's schedule, which
+// accounts for backoff and active hours.
+func (t *TrayApp) renderLastCheck() {
+	status := t.checker.GetStatus()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.mLastCheck == nil {
+		return
+	}
+
+	var last, next time.Time
+	for _, mon := range t.monitors {
+		result, ok := t.results[mon.ID]
+		if !mon.Enabled || !ok {
+			continue
+		}
+		if result.CreatedAt.After(last) {
+			last = result.CreatedAt
+		}
+		s, running := status[mon.ID]
+		if !running {
+			continue
+		}
+		if next.IsZero() || s.NextCheck.Before(next) {
+			next = s.NextCheck
+		}
+	}
+
+	if last.IsZero() {
+		t.mLastCheck.SetTitle("Waiting for first check")
+		return
+	}
+
+	nextText := "next due now"
+	if next.IsZero() {
+		nextText = "no checks scheduled"
+	} else if wait := time.Until(next); wait > 0 {
+		nextText = "next in " + formatSeconds(wait)
+	}
+	t.mLastCheck.SetTitle(fmt.Sprintf("Last checked %s ago / %s", formatSeconds(time.Since(last)), nextText))
+}
+
+func formatSeconds(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return formatShortDuration(d)
+}
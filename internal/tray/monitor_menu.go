@@ -0,0 +1,303 @@
+package tray
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/getlantern/systray"
+)
+
+type actionKind int
+
+const (
+	actionDetails actionKind = iota
+	actionCheckNow
+	actionPause
+	actionOpenURL
+)
+
+type monitorAction struct {
+	monitorID uint
+	kind      actionKind
+}
+
+// otherGroup holds the monitors without tags.
+const otherGroup = "Other"
+
+// groupMenu is the submenu for one tag. Like monitor entries, groups that
+// become empty are hidden rather than deleted.
+type groupMenu struct {
+	name   string
+	item   *systray.MenuItem
+	hidden bool
+}
+
+// menuKey identifies a monitor's entry within a group. A monitor with
+// several tags has an entry in each of their groups.
+type menuKey struct {
+	group string
+	id    uint
+}
+
+// monitorMenu is the menu entry for a single monitor. It stays bound to the
+// same monitor ID for its whole lifetime; systray cannot delete items, so
+// entries for removed monitors are hidden and reused if the monitor returns.
+type monitorMenu struct {
+	id       uint
+	url      string
+	item     *systray.MenuItem
+	details  *systray.MenuItem
+	checkNow *systray.MenuItem
+	pause    *systray.MenuItem
+	openURL  *systray.MenuItem
+	hidden   bool
+	paused   bool
+}
+
+func (t *TrayApp) newMonitorMenu(group *groupMenu, mon storage.Monitor) *monitorMenu {
+	item := group.item.AddSubMenuItem(statusTitle(mon), mon.URL)
+	mm := &monitorMenu{
+		id:       mon.ID,
+		url:      mon.URL,
+		item:     item,
+		details:  item.AddSubMenuItem("Open Details", "Show history in the web UI"),
+		checkNow: item.AddSubMenuItem("Check Now", "Check this monitor immediately"),
+		pause:    item.AddSubMenuItem("Pause", "Stop checking this monitor"),
+		openURL:  item.AddSubMenuItem("Open URL", mon.URL),
+	}
+	go t.forwardClicks(mm)
+	return mm
+}
+
+// forwardClicks turns the entry's click channels into monitorActions so
+// they are handled by the main tray loop.
+func (t *TrayApp) forwardClicks(mm *monitorMenu) {
+	for {
+		var kind actionKind
+		select {
+		case <-mm.item.ClickedCh:
+			kind = actionDetails
+		case <-mm.details.ClickedCh:
+			kind = actionDetails
+		case <-mm.checkNow.ClickedCh:
+			kind = actionCheckNow
+		case <-mm.pause.ClickedCh:
+			kind = actionPause
+		case <-mm.openURL.ClickedCh:
+			kind = actionOpenURL
+		case <-t.stopChan:
+			return
+		}
+
+		select {
+		case t.actions <- monitorAction{monitorID: mm.id, kind: kind}:
+		case <-t.stopChan:
+			return
+		}
+	}
+}
+
+func (mm *monitorMenu) update(mon storage.Monitor) {
+	mm.url = mon.URL
+	mm.item.SetTooltip(mon.URL)
+	mm.openURL.SetTooltip(mon.URL)
+
+	if mm.hidden {
+		mm.item.Show()
+		mm.hidden = false
+	}
+
+	if !mon.Enabled {
+		mm.item.SetTitle(fmt.Sprintf("⏸ %s (paused)", menuName(mon.Name)))
+		mm.pause.SetTitle("Resume")
+		mm.checkNow.Disable()
+	} else if mm.paused {
+		mm.item.SetTitle(statusTitle(mon))
+		mm.pause.SetTitle("Pause")
+		mm.checkNow.Enable()
+	}
+	mm.paused = !mon.Enabled
+}
+
+// monitorGroups returns the groups mon is listed in: its tags, or the
+// Other group.
+func monitorGroups(mon storage.Monitor) []string {
+	if tags := storage.ParseTags(mon.Tags); len(tags) > 0 {
+		return tags
+	}
+	return []string{otherGroup}
+}
+
+// syncMenu reconciles the group submenus and monitor entries with the
+// given monitors, adding entries for new monitors and hiding those that
+// were removed. New groups are added to the Monitors submenu in
+// alphabetical order with Other last, but systray can only append, so a
+// group first seen later goes at the end of it. Callers must hold t.mu.
+func (t *TrayApp) syncMenu(monitors []storage.Monitor) {
+	var names []string
+	for _, mon := range monitors {
+		for _, g := range monitorGroups(mon) {
+			if _, exists := t.mGroups[g]; !exists && !slices.Contains(names, g) {
+				names = append(names, g)
+			}
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		switch {
+		case a == otherGroup:
+			return 1
+		case b == otherGroup:
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	for _, name := range names {
+		t.mGroups[name] = &groupMenu{name: name, item: t.mGroupsRoot.AddSubMenuItem(name, "")}
+	}
+
+	seen := make(map[menuKey]bool, len(monitors))
+	used := make(map[string]bool, len(t.mGroups))
+	for _, mon := range monitors {
+		for _, g := range monitorGroups(mon) {
+			key := menuKey{group: g, id: mon.ID}
+			seen[key] = true
+			used[g] = true
+
+			mm, exists := t.mMonitors[key]
+			if !exists {
+				mm = t.newMonitorMenu(t.mGroups[g], mon)
+				t.mMonitors[key] = mm
+			}
+			mm.update(mon)
+		}
+	}
+
+	for key, mm := range t.mMonitors {
+		if !seen[key] && !mm.hidden {
+			mm.item.Hide()
+			mm.hidden = true
+		}
+	}
+	for name, gm := range t.mGroups {
+		switch {
+		case !used[name] && !gm.hidden:
+			gm.item.Hide()
+			gm.hidden = true
+		case used[name] && gm.hidden:
+			gm.item.Show()
+			gm.hidden = false
+		}
+	}
+}
+
+// renderGroups sets each group's title from the worst health of its
+// monitors, e.g. "✗ prod (2 down)". Callers must hold t.mu.
+func (t *TrayApp) renderGroups() {
+	type counts struct{ total, down, slow, paused int }
+	groups := make(map[string]*counts, len(t.mGroups))
+	for _, mon := range t.monitors {
+		h := t.health(mon)
+		for _, g := range monitorGroups(mon) {
+			c := groups[g]
+			if c == nil {
+				c = &counts{}
+				groups[g] = c
+			}
+			c.total++
+			switch h {
+			case healthDown:
+				c.down++
+			case healthSlow:
+				c.slow++
+			case healthPaused:
+				c.paused++
+			}
+		}
+	}
+
+	for name, gm := range t.mGroups {
+		c := groups[name]
+		if c == nil {
+			continue
+		}
+		label := name
+		if t.critical[name] {
+			label += " ★"
+		}
+		switch {
+		case c.down > 0:
+			label = fmt.Sprintf("✗ %s (%d down)", label, c.down)
+		case c.slow > 0:
+			label = fmt.Sprintf("◐ %s (%d slow)", label, c.slow)
+		case c.paused == c.total:
+			label = fmt.Sprintf("⏸ %s (paused)", label)
+		default:
+			label = fmt.Sprintf("✓ %s (%d)", label, c.total)
+		}
+		gm.item.SetTitle(label)
+	}
+}
+
+func (t *TrayApp) handleMonitorAction(action monitorAction) {
+	switch action.kind {
+	case actionDetails:
+		go t.settings.ShowMonitor(action.monitorID)
+
+	case actionCheckNow:
+		t.checker.CheckMonitor(action.monitorID)
+
+	case actionPause:
+		monitor, err := t.db.GetMonitor(action.monitorID)
+		if err != nil {
+			slog.Error("failed to load monitor", "id", action.monitorID, "error", err)
+			return
+		}
+		if err := t.db.ToggleMonitor(monitor.ID, !monitor.Enabled); err != nil {
+			slog.Error("failed to toggle monitor", "id", monitor.ID, "error", err)
+			return
+		}
+		t.reload()
+
+	case actionOpenURL:
+		var url string
+		t.mu.RLock()
+		for key, mm := range t.mMonitors {
+			if key.id == action.monitorID {
+				url = mm.url
+				break
+			}
+		}
+		t.mu.RUnlock()
+		if url != "" {
+			openBrowser(url)
+		}
+	}
+}
+
+// statusTitle labels mon's menu entry before the checker reports a
+// result for it.
+func statusTitle(mon storage.Monitor) string {
+	status := mon.DisplayStatus()
+	if status == storage.StatusPending {
+		return fmt.Sprintf("%s %s (first check pending)", statusIcon(status), menuName(mon.Name))
+	}
+	return fmt.Sprintf("%s %s", statusIcon(status), menuName(mon.Name))
+}
+
+func statusIcon(status string) string {
+	switch status {
+	case "up":
+		return "✓"
+	case "down":
+		return "✗"
+	case storage.StatusOutOfSchedule:
+		return "◌"
+	case storage.StatusPending:
+		return "◷"
+	default:
+		return "○"
+	}
+}
@@ -0,0 +1,74 @@
+package tray
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startHung starts s serving a handler that blocks until its client goes
+// away, with one request already waiting in it.
+func startHung(t *testing.T) *SettingsServer {
+	t.Helper()
+	entered := make(chan struct{}, 1)
+	s := &SettingsServer{handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-r.Context().Done()
+	})}
+	if err := s.start(); err != nil {
+		t.Fatal(err)
+	}
+
+	go http.Get(fmt.Sprintf("http://127.0.0.1:%d/", s.port))
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request never reached the server")
+	}
+	return s
+}
+
+func TestSettingsStopWithHungRequest(t *testing.T) {
+	s := startHung(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := s.Stop(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Stop took %s, want it to give up at the 100ms deadline", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	if _, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/", s.port)); err == nil {
+		t.Error("server still accepts requests after Stop")
+	}
+}
+
+func TestSettingsStopTwice(t *testing.T) {
+	s := startHung(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	s.Stop(ctx)
+
+	start := time.Now()
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("second Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("second Stop took %s, want it to return at once", elapsed)
+	}
+}
+
+func TestSettingsStopNotStarted(t *testing.T) {
+	var s SettingsServer
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Stop before Show: %v", err)
+	}
+}
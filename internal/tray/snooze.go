@@ -0,0 +1,115 @@
+package tray
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getlantern/systray"
+)
+
+type snoozeMenu struct {
+	parent   *systray.MenuItem
+	min30    *systray.MenuItem
+	hours2   *systray.MenuItem
+	tomorrow *systray.MenuItem
+	off      *systray.MenuItem
+}
+
+func newSnoozeMenu() *snoozeMenu {
+	parent := systray.AddMenuItem("🔔 Snooze Notifications", "Mute notifications while monitoring continues")
+	return &snoozeMenu{
+		parent:   parent,
+		min30:    parent.AddSubMenuItem("For 30 minutes", ""),
+		hours2:   parent.AddSubMenuItem("For 2 hours", ""),
+		tomorrow: parent.AddSubMenuItem("Until tomorrow 8:00", ""),
+		off:      parent.AddSubMenuItem("Off", "Resume notifications"),
+	}
+}
+
+func tomorrowMorning() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 8, 0, 0, 0, now.Location())
+}
+
+func (t *TrayApp) snooze(until time.Time) {
+	t.notifier.Snooze(until)
+	t.refreshSnooze()
+}
+
+// unsnooze resumes notifications and immediately reports any monitor that
+// went down while notifications were muted.
+func (t *TrayApp) unsnooze() {
+	t.notifier.Snooze(time.Time{})
+	t.refreshSnooze()
+	t.notifier.NotifyDownSummary(t.downNames())
+}
+
+// toggleNotifications turns notifications on or off for good, reporting
+// what is down when they come back on, as unsnooze does.
+func (t *TrayApp) toggleNotifications() {
+	enabled := !t.notifier.IsEnabled()
+	t.notifier.SetEnabled(enabled)
+	t.refreshNotify()
+	if enabled {
+		t.notifier.NotifyDownSummary(t.downNames())
+	}
+}
+
+// refreshNotify syncs the Notifications checkbox with the persisted flag,
+// which `statping notify` may change while the tray runs.
+func (t *TrayApp) refreshNotify() {
+	if t.notifier.IsEnabled() {
+		t.mNotify.Check()
+	} else {
+		t.mNotify.Uncheck()
+	}
+}
+
+func (t *TrayApp) downNames() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var down []string
+	for _, mon := range t.monitors {
+		if mon.Enabled && mon.CurrentStatus == "down" {
+			down = append(down, mon.Name)
+		}
+	}
+	return down
+}
+
+// refreshSnooze updates the snooze menu and title indicator, resuming
+// notifications once a snooze has run out.
+func (t *TrayApp) refreshSnooze() {
+	if t.mSnooze == nil {
+		return
+	}
+
+	until := t.notifier.SnoozedUntil()
+	if until.IsZero() {
+		systray.SetTitle("")
+		t.mSnooze.parent.SetTitle("🔔 Snooze Notifications")
+		t.mSnooze.off.Disable()
+		return
+	}
+
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		t.unsnooze()
+		return
+	}
+
+	systray.SetTitle("💤")
+	t.mSnooze.parent.SetTitle(fmt.Sprintf("💤 Snoozed (%s left)", formatShortDuration(remaining)))
+	t.mSnooze.off.Enable()
+}
+
+func formatShortDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -3,36 +3,62 @@ package tray
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"regexp"
+	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/ankityadav/statping/internal/checker"
 	"github.com/ankityadav/statping/internal/config"
+	"github.com/ankityadav/statping/internal/control"
+	"github.com/ankityadav/statping/internal/metrics"
 	"github.com/ankityadav/statping/internal/notifier"
 	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/textutil"
 	"github.com/getlantern/systray"
 )
 
 type TrayApp struct {
-	db        *storage.Database
-	notifier  *notifier.Notifier
-	monitors  []storage.Monitor
-	mu        sync.RWMutex
-	stopChan  chan struct{}
-	status    string
-	mStatus   *systray.MenuItem
-	mMonitors []*systray.MenuItem
+	db            *storage.Database
+	notifier      *notifier.Notifier
+	checker       *checker.Checker
+	cancel        context.CancelFunc
+	monitors      []storage.Monitor
+	results       map[uint]storage.CheckResult
+	openIncidents []storage.Incident
+	mu            sync.RWMutex
+	stopChan      chan struct{}
+	status        string
+	mStatus       *systray.MenuItem
+	mLastCheck    *systray.MenuItem
+	mIncidents    *incidentMenu
+	mSnooze       *snoozeMenu
+	mNotify       *systray.MenuItem
+	mGroupsRoot   *systray.MenuItem
+	mGroups       map[string]*groupMenu
+	mMonitors     map[menuKey]*monitorMenu
+	critical      map[string]bool
+	actions       chan monitorAction
+	settings      *SettingsServer
+	control       *control.Server
+	metrics       *metrics.InfluxSink
 }
 
 func New(db *storage.Database) *TrayApp {
-	return &TrayApp{
-		db:       db,
-		notifier: notifier.New(),
-		stopChan: make(chan struct{}),
-		status:   "green",
+	n := notifier.NewPersistent(db)
+	t := &TrayApp{
+		db:        db,
+		notifier:  n,
+		checker:   checker.New(db, n),
+		stopChan:  make(chan struct{}),
+		status:    "green",
+		results:   make(map[uint]storage.CheckResult),
+		mGroups:   make(map[string]*groupMenu),
+		mMonitors: make(map[menuKey]*monitorMenu),
+		actions:   make(chan monitorAction),
 	}
+	t.settings = NewSettingsWindow(db, t.reload)
+	t.control = control.NewServer(db, t.checker, "tray", t.reload)
+	return t
 }
 
 func (t *TrayApp) Run() {
@@ -46,32 +72,80 @@ func (t *TrayApp) onReady() {
 
 	t.mStatus = systray.AddMenuItem("● All Systems Operational", "Current status")
 	t.mStatus.Disable()
+	t.mLastCheck = systray.AddMenuItem("Waiting for first check", "")
+	t.mLastCheck.Disable()
 
 	systray.AddSeparator()
 
-	mHeader := systray.AddMenuItem("── Monitors ──", "")
-	mHeader.Disable()
+	// Groups go in a submenu of their own: systray can only append, and a
+	// group first seen after startup would otherwise land below Quit.
+	t.mGroupsRoot = systray.AddMenuItem("Monitors", "Monitors by group")
 
 	t.loadMonitors()
 
 	systray.AddSeparator()
 
+	t.mIncidents = t.newIncidentMenu()
+	t.refreshIncidents()
+
+	systray.AddSeparator()
+
 	mRefresh := systray.AddMenuItem("↻ Refresh Now", "Check all monitors immediately")
 	mSettings := systray.AddMenuItem("⚙ Settings...", "Open settings window")
+	t.mNotify = systray.AddMenuItemCheckbox("Notifications", "Turn all notifications on or off", t.notifier.IsEnabled())
+	t.mSnooze = newSnoozeMenu()
 
 	systray.AddSeparator()
 
 	mQuit := systray.AddMenuItem("Quit Statping", "Stop monitoring and exit")
 
-	go t.runChecker()
+	t.refreshSnooze()
+
+	t.checker.Subscribe(t.onResult)
+	t.metrics = metrics.StartInflux(t.db, t.checker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	if err := t.checker.Start(ctx); err != nil {
+		slog.Error("failed to start checker", "error", err)
+	}
+
+	if path, err := config.GetSocketPath(); err != nil {
+		slog.Warn("control server disabled", "error", err)
+	} else if err := t.control.Listen(path); err != nil {
+		slog.Warn("control server disabled", "error", err)
+	}
 
 	go func() {
+		snoozeTicker := time.NewTicker(time.Minute)
+		defer snoozeTicker.Stop()
+		infoTicker := time.NewTicker(5 * time.Second)
+		defer infoTicker.Stop()
+
 		for {
 			select {
 			case <-mRefresh.ClickedCh:
-				go t.checkAllMonitors()
+				t.checker.CheckAll()
+			case <-t.mSnooze.min30.ClickedCh:
+				t.snooze(time.Now().Add(30 * time.Minute))
+			case <-t.mSnooze.hours2.ClickedCh:
+				t.snooze(time.Now().Add(2 * time.Hour))
+			case <-t.mSnooze.tomorrow.ClickedCh:
+				t.snooze(tomorrowMorning())
+			case <-t.mSnooze.off.ClickedCh:
+				t.unsnooze()
+			case <-t.mNotify.ClickedCh:
+				t.toggleNotifications()
+			case <-snoozeTicker.C:
+				t.refreshSnooze()
+				t.refreshNotify()
+			case <-infoTicker.C:
+				t.renderLastCheck()
+				t.renderIncidents()
 			case <-mSettings.ClickedCh:
-				go t.openSettings()
+				go t.settings.Show()
+			case action := <-t.actions:
+				t.handleMonitorAction(action)
 			case <-mQuit.ClickedCh:
 				systray.Quit()
 				return
@@ -82,210 +156,179 @@ func (t *TrayApp) onReady() {
 	}()
 }
 
-func (t *TrayApp) openSettings() {
-	settings := NewSettingsWindow(t.db, func() {
-		t.loadMonitors()
-		t.checkAllMonitors()
-	})
-	settings.Show()
+// reload picks up monitor changes made outside the tray, e.g. through the
+// settings page.
+func (t *TrayApp) reload() {
+	t.loadMonitors()
+	t.refreshIncidents()
+	if err := t.checker.Reload(); err != nil {
+		slog.Error("failed to reload monitors", "error", err)
+	}
 }
 
 func (t *TrayApp) onExit() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := t.settings.Stop(ctx); err != nil {
+		slog.Error("settings server shutdown error", "error", err)
+	}
+	t.control.Close()
 	close(t.stopChan)
+	if t.cancel != nil {
+		t.cancel()
+	}
+	t.metrics.Close()
 }
 
 func (t *TrayApp) loadMonitors() {
-	monitors, err := t.db.ListEnabledMonitors()
+	monitors, err := t.db.ListMonitors()
 	if err != nil {
 		return
 	}
 
-	t.mu.Lock()
-	t.monitors = monitors
-
-	for _, item := range t.mMonitors {
-		item.Hide()
-	}
-	t.mMonitors = nil
-
-	for _, mon := range monitors {
-		statusIcon := "○"
-		switch mon.CurrentStatus {
-		case "up":
-			statusIcon = "✓"
-		case "down":
-			statusIcon = "✗"
-		}
-		item := systray.AddMenuItem(fmt.Sprintf("%s %s", statusIcon, mon.Name), mon.URL)
-		item.Disable()
-		t.mMonitors = append(t.mMonitors, item)
-	}
-	t.mu.Unlock()
-}
-
-func (t *TrayApp) runChecker() {
-	t.checkAllMonitors()
-
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			t.checkAllMonitors()
-		case <-t.stopChan:
-			return
-		}
-	}
-}
-
-func (t *TrayApp) checkAllMonitors() {
-	monitors, err := t.db.ListEnabledMonitors()
-	if err != nil {
-		return
+	critical := make(map[string]bool)
+	for _, g := range storage.ParseTags(t.db.GetStringSetting(storage.SettingCriticalGroups, "")) {
+		critical[g] = true
 	}
 
 	t.mu.Lock()
 	t.monitors = monitors
+	t.critical = critical
+	t.syncMenu(monitors)
 	t.mu.Unlock()
 
-	if len(monitors) == 0 {
-		t.updateStatus("green", "No monitors configured")
-		return
-	}
-
-	var hasDown, hasSlow bool
-	var downCount, slowCount, upCount int
-
-	for i, mon := range monitors {
-		statusCode, responseTime, checkErr := t.checkMonitor(&mon)
-
-		now := time.Now()
-		result := &storage.CheckResult{
-			MonitorID:    mon.ID,
-			StatusCode:   statusCode,
-			ResponseTime: responseTime,
-			Success:      checkErr == nil,
-			CreatedAt:    now,
-		}
-		if checkErr != nil {
-			result.ErrorMessage = checkErr.Error()
-		}
-		t.db.CreateCheckResult(result)
-
-		t.mu.Lock()
-		var label string
-		if checkErr != nil {
-			label = fmt.Sprintf("✗ %s (DOWN)", mon.Name)
-			hasDown = true
-			downCount++
-
-			mon.ConsecutiveFails++
-			if mon.ConsecutiveFails >= config.DefaultMaxFailures {
-				wasUp := mon.CurrentStatus != "down"
-				mon.CurrentStatus = "down"
-				if wasUp {
-					t.notifier.NotifyDown(mon.Name, mon.URL, checkErr.Error())
-				}
-			}
-		} else if responseTime > 1000 {
-			label = fmt.Sprintf("◐ %s (%dms)", mon.Name, responseTime)
-			hasSlow = true
-			slowCount++
-
-			wasDown := mon.CurrentStatus == "down"
-			mon.CurrentStatus = "up"
-			mon.ConsecutiveFails = 0
-			if wasDown {
-				t.notifier.NotifyRecovery(mon.Name, mon.URL)
-			}
-		} else {
-			label = fmt.Sprintf("✓ %s (%dms)", mon.Name, responseTime)
-			upCount++
+	t.refreshStatus()
+}
 
-			wasDown := mon.CurrentStatus == "down"
-			mon.CurrentStatus = "up"
-			mon.ConsecutiveFails = 0
-			if wasDown {
-				t.notifier.NotifyRecovery(mon.Name, mon.URL)
-			}
+// onResult is called by the checker after every check and updates the
+// monitor's menu label and the overall tray status.
+func (t *TrayApp) onResult(mon storage.Monitor, result storage.CheckResult) {
+	t.mu.Lock()
+	t.results[mon.ID] = result
+	statusChanged := false
+	for i := range t.monitors {
+		if t.monitors[i].ID == mon.ID {
+			statusChanged = t.monitors[i].CurrentStatus != mon.CurrentStatus
+			t.monitors[i] = mon
 		}
-
-		if i < len(t.mMonitors) {
-			t.mMonitors[i].SetTitle(label)
+	}
+	for key, mm := range t.mMonitors {
+		if key.id == mon.ID && !mm.hidden {
+			mm.item.SetTitle(monitorLabel(mon, result))
 		}
-		t.mu.Unlock()
-
-		mon.LastCheckAt = &now
-		t.db.UpdateMonitor(&mon)
 	}
+	t.mu.Unlock()
 
-	if hasDown {
-		t.updateStatus("red", fmt.Sprintf("%d down, %d up", downCount, upCount))
-	} else if hasSlow {
-		t.updateStatus("yellow", fmt.Sprintf("%d slow, %d up", slowCount, upCount))
-	} else {
-		t.updateStatus("green", fmt.Sprintf("All %d monitors operational", upCount))
+	t.refreshStatus()
+	t.renderLastCheck()
+	if statusChanged {
+		t.refreshIncidents()
 	}
 }
 
-func (t *TrayApp) checkMonitor(mon *storage.Monitor) (int, int64, error) {
-	timeout := time.Duration(mon.Timeout) * time.Second
-	if timeout == 0 {
-		timeout = time.Duration(config.DefaultTimeout) * time.Second
+func monitorLabel(mon storage.Monitor, result storage.CheckResult) string {
+	name := menuName(mon.Name)
+	switch {
+	case !result.Success:
+		return fmt.Sprintf("✗ %s (DOWN)", name)
+	case result.ResponseTime > mon.LatencyThresholds().Slow:
+		return fmt.Sprintf("◐ %s (%dms)", name, result.ResponseTime)
+	default:
+		return fmt.Sprintf("✓ %s (%dms)", name, result.ResponseTime)
 	}
+}
 
-	client := &http.Client{Timeout: timeout}
+// menuName keeps long monitor names from stretching the tray menu.
+func menuName(name string) string {
+	return textutil.Truncate(name, 40)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+type health int
 
-	req, err := http.NewRequestWithContext(ctx, "GET", mon.URL, nil)
-	if err != nil {
-		return 0, 0, err
-	}
-	req.Header.Set("User-Agent", "Statping/1.0")
+const (
+	healthUnknown health = iota
+	healthUp
+	healthSlow
+	healthDown
+	healthPaused
+)
 
-	start := time.Now()
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, 0, err
+// health classifies mon by its latest result. Callers must hold t.mu.
+func (t *TrayApp) health(mon storage.Monitor) health {
+	if !mon.Enabled {
+		return healthPaused
 	}
-	defer resp.Body.Close()
-
-	responseTime := time.Since(start).Milliseconds()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return resp.StatusCode, responseTime, fmt.Errorf("failed to read body: %w", err)
+	result, ok := t.results[mon.ID]
+	switch {
+	case !ok:
+		return healthUnknown
+	case !result.Success:
+		return healthDown
+	case result.ResponseTime > mon.LatencyThresholds().Slow:
+		return healthSlow
+	default:
+		return healthUp
 	}
+}
 
-	expectedCodes := storage.ParseExpectedCodes(mon.ExpectedCodes)
-	statusOK := false
-	for _, code := range expectedCodes {
-		if resp.StatusCode == code {
-			statusOK = true
-			break
-		}
+// isCritical reports whether mon counts towards the tray icon: it is in a
+// group named by the critical-groups setting, or no groups are marked
+// critical. Callers must hold t.mu.
+func (t *TrayApp) isCritical(mon storage.Monitor) bool {
+	if len(t.critical) == 0 {
+		return true
 	}
-
-	if !statusOK {
-		return resp.StatusCode, responseTime, fmt.Errorf("status %d", resp.StatusCode)
+	for _, g := range monitorGroups(mon) {
+		if t.critical[g] {
+			return true
+		}
 	}
+	return false
+}
 
-	keywords := storage.ParseKeywords(mon.Keywords)
-	if len(keywords) > 0 {
-		bodyStr := string(body)
-		for _, keyword := range keywords {
-			pattern := "(?i)" + regexp.QuoteMeta(keyword)
-			matched, _ := regexp.MatchString(pattern, bodyStr)
-			if !matched {
-				return resp.StatusCode, responseTime, fmt.Errorf("keyword '%s' not found", keyword)
+// refreshStatus recomputes the tray icon, status line and group titles
+// from the latest result of every enabled monitor.
+func (t *TrayApp) refreshStatus() {
+	t.mu.RLock()
+	var monitors, downCount, slowCount, upCount, otherDown int
+	for _, mon := range t.monitors {
+		h := t.health(mon)
+		if h == healthPaused {
+			continue
+		}
+		if !t.isCritical(mon) {
+			if h == healthDown {
+				otherDown++
 			}
+			continue
+		}
+		monitors++
+		switch h {
+		case healthDown:
+			downCount++
+		case healthSlow:
+			slowCount++
+		case healthUp:
+			upCount++
 		}
 	}
+	t.renderGroups()
+	t.mu.RUnlock()
 
-	return resp.StatusCode, responseTime, nil
+	var note string
+	if otherDown > 0 {
+		note = fmt.Sprintf(" (%d non-critical down)", otherDown)
+	}
+	switch {
+	case monitors == 0:
+		t.updateStatus("green", "No monitors configured"+note)
+	case downCount > 0:
+		t.updateStatus("red", fmt.Sprintf("%d down, %d up", downCount, upCount)+note)
+	case slowCount > 0:
+		t.updateStatus("yellow", fmt.Sprintf("%d slow, %d up", slowCount, upCount)+note)
+	default:
+		t.updateStatus("green", fmt.Sprintf("All %d monitors operational", upCount)+note)
+	}
 }
 
 func (t *TrayApp) updateStatus(status, message string) {
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// checkPane is a scrollable view of a single check result with its full
+// error, timings and stored response, wrapped to the terminal width.
+type checkPane struct {
+	check    storage.CheckResult
+	viewport viewport.Model
+}
+
+func newCheckPane(cr storage.CheckResult, width, height int) *checkPane {
+	p := &checkPane{check: cr}
+	p.setSize(width, height)
+	return p
+}
+
+func (p *checkPane) setSize(width, height int) {
+	if width <= 0 {
+		width = 80
+	}
+	if height <= 0 {
+		height = 24
+	}
+	// Leave room for the title and the help line.
+	p.viewport = viewport.New(width, max(height-4, 1))
+	p.viewport.SetContent(p.content(width))
+}
+
+func (p *checkPane) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	p.viewport, cmd = p.viewport.Update(msg)
+	return cmd
+}
+
+func (p *checkPane) view() string {
+	var b strings.Builder
+	title := fmt.Sprintf("Check #%d: %s", p.check.ID, p.check.CreatedAt.Format("2006-01-02 15:04:05"))
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+	b.WriteString(p.viewport.View())
+	b.WriteString("\n")
+	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
+		fmt.Sprintf("↑/↓/pgup/pgdn: scroll (%.0f%%) • esc: back", p.viewport.ScrollPercent()*100),
+	)
+	b.WriteString(help)
+	return b.String()
+}
+
+func (p *checkPane) content(width int) string {
+	cr := p.check
+	label := lipgloss.NewStyle().Bold(true)
+	wrap := lipgloss.NewStyle().Width(width)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Width(width)
+
+	var b strings.Builder
+	field := func(name, value string) {
+		b.WriteString(wrap.Render(label.Render(name+": ")+value) + "\n")
+	}
+
+	if cr.Success {
+		field("Result", statusUpStyle.Render("✓ Success"))
+	} else {
+		field("Result", statusDownStyle.Render("✗ Failed"))
+	}
+	if cr.StatusCode != 0 {
+		field("Status Code", fmt.Sprintf("%d", cr.StatusCode))
+	} else {
+		field("Status Code", "none (no response)")
+	}
+	field("Response Time", fmt.Sprintf("%dms", cr.ResponseTime))
+	if timings := checkTimings(cr); timings != "" {
+		field("Timing", timings)
+	}
+	if families := familyTimes(cr); families != "" {
+		field("Per Family", families)
+	}
+	if cr.ResolvedIP != "" {
+		field("Address", cr.ResolvedIP)
+	}
+	if cr.Protocol != "" {
+		field("Protocol", cr.Protocol)
+	}
+	if cr.TLSVersion != "" {
+		field("TLS", cr.TLSVersion+" "+cr.TLSCipher)
+	}
+
+	if cr.ErrorMessage != "" {
+		b.WriteString("\n" + label.Render("Error") + "\n")
+		b.WriteString(wrap.Render(cr.ErrorMessage) + "\n")
+	}
+	if cr.ResponseHeaders != "" {
+		b.WriteString("\n" + label.Render("Response Headers") + "\n")
+		b.WriteString(dim.Render(cr.ResponseHeaders) + "\n")
+	}
+	if cr.ResponseSnippet != "" {
+		b.WriteString("\n" + label.Render("Response Body") + "\n")
+		b.WriteString(dim.Render(strings.ReplaceAll(cr.ResponseSnippet, "\r", "")) + "\n")
+	} else if !cr.Success && cr.StatusCode != 0 {
+		b.WriteString("\nNo response body was recorded\n")
+	}
+	return b.String()
+}
+
+// checkTimings lists the phases of a check that took time. A reused
+// connection has no DNS, connect or TLS phase.
+func checkTimings(cr storage.CheckResult) string {
+	var parts []string
+	add := func(name string, ms int64) {
+		if ms > 0 {
+			parts = append(parts, fmt.Sprintf("%s %dms", name, ms))
+		}
+	}
+	add("DNS", cr.DNSTime)
+	add("connect", cr.ConnectTime)
+	add("TLS", cr.TLSTime)
+	add("first byte", cr.FirstByteTime)
+	if len(parts) == 0 {
+		return ""
+	}
+	if cr.FirstByteTime > 0 && cr.DNSTime == 0 && cr.ConnectTime == 0 {
+		parts = append(parts, "reused connection")
+	}
+	return strings.Join(parts, " • ")
+}
@@ -3,10 +3,12 @@ package tui
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/textutil"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -96,6 +98,9 @@ var (
 	dGraphRedStyle = lipgloss.NewStyle().
 			Foreground(dColorRed)
 
+	dGraphPurpleStyle = lipgloss.NewStyle().
+				Foreground(dColorPurple)
+
 	dHelpStyle = lipgloss.NewStyle().
 			Foreground(dColorDimGray)
 
@@ -107,50 +112,266 @@ var (
 )
 
 type DashboardModel struct {
-	db            *storage.Database
-	monitors      []storage.Monitor
-	checkResults  map[uint][]storage.CheckResult
-	width         int
-	height        int
-	selectedIndex int
-	lastUpdate    time.Time
+	db           *storage.Database
+	opts         DashboardOptions
+	monitors     []storage.Monitor
+	checkResults map[uint][]storage.CheckResult
+	// resultCursor is the highest check result ID loaded per monitor.
+	// Results saved late get a higher ID than ones checked after them,
+	// so it is tracked apart from the newest-first order of checkResults.
+	resultCursor map[uint]uint
+	percentiles  map[uint]storage.ResponseTimePercentiles
+	stats        map[uint]storage.MonitorStats
+	slaReports   map[uint]*storage.SLAReport
+	statsUpdated time.Time
+	monitorSet   string
+	width        int
+	height       int
+	selectedID   uint
+	filter       string
+	lastUpdate   time.Time
+	activity     storage.MonitoringActivity
+}
+
+// Status filters selected through the summary cards. Checked monitors that
+// are neither up nor down count as unknown, as on the cards.
+const (
+	dashFilterAll     = ""
+	dashFilterUp      = "up"
+	dashFilterDown    = "down"
+	dashFilterUnknown = "unknown"
+	dashFilterPending = storage.StatusPending
+)
+
+var dashFilters = []string{dashFilterAll, dashFilterUp, dashFilterDown, dashFilterUnknown, dashFilterPending}
+
+func statusBucket(mon storage.Monitor) string {
+	switch mon.DisplayStatus() {
+	case "up":
+		return dashFilterUp
+	case "down":
+		return dashFilterDown
+	case storage.StatusPending:
+		return dashFilterPending
+	}
+	return dashFilterUnknown
 }
 
 type dashTickMsg time.Time
 
-func NewDashboard(db *storage.Database) DashboardModel {
+// DashboardOptions scope the dashboard. Scope describes Filter in the
+// header, e.g. "tag api".
+type DashboardOptions struct {
+	Filter  storage.MonitorFilter
+	Scope   string
+	Refresh time.Duration
+}
+
+// DefaultDashboardRefresh is how often the dashboard reloads by default.
+const DefaultDashboardRefresh = 2 * time.Second
+
+func NewDashboard(db *storage.Database, opts DashboardOptions) DashboardModel {
+	if opts.Refresh <= 0 {
+		opts.Refresh = DefaultDashboardRefresh
+	}
 	m := DashboardModel{
 		db:           db,
+		opts:         opts,
 		checkResults: make(map[uint][]storage.CheckResult),
+		resultCursor: make(map[uint]uint),
+		percentiles:  make(map[uint]storage.ResponseTimePercentiles),
+		slaReports:   make(map[uint]*storage.SLAReport),
 	}
 	m.loadData()
 	return m
 }
 
+// dashHistory is the number of recent checks kept per monitor for the
+// sparkline and metrics.
+const dashHistory = 60
+
+// loadData refreshes the monitor list and appends only check results that
+// arrived since the last tick. Check history is fully reloaded only when
+// monitors are added or removed.
 func (m *DashboardModel) loadData() {
 	monitors, err := m.db.ListMonitors()
 	if err != nil {
 		return
 	}
+	monitors = m.opts.Filter.Apply(monitors)
 	m.monitors = monitors
+	if activity, err := m.db.GetMonitoringActivity(); err == nil {
+		m.activity = activity
+	}
 
-	for _, mon := range monitors {
-		results, err := m.db.GetRecentCheckResults(mon.ID, 60)
-		if err == nil {
-			m.checkResults[mon.ID] = results
+	ids := make([]string, len(monitors))
+	for i, mon := range monitors {
+		ids[i] = fmt.Sprint(mon.ID)
+	}
+	monitorSet := strings.Join(ids, ",")
+
+	if monitorSet != m.monitorSet {
+		m.checkResults = make(map[uint][]storage.CheckResult, len(monitors))
+		m.resultCursor = make(map[uint]uint, len(monitors))
+		for _, mon := range monitors {
+			results, err := m.db.GetRecentCheckResults(mon.ID, dashHistory)
+			if err == nil {
+				m.checkResults[mon.ID] = results
+				m.resultCursor[mon.ID] = maxResultID(results)
+			}
+		}
+		m.monitorSet = monitorSet
+		m.statsUpdated = time.Time{}
+	} else {
+		// Only monitors whose newest check changed need a query.
+		latest, err := m.db.GetLatestResultPerMonitor()
+		for _, mon := range monitors {
+			var newestID uint
+			if err == nil {
+				newest, ok := latest[mon.ID]
+				if !ok || newest.ID <= m.resultCursor[mon.ID] {
+					continue
+				}
+				newestID = newest.ID
+			}
+			m.appendNewResults(mon.ID, newestID)
+		}
+	}
+
+	// Percentiles and SLA reports scan long periods, so refresh them less
+	// often than the refresh tick.
+	if time.Since(m.statsUpdated) >= time.Minute {
+		since := time.Now().Add(-24 * time.Hour)
+		if stats, err := m.db.GetStatsForAllMonitors(since); err == nil {
+			m.stats = stats
+		}
+		for i := range monitors {
+			if p, err := m.db.GetResponseTimePercentiles(monitors[i].ID, since); err == nil {
+				m.percentiles[monitors[i].ID] = p
+			}
+			if report, err := m.db.GetCurrentSLAReport(&monitors[i]); err == nil {
+				m.slaReports[monitors[i].ID] = report
+			}
 		}
+		m.statsUpdated = time.Now()
 	}
+	m.ensureSelection()
 	m.lastUpdate = time.Now()
 }
 
+// visibleMonitors returns the monitors the filter lets through. The
+// selected monitor stays listed after its status changes so a refresh
+// never moves the cursor onto another monitor; it drops out once the
+// cursor leaves it.
+func (m DashboardModel) visibleMonitors() []storage.Monitor {
+	if m.filter == dashFilterAll {
+		return m.monitors
+	}
+	var visible []storage.Monitor
+	for _, mon := range m.monitors {
+		if statusBucket(mon) == m.filter || mon.ID == m.selectedID {
+			visible = append(visible, mon)
+		}
+	}
+	return visible
+}
+
+// setFilter switches the filter, keeping the selection if it matches.
+func (m *DashboardModel) setFilter(filter string) {
+	m.filter = filter
+	for _, mon := range m.monitors {
+		if mon.ID == m.selectedID && (filter == dashFilterAll || statusBucket(mon) == filter) {
+			return
+		}
+	}
+	m.selectedID = 0
+	m.ensureSelection()
+}
+
+// ensureSelection selects the first visible monitor when the selected one
+// has been removed or nothing is selected yet.
+func (m *DashboardModel) ensureSelection() {
+	visible := m.visibleMonitors()
+	for _, mon := range visible {
+		if mon.ID == m.selectedID {
+			return
+		}
+	}
+	m.selectedID = 0
+	if len(visible) > 0 {
+		m.selectedID = visible[0].ID
+	}
+}
+
+func (m *DashboardModel) moveSelection(delta int) {
+	visible := m.visibleMonitors()
+	for i, mon := range visible {
+		if mon.ID == m.selectedID {
+			if j := i + delta; j >= 0 && j < len(visible) {
+				m.selectedID = visible[j].ID
+			}
+			return
+		}
+	}
+}
+
+// appendNewResults merges check results saved since the last load into a
+// monitor's history, keeping the newest-first order of
+// GetRecentCheckResults and so the same results a full reload would give.
+// newestID is the monitor's highest result ID, or 0 if unknown.
+func (m *DashboardModel) appendNewResults(monitorID, newestID uint) {
+	existing := m.checkResults[monitorID]
+	fresh, err := m.db.GetCheckResultsAfter(monitorID, m.resultCursor[monitorID], dashHistory)
+	if err != nil || len(fresh) == 0 {
+		return
+	}
+
+	// A full window of new rows means we fell behind (e.g. after sleep),
+	// so the oldest-first page may not reach the newest checks.
+	if len(fresh) == dashHistory {
+		if results, err := m.db.GetRecentCheckResults(monitorID, dashHistory); err == nil {
+			m.checkResults[monitorID] = results
+			m.resultCursor[monitorID] = max(newestID, maxResultID(results), maxResultID(fresh))
+		}
+		return
+	}
+
+	// A result saved late from the checker's retry buffer is older than
+	// ones already held, so it is sorted in rather than put first.
+	merged := make([]storage.CheckResult, 0, len(fresh)+len(existing))
+	merged = append(merged, fresh...)
+	merged = append(merged, existing...)
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	if len(merged) > dashHistory {
+		merged = merged[:dashHistory]
+	}
+	m.checkResults[monitorID] = merged
+	m.resultCursor[monitorID] = max(m.resultCursor[monitorID], maxResultID(fresh))
+}
+
+// maxResultID returns the highest ID among results, or 0.
+func maxResultID(results []storage.CheckResult) uint {
+	var id uint
+	for _, r := range results {
+		id = max(id, r.ID)
+	}
+	return id
+}
+
 func (m DashboardModel) Init() tea.Cmd {
 	return tea.Batch(
-		dashTickCmd(),
+		dashTickCmd(m.opts.Refresh),
 	)
 }
 
-func dashTickCmd() tea.Cmd {
-	return tea.Tick(time.Second*2, func(t time.Time) tea.Msg {
+func dashTickCmd(every time.Duration) tea.Cmd {
+	return tea.Tick(every, func(t time.Time) tea.Msg {
 		return dashTickMsg(t)
 	})
 }
@@ -158,18 +379,39 @@ func dashTickCmd() tea.Cmd {
 func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+c", "q", "esc":
+		switch key := msg.String(); key {
+		case "esc":
+			if m.filter != dashFilterAll {
+				m.setFilter(dashFilterAll)
+				return m, nil
+			}
+			return m, tea.Quit
+		case "ctrl+c", "q":
 			return m, tea.Quit
 		case "j", "down":
-			if m.selectedIndex < len(m.monitors)-1 {
-				m.selectedIndex++
-			}
+			m.moveSelection(1)
 		case "k", "up":
-			if m.selectedIndex > 0 {
-				m.selectedIndex--
+			m.moveSelection(-1)
+		case "1", "2", "3", "4":
+			// Choosing the active card again clears the filter.
+			filter := dashFilters[key[0]-'0']
+			if filter == m.filter {
+				filter = dashFilterAll
+			}
+			m.setFilter(filter)
+		case "tab", "shift+tab":
+			step := 1
+			if key == "shift+tab" {
+				step = len(dashFilters) - 1
+			}
+			for i, f := range dashFilters {
+				if f == m.filter {
+					m.setFilter(dashFilters[(i+step)%len(dashFilters)])
+					break
+				}
 			}
 		case "r":
+			m.monitorSet = ""
 			m.loadData()
 		}
 
@@ -179,7 +421,7 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case dashTickMsg:
 		m.loadData()
-		return m, dashTickCmd()
+		return m, dashTickCmd(m.opts.Refresh)
 	}
 
 	return m, nil
@@ -195,36 +437,59 @@ func (m DashboardModel) View() string {
 	// Header with gradient-like effect
 	headerText := " 📊 STATPING DASHBOARD "
 	header := dHeaderStyle.Render(headerText)
-	statsText := dSubtitleStyle.Render(fmt.Sprintf("  %d monitors • Updated %s", len(m.monitors), m.lastUpdate.Format("15:04:05")))
+	visible := m.visibleMonitors()
+	count := fmt.Sprintf("%d monitors", len(m.monitors))
+	if m.filter != dashFilterAll {
+		count = fmt.Sprintf("%d of %d monitors • showing %s (esc to clear)", len(visible), len(m.monitors), strings.ToUpper(m.filter))
+	}
+	if m.opts.Scope != "" {
+		count += " • " + m.opts.Scope
+	}
+	statsText := dSubtitleStyle.Render(fmt.Sprintf("  %s • Updated %s", count, m.lastUpdate.Format("15:04:05")))
 	b.WriteString(header + statsText)
 	b.WriteString("\n\n")
+	if banner := stallBanner(m.activity); banner != "" {
+		b.WriteString("  " + banner + "\n\n")
+	}
 
 	if len(m.monitors) == 0 {
+		text := "  No monitors configured. Use 'statping add <url>' to add one."
+		if !m.opts.Filter.IsZero() {
+			text = "  No monitors match " + m.opts.Scope + "."
+		}
 		emptyMsg := lipgloss.NewStyle().
 			Foreground(dColorGray).
 			Italic(true).
-			Render("  No monitors configured. Use 'statping add <url>' to add one.")
+			Render(text)
 		b.WriteString(emptyMsg)
 		return b.String()
 	}
 
 	// Summary cards with better styling
-	upCount, downCount, unknownCount := m.countStatus()
-	summaryCards := m.renderSummaryCards(upCount, downCount, unknownCount)
+	upCount, downCount, unknownCount, pendingCount := m.countStatus()
+	summaryCards := m.renderSummaryCards(upCount, downCount, unknownCount, pendingCount)
 	b.WriteString(summaryCards)
 	b.WriteString("\n\n")
 
 	// Monitor cards with graphs
-	for i, mon := range m.monitors {
-		selected := i == m.selectedIndex
-		card := m.renderMonitorCard(mon, selected)
+	if len(visible) == 0 {
+		b.WriteString(lipgloss.NewStyle().
+			Foreground(dColorGray).
+			Italic(true).
+			Render(fmt.Sprintf("  No %s monitors.", m.filter)))
+		b.WriteString("\n\n")
+	}
+	for _, mon := range visible {
+		card := m.renderMonitorCard(mon, mon.ID == m.selectedID)
 		b.WriteString(card)
 		b.WriteString("\n")
 	}
 
 	// Help bar with styled keys
-	helpText := fmt.Sprintf("%s navigate • %s refresh • %s quit",
+	helpText := fmt.Sprintf("%s navigate • %s filter • %s clear filter • %s refresh • %s quit",
 		dHelpKeyStyle.Render("↑↓"),
+		dHelpKeyStyle.Render("1-4/tab"),
+		dHelpKeyStyle.Render("esc"),
 		dHelpKeyStyle.Render("r"),
 		dHelpKeyStyle.Render("q"))
 	b.WriteString(dHelpStyle.Render(helpText))
@@ -232,13 +497,15 @@ func (m DashboardModel) View() string {
 	return b.String()
 }
 
-func (m DashboardModel) countStatus() (up, down, unknown int) {
+func (m DashboardModel) countStatus() (up, down, unknown, pending int) {
 	for _, mon := range m.monitors {
-		switch mon.CurrentStatus {
-		case "up":
+		switch statusBucket(mon) {
+		case dashFilterUp:
 			up++
-		case "down":
+		case dashFilterDown:
 			down++
+		case dashFilterPending:
+			pending++
 		default:
 			unknown++
 		}
@@ -246,36 +513,64 @@ func (m DashboardModel) countStatus() (up, down, unknown int) {
 	return
 }
 
-func (m DashboardModel) renderSummaryCards(up, down, unknown int) string {
-	upCard := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(dColorGreen).
-		Padding(0, 3).
+func (m DashboardModel) renderSummaryCards(up, down, unknown, pending int) string {
+	// The card of the active filter gets a thick border.
+	filterCard := func(filter string, color lipgloss.Color) lipgloss.Style {
+		border := lipgloss.RoundedBorder()
+		if m.filter == filter {
+			border = lipgloss.ThickBorder()
+		}
+		return lipgloss.NewStyle().
+			Border(border).
+			BorderForeground(color).
+			Padding(0, 3)
+	}
+
+	upCard := filterCard(dashFilterUp, dColorGreen).
 		Render(fmt.Sprintf("%s\n%s",
 			dStatusUpStyle.Render(fmt.Sprintf("✓ %d UP", up)),
-			dMetricLabelStyle.Render("Healthy")))
+			dMetricLabelStyle.Render("Healthy [1]")))
 
-	downCard := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(dColorRed).
-		Padding(0, 3).
+	downCard := filterCard(dashFilterDown, dColorRed).
 		Render(fmt.Sprintf("%s\n%s",
 			dStatusDownStyle.Render(fmt.Sprintf("✗ %d DOWN", down)),
-			dMetricLabelStyle.Render("Issues")))
+			dMetricLabelStyle.Render("Issues [2]")))
 
-	unknownCard := lipgloss.NewStyle().
+	unknownCard := filterCard(dashFilterUnknown, dColorGray).
+		Render(fmt.Sprintf("%s\n%s",
+			dStatusUnknownStyle.Render(fmt.Sprintf("? %d UNKNOWN", unknown)),
+			dMetricLabelStyle.Render("No result [3]")))
+
+	pendingCard := filterCard(dashFilterPending, dColorGray).
+		Render(fmt.Sprintf("%s\n%s",
+			dStatusUnknownStyle.Render(fmt.Sprintf("◷ %d PENDING", pending)),
+			dMetricLabelStyle.Render("Not checked yet [4]")))
+
+	var checks, successes int64
+	var totalTime float64
+	for _, s := range m.stats {
+		checks += s.Checks
+		successes += s.Successes
+		totalTime += s.AvgResponseTime * float64(s.Successes)
+	}
+	avg := "n/a"
+	if successes > 0 {
+		avg = fmt.Sprintf("%.0fms", totalTime/float64(successes))
+	}
+	statsCard := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(dColorGray).
 		Padding(0, 3).
 		Render(fmt.Sprintf("%s\n%s",
-			dStatusUnknownStyle.Render(fmt.Sprintf("? %d UNKNOWN", unknown)),
-			dMetricLabelStyle.Render("Pending")))
+			dMonitorNameStyle.Render("⏱ "+avg+" avg"),
+			dMetricLabelStyle.Render(fmt.Sprintf("%d checks in 24h", checks))))
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, upCard, "  ", downCard, "  ", unknownCard)
+	return lipgloss.JoinHorizontal(lipgloss.Top, upCard, "  ", downCard, "  ", unknownCard, "  ", pendingCard, "  ", statsCard)
 }
 
 func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) string {
 	results := m.checkResults[mon.ID]
+	pct := m.percentiles[mon.ID]
 
 	// Calculate metrics
 	var avgResponseTime, minResponseTime, maxResponseTime int64
@@ -302,9 +597,12 @@ func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) st
 		}
 	}
 
+	// Time-weighted over the span of the loaded checks, which arrive newest
+	// first.
 	uptime := float64(0)
 	if len(results) > 0 {
-		uptime = float64(successCount) / float64(len(results)) * 100
+		oldest := results[len(results)-1].CreatedAt
+		uptime = storage.ComputeUptime(results, &mon, oldest, time.Now()).Percent()
 	}
 
 	// Build card content
@@ -313,13 +611,16 @@ func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) st
 	// Status indicator and name
 	var statusIcon string
 	var statusStyle lipgloss.Style
-	switch mon.CurrentStatus {
+	switch mon.DisplayStatus() {
 	case "up":
 		statusIcon = "●"
 		statusStyle = dStatusUpStyle
 	case "down":
 		statusIcon = "●"
 		statusStyle = dStatusDownStyle
+	case storage.StatusPending:
+		statusIcon = "◷"
+		statusStyle = dStatusUnknownStyle
 	default:
 		statusIcon = "○"
 		statusStyle = dStatusUnknownStyle
@@ -328,8 +629,8 @@ func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) st
 	// Header row with status, name, and URL
 	nameRow := fmt.Sprintf("%s %s  %s",
 		statusStyle.Render(statusIcon),
-		dMonitorNameStyle.Render(mon.Name),
-		dUrlStyle.Render(truncateURL(mon.URL, 45)))
+		dMonitorNameStyle.Render(textutil.Truncate(mon.Name, 30)),
+		dUrlStyle.Render(textutil.Truncate(mon.DisplayTarget(), 45)))
 	content.WriteString(nameRow)
 	content.WriteString("\n\n")
 
@@ -338,29 +639,55 @@ func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) st
 	content.WriteString("\n")
 
 	// Sparkline graph
-	graph := m.renderSparkline(results, 60)
+	limits := mon.LatencyThresholds()
+	graph := m.renderSparkline(results, 60, limits)
 	content.WriteString(graph)
 	content.WriteString("\n\n")
 
-	// Metrics row with better spacing
+	// Metrics row with better spacing. Without successful checks there
+	// is no response time to show, rather than 0ms.
+	ms := func(v int64, ok bool) string {
+		if !ok {
+			return "n/a"
+		}
+		return fmt.Sprintf("%dms", v)
+	}
+	hasRecent, hasDay := successCount > 0, pct.Count > 0
 	metricsRow := lipgloss.JoinHorizontal(lipgloss.Top,
 		m.renderMetric("Uptime", fmt.Sprintf("%.1f%%", uptime), uptime >= 99),
 		"    ",
-		m.renderMetric("Avg", fmt.Sprintf("%dms", avgResponseTime), avgResponseTime < 500),
+		m.renderMetric("Avg", ms(avgResponseTime, hasRecent), avgResponseTime < limits.Target),
 		"    ",
-		m.renderMetric("Min", fmt.Sprintf("%dms", minResponseTime), true),
+		m.renderMetric("Min", ms(minResponseTime, hasRecent), true),
 		"    ",
-		m.renderMetric("Max", fmt.Sprintf("%dms", maxResponseTime), maxResponseTime < 1000),
+		m.renderMetric("Max", ms(maxResponseTime, hasRecent), maxResponseTime < limits.Slow),
 		"    ",
-		m.renderMetric("Checks", fmt.Sprintf("%d", len(results)), true),
+		m.renderMetric("p95 24h", ms(pct.P95, hasDay), pct.P95 < limits.Slow),
+		"    ",
+		m.renderMetric("p99 24h", ms(pct.P99, hasDay), pct.P99 < limits.Tail),
+		"    ",
+		m.renderMetric("Checks 24h", fmt.Sprintf("%d", m.stats[mon.ID].Checks), true),
 	)
 	content.WriteString(metricsRow)
 
+	if report := m.slaReports[mon.ID]; report != nil {
+		content.WriteString("\n\n")
+		sla := fmt.Sprintf("SLA %.3f%% / %.3f%% • budget %s", report.Uptime, report.Target, report.BudgetSummary())
+		if report.Exhausted() {
+			content.WriteString(dMetricBadStyle.Render(sla))
+		} else {
+			content.WriteString(dMetricGoodStyle.Render(sla))
+		}
+	}
+
 	// Last check info
 	if mon.LastCheckAt != nil {
 		content.WriteString("\n\n")
 		lastCheck := fmt.Sprintf("Last check: %s ago", formatTimeAgo(*mon.LastCheckAt))
 		content.WriteString(dMetricLabelStyle.Render(lastCheck))
+	} else if mon.Enabled {
+		content.WriteString("\n\n")
+		content.WriteString(dMetricLabelStyle.Render("Waiting for the first check"))
 	}
 
 	// Card styling based on status and selection
@@ -384,7 +711,15 @@ func (m DashboardModel) renderMonitorCard(mon storage.Monitor, selected bool) st
 	return cardStyleFinal.Render(content.String())
 }
 
-func (m DashboardModel) renderSparkline(results []storage.CheckResult, width int) string {
+func (m DashboardModel) renderSparkline(results []storage.CheckResult, width int, limits storage.LatencyThresholds) string {
+	return Sparkline(results, width, limits)
+}
+
+// Sparkline renders the response times of results, newest first, as a
+// colored bar of at most width blocks followed by the scale, coloured
+// against limits. Failed checks are drawn in red and anomalous ones, far
+// slower than the monitor's baseline, in purple.
+func Sparkline(results []storage.CheckResult, width int, limits storage.LatencyThresholds) string {
 	if len(results) == 0 {
 		return dMetricLabelStyle.Render("No data yet")
 	}
@@ -435,9 +770,11 @@ func (m DashboardModel) renderSparkline(results []storage.CheckResult, width int
 
 		// Color based on response time
 		block := string(dSparkBlocks[blockIdx])
-		if r.ResponseTime < 200 {
+		if r.Anomalous {
+			spark.WriteString(dGraphPurpleStyle.Render(block))
+		} else if r.ResponseTime < limits.Fast {
 			spark.WriteString(dGraphGreenStyle.Render(block))
-		} else if r.ResponseTime < 500 {
+		} else if r.ResponseTime < limits.Target {
 			spark.WriteString(dGraphYellowStyle.Render(block))
 		} else {
 			spark.WriteString(dGraphOrangeStyle.Render(block))
@@ -461,13 +798,6 @@ func (m DashboardModel) renderMetric(label, value string, good bool) string {
 		dMetricLabelStyle.Render(label))
 }
 
-func truncateURL(url string, maxLen int) string {
-	if len(url) <= maxLen {
-		return url
-	}
-	return url[:maxLen-3] + "..."
-}
-
 func formatTimeAgo(t time.Time) string {
 	d := time.Since(t)
 	if d < time.Minute {
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+func TestIncrementalResultsMatchReload(t *testing.T) {
+	db, err := storage.New(storage.DriverSQLite, ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mon := &storage.Monitor{Name: "api", Type: storage.MonitorTypeHTTP, URL: "https://api.example.com", Enabled: true, CheckInterval: 60}
+	if err := db.CreateMonitor(mon); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	checked := 0
+	// record saves a check made n intervals after start.
+	record := func(n int) {
+		t.Helper()
+		cr := &storage.CheckResult{
+			MonitorID:    mon.ID,
+			CreatedAt:    start.Add(time.Duration(n) * time.Minute),
+			Success:      n%7 != 0,
+			ResponseTime: int64(50 + n%13*10),
+		}
+		if err := db.CreateCheckResult(cr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(count int) {
+		for range count {
+			checked++
+			record(checked)
+		}
+	}
+
+	check(dashHistory + 10)
+	m := NewDashboard(db, DashboardOptions{})
+
+	// compare checks the dashboard's history against a full reload, and
+	// the sparkline drawn from each.
+	compare := func(step string) {
+		t.Helper()
+		want, err := db.GetRecentCheckResults(mon.ID, dashHistory)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := m.checkResults[mon.ID]
+		if !reflect.DeepEqual(resultIDs(got), resultIDs(want)) {
+			t.Fatalf("%s: history %v, full reload %v", step, resultIDs(got), resultIDs(want))
+		}
+		if g, w := Sparkline(got, dashHistory, storage.LatencyThresholds{}), Sparkline(want, dashHistory, storage.LatencyThresholds{}); g != w {
+			t.Errorf("%s: sparkline %q, full reload %q", step, g, w)
+		}
+	}
+	compare("first load")
+
+	for _, tc := range []struct {
+		step  string
+		write func()
+	}{
+		{"nothing new", func() {}},
+		{"one check", func() { check(1) }},
+		{"a few checks", func() { check(5) }},
+		// The checker's retry buffer saves a result after newer ones.
+		{"late result", func() {
+			check(2)
+			record(checked - 5)
+			check(1)
+		}},
+		{"late result older than the window", func() { record(checked - 2*dashHistory) }},
+		{"late result alone", func() { record(checked - 1) }},
+		{"fell behind", func() { check(dashHistory + 5) }},
+		{"after falling behind", func() { check(3) }},
+	} {
+		tc.write()
+		m.loadData()
+		compare(tc.step)
+	}
+}
+
+func resultIDs(results []storage.CheckResult) []uint {
+	ids := make([]uint, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
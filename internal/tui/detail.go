@@ -15,6 +15,17 @@ type detailModel struct {
 	monitor      *storage.Monitor
 	checkResults []storage.CheckResult
 	incidents    []storage.Incident
+	changes      []storage.ContentChange
+
+	// selectedCheck is the ID of the check picked with up/down, whose
+	// response is shown if it failed. Zero selects nothing.
+	selectedCheck uint
+
+	// pane shows every detail of a check opened with enter. It is nil
+	// while the detail view itself is shown.
+	pane *checkPane
+
+	width, height int
 }
 
 func newDetailModel(db *storage.Database) detailModel {
@@ -25,9 +36,18 @@ func newDetailModel(db *storage.Database) detailModel {
 
 func (m *detailModel) setMonitor(monitor *storage.Monitor) {
 	m.monitor = monitor
+	m.selectedCheck = 0
+	m.pane = nil
 	m.refresh()
 }
 
+func (m *detailModel) setSize(width, height int) {
+	m.width, m.height = width, height
+	if m.pane != nil {
+		m.pane.setSize(width, height)
+	}
+}
+
 func (m *detailModel) refresh() {
 	if m.monitor == nil {
 		return
@@ -47,9 +67,28 @@ func (m *detailModel) refresh() {
 	if err == nil {
 		m.incidents = incidents
 	}
+
+	m.changes = nil
+	if m.monitor.WatchContent {
+		changes, err := m.db.GetContentChanges(m.monitor.ID, 5)
+		if err == nil {
+			m.changes = changes
+		}
+	}
 }
 
 func (m detailModel) Update(msg tea.Msg) (detailModel, tea.Cmd) {
+	if m.pane != nil {
+		if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+			m.pane = nil
+			return m, nil
+		}
+		pane := *m.pane
+		cmd := pane.update(msg)
+		m.pane = &pane
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -57,15 +96,50 @@ func (m detailModel) Update(msg tea.Msg) (detailModel, tea.Cmd) {
 			return m, backToList()
 		case "e":
 			return m, editMonitor(m.monitor)
+		case "down", "j":
+			m.moveSelection(1)
+		case "up", "k":
+			m.moveSelection(-1)
+		case "enter":
+			if i := m.selectedIndex(); i >= 0 {
+				m.pane = newCheckPane(m.checkResults[i], m.width, m.height)
+			}
 		}
 	}
 	return m, nil
 }
 
+// moveSelection moves the check selection by delta within the recent
+// checks, starting from the newest.
+func (m *detailModel) moveSelection(delta int) {
+	if len(m.checkResults) == 0 {
+		return
+	}
+	i := m.selectedIndex()
+	if i < 0 {
+		i = 0
+	} else {
+		i = max(0, min(len(m.checkResults)-1, i+delta))
+	}
+	m.selectedCheck = m.checkResults[i].ID
+}
+
+func (m detailModel) selectedIndex() int {
+	for i, cr := range m.checkResults {
+		if m.selectedCheck != 0 && cr.ID == m.selectedCheck {
+			return i
+		}
+	}
+	return -1
+}
+
 func (m detailModel) View() string {
 	if m.monitor == nil {
 		return "No monitor selected"
 	}
+	if m.pane != nil {
+		return m.pane.view()
+	}
 
 	var b strings.Builder
 
@@ -73,12 +147,69 @@ func (m detailModel) View() string {
 	b.WriteString("\n\n")
 
 	infoStyle := lipgloss.NewStyle().Bold(true)
-	b.WriteString(infoStyle.Render("URL: "))
-	b.WriteString(m.monitor.URL)
-	b.WriteString("\n")
+	if m.monitor.IsHeartbeat() {
+		b.WriteString(infoStyle.Render("Ping Path: "))
+		b.WriteString("/api/heartbeat/" + m.monitor.HeartbeatToken)
+		b.WriteString("\n")
+
+		b.WriteString(infoStyle.Render("Last Ping: "))
+		if m.monitor.LastPingAt != nil {
+			b.WriteString(m.monitor.LastPingAt.Format("2006-01-02 15:04:05"))
+		} else {
+			b.WriteString("never")
+		}
+		b.WriteString("\n")
+	} else if m.monitor.IsBanner() {
+		b.WriteString(infoStyle.Render("Target: "))
+		b.WriteString(m.monitor.URL)
+		b.WriteString("\n")
+
+		b.WriteString(infoStyle.Render("Expected Banner: "))
+		if m.monitor.ExpectedBanner != "" {
+			b.WriteString(m.monitor.ExpectedBanner)
+		} else {
+			b.WriteString("any")
+		}
+		b.WriteString("\n")
+	} else if m.monitor.IsTCP() {
+		b.WriteString(infoStyle.Render("Target: "))
+		b.WriteString(m.monitor.URL)
+		b.WriteString("\n")
+
+		if len(m.checkResults) > 0 && m.checkResults[0].PortResults != "" {
+			b.WriteString(infoStyle.Render("Ports (last check):"))
+			b.WriteString("\n")
+			for _, line := range strings.Split(m.checkResults[0].PortResults, "\n") {
+				b.WriteString("  " + line + "\n")
+			}
+		}
+	} else {
+		b.WriteString(infoStyle.Render("URL: "))
+		b.WriteString(m.monitor.URL)
+		b.WriteString("\n")
+
+		if m.monitor.SkipTLSVerify {
+			warnStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196"))
+			b.WriteString(warnStyle.Render("⚠ TLS certificate verification is disabled"))
+			b.WriteString("\n")
+		}
+		if m.monitor.HTTPVersion != storage.HTTPVersionAuto || m.monitor.AddressFamily != storage.AddressFamilyAny || m.monitor.RequiredProto != "" || m.monitor.DisableKeepAlive || m.monitor.Proxy != "" {
+			b.WriteString(infoStyle.Render("Connection: "))
+			b.WriteString(connectionSummary(m.monitor))
+			b.WriteString("\n")
+		}
+		if m.monitor.HasOAuth2() {
+			b.WriteString(infoStyle.Render("OAuth2: "))
+			b.WriteString("client " + m.monitor.OAuth2ClientID + " via " + m.monitor.OAuth2TokenURL)
+			if m.monitor.OAuth2Scopes != "" {
+				b.WriteString(" (" + m.monitor.OAuth2Scopes + ")")
+			}
+			b.WriteString("\n")
+		}
+	}
 
 	b.WriteString(infoStyle.Render("Status: "))
-	status := m.formatStatus(m.monitor.CurrentStatus)
+	status := m.formatStatus(m.monitor.DisplayStatus())
 	b.WriteString(status)
 	b.WriteString("\n")
 
@@ -86,8 +217,23 @@ func (m detailModel) View() string {
 	b.WriteString(fmt.Sprintf("%d seconds", m.monitor.CheckInterval))
 	b.WriteString("\n")
 
+	if sched := m.monitor.ActiveSchedule(); sched != nil {
+		b.WriteString(infoStyle.Render("Active Hours: "))
+		b.WriteString(sched.String())
+		b.WriteString("\n")
+	}
+
+	if rule := m.monitor.LatencyRule(); rule != "" {
+		b.WriteString(infoStyle.Render("Latency Alert: "))
+		b.WriteString(rule)
+		b.WriteString("\n")
+	}
+
 	b.WriteString(infoStyle.Render("Timeout: "))
 	b.WriteString(fmt.Sprintf("%d seconds", m.monitor.Timeout))
+	if m.monitor.ConnectTimeout > 0 {
+		b.WriteString(fmt.Sprintf(" (connect %d)", m.monitor.ConnectTimeout))
+	}
 	b.WriteString("\n")
 
 	b.WriteString(infoStyle.Render("Expected Codes: "))
@@ -100,6 +246,34 @@ func (m detailModel) View() string {
 		b.WriteString("\n")
 	}
 
+	if m.monitor.TargetLatencyMs > 0 {
+		b.WriteString(infoStyle.Render("Target Latency: "))
+		b.WriteString(fmt.Sprintf("%dms", m.monitor.TargetLatencyMs))
+		b.WriteString("\n")
+	}
+
+	if m.monitor.NotificationCooldown != 0 {
+		b.WriteString(infoStyle.Render("Notification Cooldown: "))
+		if cooldown := m.monitor.Cooldown(); cooldown > 0 {
+			b.WriteString(cooldown.String())
+		} else {
+			b.WriteString("none")
+		}
+		b.WriteString("\n")
+	}
+
+	if m.monitor.MinBodyBytes > 0 {
+		b.WriteString(infoStyle.Render("Minimum Response Size: "))
+		b.WriteString(fmt.Sprintf("%d bytes", m.monitor.MinBodyBytes))
+		b.WriteString("\n")
+	}
+
+	if m.monitor.ExpectedSHA256 != "" {
+		b.WriteString(infoStyle.Render("Expected Body SHA-256: "))
+		b.WriteString(m.monitor.ExpectedSHA256)
+		b.WriteString("\n")
+	}
+
 	b.WriteString(infoStyle.Render("Enabled: "))
 	if m.monitor.Enabled {
 		b.WriteString("Yes")
@@ -119,35 +293,124 @@ func (m detailModel) View() string {
 	b.WriteString("\n")
 
 	since := time.Now().Add(-24 * time.Hour)
-	total, successful, avgResponseTime, err := m.db.GetCheckResultStats(m.monitor.ID, since)
-	if err == nil && total > 0 {
-		uptime := float64(successful) / float64(total) * 100
-		b.WriteString(fmt.Sprintf("Uptime: %.2f%% (%d/%d checks)\n", uptime, successful, total))
-		b.WriteString(fmt.Sprintf("Avg Response Time: %.0fms\n", avgResponseTime))
+	stats, err := m.db.GetCheckResultStats(m.monitor.ID, since)
+	if err == nil && stats.Total > 0 {
+		uptime := stats.Uptime
+		b.WriteString(fmt.Sprintf("Uptime: %.2f%% time-weighted", uptime.Percent()))
+		if uptime.Unknown >= time.Minute {
+			b.WriteString(fmt.Sprintf(", %s unknown", uptime.Unknown.Round(time.Minute)))
+		}
+		if uptime.OutOfSchedule >= time.Minute {
+			b.WriteString(fmt.Sprintf(", %s out of schedule", uptime.OutOfSchedule.Round(time.Minute)))
+		}
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Check Ratio: %.2f%% (%d/%d checks)\n", uptime.CheckRatio(), stats.Successful, stats.Total))
+		if !stats.HasResponseTime() {
+			b.WriteString("Avg Response Time: n/a (no successful checks)\n")
+		} else {
+			b.WriteString(fmt.Sprintf("Avg Response Time: %.0fms\n", stats.AvgResponseTime))
+			if p, err := m.db.GetResponseTimePercentiles(m.monitor.ID, since); err == nil {
+				b.WriteString(fmt.Sprintf("Response Time p50/p95/p99: %dms / %dms / %dms\n", p.P50, p.P95, p.P99))
+			}
+		}
 	} else {
 		b.WriteString("No data available\n")
 	}
 
+	if inc, err := m.db.GetIncidentStats(m.monitor.ID, since); err == nil && inc.Count > 0 {
+		b.WriteString(fmt.Sprintf("Incidents: %d, %s down, longest %s\n",
+			inc.Count, inc.Downtime.Round(time.Second), inc.Longest.Round(time.Second)))
+		mttr := "n/a"
+		if inc.MTTR > 0 {
+			mttr = inc.MTTR.Round(time.Second).String()
+		}
+		b.WriteString(fmt.Sprintf("MTTR: %s • MTBF: %s\n", mttr, inc.MTBF.Round(time.Minute)))
+	}
+
+	if reasons, err := m.db.GetFailureReasons(m.monitor.ID, since, 3); err == nil && len(reasons) > 0 {
+		b.WriteString("Failures:")
+		for i, r := range reasons {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString(fmt.Sprintf(" %.0f%% %s (%d)", r.Percent, r.Reason, r.Count))
+		}
+		b.WriteString("\n")
+	}
+
+	now := time.Now()
+	for _, period := range []struct {
+		label string
+		span  time.Duration
+	}{{"24h", 24 * time.Hour}, {"7d", 7 * 24 * time.Hour}} {
+		from := now.Add(-period.span)
+		if spans, err := m.db.GetStatusTimeline(m.monitor.ID, from); err == nil {
+			b.WriteString(statusTimelineView(period.label, spans, from, now))
+		}
+	}
+
+	if days, err := m.db.GetDailyUptime(m.monitor.ID, dailyUptimeDays); err == nil {
+		b.WriteString(dailyUptimeView(days))
+	}
+
+	if report, err := m.db.GetCurrentSLAReport(m.monitor); err == nil && report != nil {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render(fmt.Sprintf("SLA (%s)", report.PeriodStart.Format("January"))))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Uptime: %.3f%% (target %.3f%%)\n", report.Uptime, report.Target))
+		budget := "Error Budget: " + report.BudgetSummary()
+		if report.Exhausted() {
+			b.WriteString(statusDownStyle.Render(budget))
+		} else {
+			b.WriteString(statusUpStyle.Render(budget))
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString("\n")
 	b.WriteString(titleStyle.Render("Recent Checks"))
 	b.WriteString("\n")
 
 	if len(m.checkResults) > 0 {
-		for _, cr := range m.checkResults {
+		selected := m.selectedIndex()
+		for i, cr := range m.checkResults {
 			statusIcon := "✓"
 			if !cr.Success {
 				statusIcon = "✗"
 			}
+			cursor := "  "
+			if i == selected {
+				cursor = "› "
+			}
 			timeStr := cr.CreatedAt.Format("15:04:05")
-			b.WriteString(fmt.Sprintf("%s %s - ", statusIcon, timeStr))
+			b.WriteString(fmt.Sprintf("%s%s %s - ", cursor, statusIcon, timeStr))
 
 			if cr.Success {
 				b.WriteString(fmt.Sprintf("HTTP %d (%dms)", cr.StatusCode, cr.ResponseTime))
+				if families := familyTimes(cr); families != "" {
+					b.WriteString(" " + families)
+				}
+				if cr.Anomalous {
+					b.WriteString(" " + lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Render("anomalous"))
+				}
 			} else {
 				b.WriteString(fmt.Sprintf("Failed: %s", cr.ErrorMessage))
 			}
+			if cr.ResolvedIP != "" {
+				b.WriteString(" [" + cr.ResolvedIP)
+				if cr.Protocol != "" {
+					b.WriteString(", " + cr.Protocol)
+				}
+				if cr.TLSVersion != "" {
+					b.WriteString(", " + cr.TLSVersion)
+				}
+				b.WriteString("]")
+			}
 			b.WriteString("\n")
 		}
+		if selected >= 0 && !m.checkResults[selected].Success {
+			b.WriteString(responseView(m.checkResults[selected]))
+		}
 	} else {
 		b.WriteString("No check results yet\n")
 	}
@@ -157,23 +420,50 @@ func (m detailModel) View() string {
 		b.WriteString(titleStyle.Render("Recent Incidents"))
 		b.WriteString("\n")
 
+		slowStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+		downStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196"))
 		for _, inc := range m.incidents {
-			b.WriteString(fmt.Sprintf("Started: %s\n", inc.StartedAt.Format("2006-01-02 15:04:05")))
+			if inc.IsPerformance() {
+				b.WriteString(slowStyle.Render("⏱ Slow"))
+			} else {
+				b.WriteString(downStyle.Render("✗ Down"))
+			}
+			b.WriteString(fmt.Sprintf("  Started: %s\n", inc.StartedAt.Format("2006-01-02 15:04:05")))
 			if inc.ResolvedAt != nil {
 				duration := inc.ResolvedAt.Sub(inc.StartedAt)
 				b.WriteString(fmt.Sprintf("Resolved: %s (Duration: %s)\n",
 					inc.ResolvedAt.Format("2006-01-02 15:04:05"),
 					formatDuration(duration)))
+				if inc.Resolution != "" {
+					b.WriteString(fmt.Sprintf("Note: %s\n", inc.Resolution))
+				}
 			} else {
 				duration := time.Since(inc.StartedAt)
 				b.WriteString(fmt.Sprintf("Status: ONGOING (Duration: %s)\n", formatDuration(duration)))
 			}
-			b.WriteString(fmt.Sprintf("Error: %s\n\n", inc.ErrorMessage))
+			label := "Error"
+			if inc.IsPerformance() {
+				label = "Latency"
+			}
+			b.WriteString(fmt.Sprintf("%s: %s\n\n", label, inc.ErrorMessage))
+		}
+	}
+
+	if m.monitor.WatchContent {
+		b.WriteString("\n")
+		b.WriteString(titleStyle.Render("Content Changes"))
+		b.WriteString("\n")
+
+		if len(m.changes) == 0 {
+			b.WriteString("No changes detected yet\n")
+		}
+		for _, ch := range m.changes {
+			b.WriteString(fmt.Sprintf("%s  %s\n", ch.CreatedAt.Format("2006-01-02 15:04:05"), ch.Summary))
 		}
 	}
 
 	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
-		"e: edit • esc/q: back to list",
+		"↑/↓: select check • enter: check details • e: edit • esc/q: back to list",
 	)
 	b.WriteString("\n")
 	b.WriteString(help)
@@ -187,11 +477,55 @@ func (m detailModel) formatStatus(status string) string {
 		return statusUpStyle.Render("✓ UP")
 	case "down":
 		return statusDownStyle.Render("✗ DOWN")
+	case storage.StatusOutOfSchedule:
+		return statusUnknownStyle.Render("◌ OUT OF SCHEDULE")
+	case storage.StatusPending:
+		return statusUnknownStyle.Render("◷ PENDING FIRST CHECK")
 	default:
 		return statusUnknownStyle.Render("? UNKNOWN")
 	}
 }
 
+// responseView shows the headers and body snippet stored for a failed
+// check.
+func responseView(cr storage.CheckResult) string {
+	const maxLines, maxWidth = 12, 100
+
+	if cr.ResponseSnippet == "" && cr.ResponseHeaders == "" {
+		return "  No response body or headers were recorded\n"
+	}
+
+	var b strings.Builder
+	infoStyle := lipgloss.NewStyle().Bold(true)
+	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	if cr.ResponseHeaders != "" {
+		b.WriteString(infoStyle.Render("  Response headers:") + "\n")
+		for _, line := range strings.Split(cr.ResponseHeaders, "\n") {
+			b.WriteString("    " + dim.Render(truncate(line, maxWidth)) + "\n")
+		}
+	}
+	if cr.ResponseSnippet != "" {
+		b.WriteString(infoStyle.Render("  Response body:") + "\n")
+		lines := strings.Split(cr.ResponseSnippet, "\n")
+		for i, line := range lines {
+			if i == maxLines {
+				b.WriteString("    " + dim.Render(fmt.Sprintf("… %d more lines", len(lines)-maxLines)) + "\n")
+				break
+			}
+			b.WriteString("    " + dim.Render(truncate(strings.TrimRight(line, "\r\t "), maxWidth)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:width-1]) + "…"
+}
+
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%.0fs", d.Seconds())
@@ -204,3 +538,124 @@ func formatDuration(d time.Duration) string {
 	}
 	return fmt.Sprintf("%.1fd", d.Hours()/24)
 }
+
+// familyTimes lists a dual-stack check's per-family response times, or
+// returns "" for other checks.
+func familyTimes(cr storage.CheckResult) string {
+	if cr.IPv4Time == 0 && cr.IPv6Time == 0 {
+		return ""
+	}
+	return "IPv4 " + familyTime(cr.IPv4Time) + ", IPv6 " + familyTime(cr.IPv6Time)
+}
+
+func connectionSummary(mon *storage.Monitor) string {
+	var parts []string
+	if mon.HTTPVersion != storage.HTTPVersionAuto {
+		parts = append(parts, "HTTP/"+mon.HTTPVersion+" only")
+	}
+	if mon.RequiredProto != "" {
+		parts = append(parts, "requires "+mon.RequiredProto)
+	}
+	switch mon.AddressFamily {
+	case storage.AddressFamilyIPv4:
+		parts = append(parts, "IPv4 only")
+	case storage.AddressFamilyIPv6:
+		parts = append(parts, "IPv6 only")
+	case storage.AddressFamilyBoth:
+		parts = append(parts, "checks IPv4 and IPv6")
+	}
+	if mon.DisableKeepAlive {
+		parts = append(parts, "new connection per check")
+	}
+	if mon.Proxy != "" {
+		parts = append(parts, "proxy "+storage.RedactProxy(mon.Proxy))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// statusTimelineWidth is how many blocks the status timeline bars in the
+// detail view are drawn with.
+const statusTimelineWidth = 48
+
+// statusTimelineView renders the monitor's status over [since, until) as a
+// bar of blocks colored by status, followed by how many times it changed.
+func statusTimelineView(label string, spans []storage.StatusSpan, since, until time.Time) string {
+	if len(spans) == 0 {
+		return ""
+	}
+	styles := map[string]lipgloss.Style{
+		"up":                        lipgloss.NewStyle().Foreground(lipgloss.Color("42")),
+		"down":                      lipgloss.NewStyle().Foreground(lipgloss.Color("196")),
+		storage.StatusPending:       lipgloss.NewStyle().Foreground(lipgloss.Color("214")),
+		storage.StatusOutOfSchedule: lipgloss.NewStyle().Foreground(lipgloss.Color("63")),
+	}
+	noneStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	var bar strings.Builder
+	for _, status := range storage.TimelineBuckets(spans, since, until, statusTimelineWidth) {
+		style, ok := styles[status]
+		switch {
+		case status == "":
+			bar.WriteString(noneStyle.Render("·"))
+		case !ok:
+			bar.WriteString(noneStyle.Render("█"))
+		default:
+			bar.WriteString(style.Render("█"))
+		}
+	}
+
+	changes := "no changes"
+	if n := len(spans) - 1; n == 1 {
+		changes = "1 change"
+	} else if n > 1 {
+		changes = fmt.Sprintf("%d changes", n)
+	}
+	return fmt.Sprintf("Status (%s): %s %s\n", label, bar.String(), changes)
+}
+
+// dailyUptimeDays is how many days the daily uptime bar in the detail view
+// covers.
+const dailyUptimeDays = 30
+
+// dailyUptimeView renders one block per day, colored like the status page
+// bars, followed by the overall uptime and the worst day.
+func dailyUptimeView(days []storage.DailyUptime) string {
+	upStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	partialStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	downStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	noneStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	var bar strings.Builder
+	var checks, failures int64
+	var worst *storage.DailyUptime
+	for i, day := range days {
+		switch {
+		case day.Checks == 0:
+			bar.WriteString(noneStyle.Render("·"))
+			continue
+		case day.Failures == 0:
+			bar.WriteString(upStyle.Render("█"))
+		case day.Uptime >= 95:
+			bar.WriteString(partialStyle.Render("█"))
+		default:
+			bar.WriteString(downStyle.Render("█"))
+		}
+		checks += day.Checks
+		failures += day.Failures
+		if worst == nil || day.Uptime < worst.Uptime {
+			worst = &days[i]
+		}
+	}
+	if checks == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Daily (%dd): %s %.2f%%\n", len(days), bar.String(),
+		float64(checks-failures)/float64(checks)*100))
+	if worst != nil && worst.Failures > 0 {
+		b.WriteString(fmt.Sprintf("Worst Day: %s, %.2f%% uptime, %.0fmin in incidents, avg %.0fms\n",
+			worst.Date.Format("Jan 2"), worst.Uptime, worst.IncidentMinutes, worst.AvgResponseTime))
+	}
+	return b.String()
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ankityadav/statping/internal/checker"
 	"github.com/ankityadav/statping/internal/config"
 	"github.com/ankityadav/statping/internal/storage"
 	"github.com/charmbracelet/bubbles/textinput"
@@ -14,11 +15,29 @@ import (
 
 type formModel struct {
 	db         *storage.Database
+	tester     *checker.Checker
 	monitor    *storage.Monitor
+	template   *storage.Monitor
 	inputs     []textinput.Model
 	focusIndex int
 	isEdit     bool
 	err        error
+
+	// pending is the validated monitor waiting on its test check, and
+	// testResult the check's outcome once it failed.
+	pending    *storage.Monitor
+	testing    bool
+	testResult *checker.TestResult
+
+	// duplicate is the monitor whose URL normalizes to the one entered,
+	// until the user edits it instead or sets allowDuplicate to add anyway.
+	duplicate      *storage.Monitor
+	allowDuplicate bool
+}
+
+// formTestMsg carries the result of the test check run before saving.
+type formTestMsg struct {
+	result checker.TestResult
 }
 
 const (
@@ -28,10 +47,25 @@ const (
 	inputTimeout
 	inputExpectedCodes
 	inputKeywords
+	inputMinBody
+	inputTargetLatency
+	inputCooldown
+	inputTags
+	inputUserAgent
+	inputCheckHeader
+	inputWatchContent
+	inputBackoff
+	inputHTTPVersion
+	inputAddressFamily
+	inputRequiredProto
+	inputNoKeepAlive
+	inputSkipTLSVerify
+	inputProxy
+	inputActiveHours
 )
 
 func newFormModel(db *storage.Database) formModel {
-	inputs := make([]textinput.Model, 6)
+	inputs := make([]textinput.Model, 21)
 
 	inputs[inputName] = textinput.New()
 	inputs[inputName].Placeholder = "My Website"
@@ -64,17 +98,95 @@ func newFormModel(db *storage.Database) formModel {
 	inputs[inputKeywords].CharLimit = 200
 	inputs[inputKeywords].Width = 50
 
+	inputs[inputMinBody] = textinput.New()
+	inputs[inputMinBody].Placeholder = "1024 (optional)"
+	inputs[inputMinBody].CharLimit = 9
+	inputs[inputMinBody].Width = 20
+
+	inputs[inputTargetLatency] = textinput.New()
+	inputs[inputTargetLatency].Placeholder = fmt.Sprintf("%d (optional)", storage.DefaultTargetLatency)
+	inputs[inputTargetLatency].CharLimit = 6
+	inputs[inputTargetLatency].Width = 20
+
+	inputs[inputCooldown] = textinput.New()
+	inputs[inputCooldown].Placeholder = fmt.Sprintf("%d (optional, -1 = none)", config.NotificationCooldown)
+	inputs[inputCooldown].CharLimit = 7
+	inputs[inputCooldown].Width = 30
+
+	inputs[inputTags] = textinput.New()
+	inputs[inputTags].Placeholder = "prod,api (comma-separated, optional)"
+	inputs[inputTags].CharLimit = 200
+	inputs[inputTags].Width = 50
+
+	inputs[inputUserAgent] = textinput.New()
+	inputs[inputUserAgent].Placeholder = config.DefaultUserAgent + " (optional)"
+	inputs[inputUserAgent].CharLimit = 200
+	inputs[inputUserAgent].Width = 50
+
+	inputs[inputCheckHeader] = textinput.New()
+	inputs[inputCheckHeader].Placeholder = "y/n"
+	inputs[inputCheckHeader].CharLimit = 3
+	inputs[inputCheckHeader].Width = 20
+
+	inputs[inputWatchContent] = textinput.New()
+	inputs[inputWatchContent].Placeholder = "y/n"
+	inputs[inputWatchContent].CharLimit = 3
+	inputs[inputWatchContent].Width = 20
+
+	inputs[inputBackoff] = textinput.New()
+	inputs[inputBackoff].Placeholder = "y/n"
+	inputs[inputBackoff].CharLimit = 3
+	inputs[inputBackoff].Width = 20
+
+	inputs[inputHTTPVersion] = textinput.New()
+	inputs[inputHTTPVersion].Placeholder = "auto, 1.1 or 2"
+	inputs[inputHTTPVersion].CharLimit = 8
+	inputs[inputHTTPVersion].Width = 20
+
+	inputs[inputAddressFamily] = textinput.New()
+	inputs[inputAddressFamily].Placeholder = "any, ipv4, ipv6 or both"
+	inputs[inputAddressFamily].CharLimit = 10
+	inputs[inputAddressFamily].Width = 20
+
+	inputs[inputRequiredProto] = textinput.New()
+	inputs[inputRequiredProto].Placeholder = "HTTP/2.0 (optional)"
+	inputs[inputRequiredProto].CharLimit = 10
+	inputs[inputRequiredProto].Width = 20
+
+	inputs[inputNoKeepAlive] = textinput.New()
+	inputs[inputNoKeepAlive].Placeholder = "y/n"
+	inputs[inputNoKeepAlive].CharLimit = 3
+	inputs[inputNoKeepAlive].Width = 20
+
+	inputs[inputSkipTLSVerify] = textinput.New()
+	inputs[inputSkipTLSVerify].Placeholder = "y/n"
+	inputs[inputSkipTLSVerify].CharLimit = 3
+	inputs[inputSkipTLSVerify].Width = 20
+
+	inputs[inputProxy] = textinput.New()
+	inputs[inputProxy].Placeholder = "socks5://host:1080 or direct (optional)"
+	inputs[inputProxy].CharLimit = 200
+	inputs[inputProxy].Width = 50
+
+	inputs[inputActiveHours] = textinput.New()
+	inputs[inputActiveHours].Placeholder = "mon-fri 09:00-18:00 Europe/Berlin (optional)"
+	inputs[inputActiveHours].CharLimit = 100
+	inputs[inputActiveHours].Width = 50
+
 	return formModel{
 		db:     db,
+		tester: checker.New(db, nil),
 		inputs: inputs,
 	}
 }
 
 func (m *formModel) reset() {
 	m.monitor = nil
+	m.template = nil
 	m.isEdit = false
 	m.focusIndex = 0
 	m.err = nil
+	m.clearTest()
 
 	m.inputs[inputName].SetValue("")
 	m.inputs[inputURL].SetValue("")
@@ -82,6 +194,21 @@ func (m *formModel) reset() {
 	m.inputs[inputTimeout].SetValue(fmt.Sprintf("%d", config.DefaultTimeout))
 	m.inputs[inputExpectedCodes].SetValue("200")
 	m.inputs[inputKeywords].SetValue("")
+	m.inputs[inputMinBody].SetValue("")
+	m.inputs[inputTargetLatency].SetValue("")
+	m.inputs[inputCooldown].SetValue("")
+	m.inputs[inputTags].SetValue("")
+	m.inputs[inputUserAgent].SetValue("")
+	m.inputs[inputCheckHeader].SetValue("n")
+	m.inputs[inputWatchContent].SetValue("n")
+	m.inputs[inputBackoff].SetValue("n")
+	m.inputs[inputHTTPVersion].SetValue("auto")
+	m.inputs[inputAddressFamily].SetValue("any")
+	m.inputs[inputRequiredProto].SetValue("")
+	m.inputs[inputNoKeepAlive].SetValue("n")
+	m.inputs[inputSkipTLSVerify].SetValue("n")
+	m.inputs[inputProxy].SetValue("")
+	m.inputs[inputActiveHours].SetValue("")
 
 	m.inputs[inputName].Focus()
 	for i := 1; i < len(m.inputs); i++ {
@@ -91,9 +218,30 @@ func (m *formModel) reset() {
 
 func (m *formModel) setMonitor(monitor *storage.Monitor) {
 	m.monitor = monitor
+	m.template = nil
 	m.isEdit = true
+	m.fill(monitor)
+}
+
+// setClone opens the add form pre-filled from monitor. Settings the form
+// doesn't show are carried over from the clone as well.
+func (m *formModel) setClone(monitor *storage.Monitor) error {
+	clone, err := monitor.Clone()
+	if err != nil {
+		return err
+	}
+	clone.Name += " (copy)"
+	m.monitor = nil
+	m.template = clone
+	m.isEdit = false
+	m.fill(clone)
+	return nil
+}
+
+func (m *formModel) fill(monitor *storage.Monitor) {
 	m.focusIndex = 0
 	m.err = nil
+	m.clearTest()
 
 	m.inputs[inputName].SetValue(monitor.Name)
 	m.inputs[inputURL].SetValue(monitor.URL)
@@ -101,6 +249,21 @@ func (m *formModel) setMonitor(monitor *storage.Monitor) {
 	m.inputs[inputTimeout].SetValue(fmt.Sprintf("%d", monitor.Timeout))
 	m.inputs[inputExpectedCodes].SetValue(monitor.ExpectedCodes)
 	m.inputs[inputKeywords].SetValue(monitor.Keywords)
+	m.inputs[inputMinBody].SetValue(optionalNumber(monitor.MinBodyBytes))
+	m.inputs[inputTargetLatency].SetValue(optionalNumber(monitor.TargetLatencyMs))
+	m.inputs[inputCooldown].SetValue(optionalNumber(monitor.NotificationCooldown))
+	m.inputs[inputTags].SetValue(monitor.Tags)
+	m.inputs[inputUserAgent].SetValue(monitor.UserAgent)
+	m.inputs[inputCheckHeader].SetValue(yesNo(monitor.CheckHeader))
+	m.inputs[inputWatchContent].SetValue(yesNo(monitor.WatchContent))
+	m.inputs[inputBackoff].SetValue(yesNo(monitor.Backoff))
+	m.inputs[inputHTTPVersion].SetValue(httpVersionValue(monitor.HTTPVersion))
+	m.inputs[inputAddressFamily].SetValue(addressFamilyValue(monitor.AddressFamily))
+	m.inputs[inputRequiredProto].SetValue(monitor.RequiredProto)
+	m.inputs[inputNoKeepAlive].SetValue(yesNo(monitor.DisableKeepAlive))
+	m.inputs[inputSkipTLSVerify].SetValue(yesNo(monitor.SkipTLSVerify))
+	m.inputs[inputProxy].SetValue(monitor.Proxy)
+	m.inputs[inputActiveHours].SetValue(monitor.ActiveHours)
 
 	m.inputs[inputName].Focus()
 	for i := 1; i < len(m.inputs); i++ {
@@ -108,11 +271,67 @@ func (m *formModel) setMonitor(monitor *storage.Monitor) {
 	}
 }
 
+func (m *formModel) clearTest() {
+	m.pending = nil
+	m.testing = false
+	m.testResult = nil
+	m.duplicate = nil
+	m.allowDuplicate = false
+}
+
 func (m formModel) Update(msg tea.Msg) (formModel, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case formTestMsg:
+		if !m.testing {
+			return m, nil
+		}
+		m.testing = false
+		if msg.result.Err == nil {
+			return m, m.commit()
+		}
+		m.testResult = &msg.result
+		return m, nil
+
 	case tea.KeyMsg:
+		if m.testing {
+			if msg.String() == "esc" {
+				m.clearTest()
+			}
+			return m, nil
+		}
+		if m.duplicate != nil {
+			switch msg.String() {
+			case "e":
+				return m, editMonitor(m.duplicate)
+			case "s":
+				if m.exactDuplicate() {
+					return m, nil
+				}
+				m.duplicate = nil
+				m.allowDuplicate = true
+				return m, m.save()
+			case "esc":
+				m.clearTest()
+				m.err = nil
+				m.focusIndex = inputURL
+				return m, m.updateFocus()
+			}
+			return m, nil
+		}
+		if m.testResult != nil {
+			switch msg.String() {
+			case "s":
+				return m, m.commit()
+			case "esc":
+				m.clearTest()
+				m.focusIndex = inputURL
+				return m, m.updateFocus()
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "esc":
 			return m, backToList()
@@ -164,18 +383,84 @@ func (m *formModel) updateFocus() tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// save validates the form and runs a test check of the result in the
+// background; the monitor is stored once it passes, or if the user saves
+// anyway. Heartbeat monitors have nothing to test and are stored directly.
 func (m *formModel) save() tea.Cmd {
+	monitor, err := m.build()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.err = nil
+	m.pending = monitor
+	if monitor.IsHeartbeat() {
+		return m.commit()
+	}
+
+	m.testing = true
+	tester := m.tester
+	return func() tea.Msg {
+		return formTestMsg{result: tester.Test(monitor)}
+	}
+}
+
+// exactDuplicate reports whether the duplicate has exactly the URL entered,
+// which URLs being unique rules out saving anyway.
+func (m *formModel) exactDuplicate() bool {
+	return m.duplicate.URL == strings.TrimSpace(m.inputs[inputURL].Value())
+}
+
+func (m *formModel) commit() tea.Cmd {
+	monitor := m.pending
+	var err error
+	if m.isEdit {
+		err = m.db.UpdateMonitor(monitor)
+	} else {
+		err = m.db.CreateMonitor(monitor)
+	}
+	m.clearTest()
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	if m.isEdit {
+		m.monitor = monitor
+	}
+	return monitorSaved()
+}
+
+// build validates the inputs and returns the monitor they describe. When
+// editing it is a copy, so the original is untouched until it is saved, and
+// when cloning it starts from the clone's settings.
+func (m *formModel) build() (*storage.Monitor, error) {
 	name := strings.TrimSpace(m.inputs[inputName].Value())
 	url := strings.TrimSpace(m.inputs[inputURL].Value())
 
 	if name == "" {
-		m.err = fmt.Errorf("name is required")
-		return nil
+		return nil, fmt.Errorf("name is required")
 	}
 
 	if url == "" {
-		m.err = fmt.Errorf("URL is required")
-		return nil
+		return nil, fmt.Errorf("URL is required")
+	}
+	if (m.isEdit && m.monitor != nil && m.monitor.IsBanner()) || (m.template != nil && m.template.IsBanner()) {
+		target, err := storage.ValidateBannerTarget(url)
+		if err != nil {
+			return nil, err
+		}
+		url = target
+	}
+	if (m.isEdit && m.monitor != nil && m.monitor.IsTCP()) || (m.template != nil && m.template.IsTCP()) {
+		target, err := storage.ValidateTCPTarget(url)
+		if err != nil {
+			return nil, err
+		}
+		url = target
+	}
+	if existing, err := m.db.GetMonitorByURL(url); err == nil && (m.monitor == nil || existing.ID != m.monitor.ID) && !m.allowDuplicate {
+		m.duplicate = existing
+		return nil, fmt.Errorf("%s is already monitored by %q (%s)", url, existing.Name, existing.URL)
 	}
 
 	interval, err := strconv.Atoi(m.inputs[inputInterval].Value())
@@ -194,37 +479,98 @@ func (m *formModel) save() tea.Cmd {
 	}
 
 	keywords := strings.TrimSpace(m.inputs[inputKeywords].Value())
-
-	if m.isEdit && m.monitor != nil {
-		m.monitor.Name = name
-		m.monitor.URL = url
-		m.monitor.CheckInterval = interval
-		m.monitor.Timeout = timeout
-		m.monitor.ExpectedCodes = expectedCodes
-		m.monitor.Keywords = keywords
-
-		if err := m.db.UpdateMonitor(m.monitor); err != nil {
-			m.err = err
-			return nil
+	minBody := 0
+	if v := strings.TrimSpace(m.inputs[inputMinBody].Value()); v != "" {
+		minBody, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("minimum body size must be a number of bytes")
 		}
-	} else {
-		monitor := &storage.Monitor{
-			Name:          name,
-			URL:           url,
-			CheckInterval: interval,
-			Timeout:       timeout,
-			ExpectedCodes: expectedCodes,
-			Keywords:      keywords,
-			Enabled:       true,
+	}
+	targetLatency := 0
+	if v := strings.TrimSpace(m.inputs[inputTargetLatency].Value()); v != "" {
+		targetLatency, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("target latency must be a number of milliseconds")
 		}
-
-		if err := m.db.CreateMonitor(monitor); err != nil {
-			m.err = err
-			return nil
+	}
+	cooldown := 0
+	if v := strings.TrimSpace(m.inputs[inputCooldown].Value()); v != "" {
+		cooldown, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("notification cooldown must be a number of seconds")
 		}
 	}
+	tags := strings.Join(storage.ParseTags(m.inputs[inputTags].Value()), ",")
+	userAgent := strings.TrimSpace(m.inputs[inputUserAgent].Value())
+	checkHeader := isYes(m.inputs[inputCheckHeader].Value())
+	watchContent := isYes(m.inputs[inputWatchContent].Value())
+	backoff := isYes(m.inputs[inputBackoff].Value())
+	noKeepAlive := isYes(m.inputs[inputNoKeepAlive].Value())
+	skipTLSVerify := isYes(m.inputs[inputSkipTLSVerify].Value())
+
+	httpVersion, err := storage.ValidateHTTPVersion(strings.TrimSpace(m.inputs[inputHTTPVersion].Value()))
+	if err != nil {
+		return nil, err
+	}
+	family, err := storage.ValidateAddressFamily(strings.TrimSpace(m.inputs[inputAddressFamily].Value()))
+	if err != nil {
+		return nil, err
+	}
 
-	return monitorSaved()
+	proxy := strings.TrimSpace(m.inputs[inputProxy].Value())
+	if err := storage.ValidateProxy(proxy); err != nil {
+		return nil, err
+	}
+
+	activeHours, err := storage.ValidateSchedule(m.inputs[inputActiveHours].Value())
+	if err != nil {
+		return nil, err
+	}
+
+	monitor := storage.Monitor{Enabled: true, Public: true}
+	switch {
+	case m.isEdit && m.monitor != nil:
+		monitor = *m.monitor
+	case m.template != nil:
+		monitor = *m.template
+	}
+	monitor.Name = name
+	monitor.URL = url
+	monitor.CheckInterval = interval
+	monitor.Timeout = timeout
+	monitor.ExpectedCodes = expectedCodes
+	monitor.Keywords = keywords
+	monitor.MinBodyBytes = minBody
+	monitor.TargetLatencyMs = targetLatency
+	monitor.NotificationCooldown = cooldown
+	monitor.Tags = tags
+	monitor.UserAgent = userAgent
+	monitor.CheckHeader = checkHeader
+	monitor.WatchContent = watchContent
+	monitor.Backoff = backoff
+	monitor.HTTPVersion = httpVersion
+	monitor.AddressFamily = family
+	monitor.RequiredProto, err = storage.ValidateRequiredProto(strings.TrimSpace(m.inputs[inputRequiredProto].Value()), httpVersion, monitor.Type)
+	if err != nil {
+		return nil, err
+	}
+	monitor.DisableKeepAlive = noKeepAlive
+	monitor.SkipTLSVerify = skipTLSVerify
+	monitor.Proxy = proxy
+	monitor.ActiveHours = activeHours
+	if err := storage.ValidateMinBodyBytes(monitor.MinBodyBytes, monitor.Type); err != nil {
+		return nil, err
+	}
+	if err := storage.ValidateTargetLatency(monitor.TargetLatencyMs, monitor.Type); err != nil {
+		return nil, err
+	}
+	if err := storage.ValidateCooldown(monitor.NotificationCooldown); err != nil {
+		return nil, err
+	}
+	if _, err := storage.ParseKeywordRules(monitor.Keywords); err != nil {
+		return nil, err
+	}
+	return &monitor, nil
 }
 
 func (m formModel) View() string {
@@ -233,6 +579,8 @@ func (m formModel) View() string {
 	title := "Add Monitor"
 	if m.isEdit {
 		title = "Edit Monitor"
+	} else if m.template != nil {
+		title = "Clone Monitor"
 	}
 
 	b.WriteString(titleStyle.Render(title))
@@ -244,7 +592,22 @@ func (m formModel) View() string {
 		"Check Interval (seconds):",
 		"Timeout (seconds):",
 		"Expected Status Codes:",
-		"Keywords (comma-separated):",
+		"Keywords (comma-separated, headers:/redirect-chain: prefix, re: regex, >=N count):",
+		"Minimum response size (bytes, empty = off):",
+		fmt.Sprintf("Target latency (ms, empty = %d):", storage.DefaultTargetLatency),
+		fmt.Sprintf("Notification cooldown (seconds, empty = %d, -1 = none):", config.NotificationCooldown),
+		"Tags (comma-separated):",
+		"User-Agent:",
+		"Send X-Statping-Check header (y/n):",
+		"Notify when content changes (y/n):",
+		"Check less often while down (y/n):",
+		"HTTP Version (auto/1.1/2):",
+		"Address family (any/ipv4/ipv6/both):",
+		"Required protocol (empty = any):",
+		"New connection for every check (y/n):",
+		"Skip TLS verification - INSECURE (y/n):",
+		"Proxy:",
+		"Active Hours (empty = always):",
 	}
 
 	for i, input := range m.inputs {
@@ -254,16 +617,99 @@ func (m formModel) View() string {
 		b.WriteString("\n\n")
 	}
 
+	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 	if m.err != nil {
-		errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
 		b.WriteString(errStyle.Render(fmt.Sprintf("Error: %v", m.err)))
 		b.WriteString("\n\n")
 	}
 
-	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
-		"tab/j: next • shift+tab/k: previous • enter: save • esc: cancel",
-	)
+	helpText := "tab/j: next • shift+tab/k: previous • enter: save • esc: cancel"
+	switch {
+	case m.testing:
+		b.WriteString("Testing " + m.pending.URL + "...\n\n")
+		helpText = "esc: go back"
+	case m.duplicate != nil && m.exactDuplicate():
+		helpText = "e: edit the existing monitor instead • esc: go back"
+	case m.duplicate != nil:
+		helpText = "e: edit the existing monitor instead • s: save anyway • esc: go back"
+	case m.testResult != nil:
+		b.WriteString(errStyle.Render("✗ Test check failed"))
+		b.WriteString("\n")
+		b.WriteString(testResultView(*m.testResult))
+		b.WriteString("\n")
+		helpText = "s: save anyway • esc: go back and fix"
+	}
+
+	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(helpText)
 	b.WriteString(help)
 
 	return baseStyle.Render(b.String())
 }
+
+func testResultView(r checker.TestResult) string {
+	var b strings.Builder
+	if r.StatusCode != 0 {
+		fmt.Fprintf(&b, "  Status:  %d (%dms)\n", r.StatusCode, r.ResponseTime)
+	}
+	if r.ResolvedIP != "" {
+		fmt.Fprintf(&b, "  IP:      %s\n", r.ResolvedIP)
+	}
+	if r.Protocol != "" {
+		fmt.Fprintf(&b, "  Proto:   %s\n", r.Protocol)
+	}
+	if r.IPv4Time != 0 || r.IPv6Time != 0 {
+		fmt.Fprintf(&b, "  IPv4:    %s\n  IPv6:    %s\n", familyTime(r.IPv4Time), familyTime(r.IPv6Time))
+	}
+	for _, k := range r.Keywords {
+		mark := "✓ found"
+		if !k.Found {
+			mark = "✗ not found"
+		}
+		fmt.Fprintf(&b, "  Keyword: %q %s (%d)\n", k.Keyword, mark, k.Count)
+	}
+	if r.Err != nil {
+		fmt.Fprintf(&b, "  Error:   %v\n", r.Err)
+	}
+	return b.String()
+}
+
+func isYes(s string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(s)), "y")
+}
+
+func httpVersionValue(v string) string {
+	if v == storage.HTTPVersionAuto {
+		return "auto"
+	}
+	return v
+}
+
+func addressFamilyValue(v string) string {
+	if v == storage.AddressFamilyAny {
+		return "any"
+	}
+	return v
+}
+
+// familyTime formats a dual-stack check's time over one family, which is
+// zero when that family failed.
+func familyTime(ms int64) string {
+	if ms == 0 {
+		return "failed"
+	}
+	return fmt.Sprintf("%dms", ms)
+}
+
+func optionalNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "y"
+	}
+	return "n"
+}
@@ -5,7 +5,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ankityadav/statping/internal/checker"
+	"github.com/ankityadav/statping/internal/config"
 	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/textutil"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -34,22 +37,109 @@ var (
 
 type listModel struct {
 	db       *storage.Database
+	checker  *checker.Checker
 	table    table.Model
 	monitors []storage.Monitor
+	message  string
+	width    int
+
+	// deleting is the monitor awaiting confirmation to go to the trash.
+	deleting *storage.Monitor
+
+	// uptimes holds each monitor's 24h uptime, refreshed every
+	// uptimeRefresh rather than on every tick.
+	uptimes  map[uint]storage.Uptime
+	uptimeAt time.Time
+
+	// latest is each monitor's newest check result.
+	latest map[uint]storage.CheckResult
+
+	activity storage.MonitoringActivity
+}
+
+const (
+	colID = iota
+	colName
+	colURL
+	colStatus
+	colResponse
+	colTarget
+	colLastCheck
+	colNextCheck
+	colUptime
+	colEnabled
+)
+
+var listColumns = []table.Column{
+	{Title: "ID", Width: 4},
+	{Title: "Name", Width: 20},
+	{Title: "URL", Width: 40},
+	{Title: "Status", Width: 11},
+	{Title: "Response", Width: 8},
+	{Title: "Target", Width: 7},
+	{Title: "Last Check", Width: 16},
+	{Title: "Next Check", Width: 10},
+	{Title: "24h Uptime", Width: 10},
+	{Title: "Enabled", Width: 8},
 }
 
-func newListModel(db *storage.Database) listModel {
-	columns := []table.Column{
-		{Title: "ID", Width: 4},
-		{Title: "Name", Width: 20},
-		{Title: "URL", Width: 40},
-		{Title: "Status", Width: 10},
-		{Title: "Last Check", Width: 20},
-		{Title: "Enabled", Width: 8},
+// listDropOrder is the order columns are hidden in when the terminal is too
+// narrow, after the URL column has shrunk to minURLWidth.
+var listDropOrder = []int{colEnabled, colTarget, colResponse, colLastCheck, colURL, colUptime, colNextCheck}
+
+const (
+	minURLWidth   = 20
+	uptimeRefresh = 30 * time.Second
+)
+
+// layoutColumns returns the columns that fit in width, and the index into
+// listColumns of each. A width of 0 means unknown and shows them all.
+func layoutColumns(width int) ([]table.Column, []int) {
+	cols := make([]table.Column, len(listColumns))
+	copy(cols, listColumns)
+	visible := make([]bool, len(cols))
+	for i := range visible {
+		visible[i] = true
+	}
+
+	// Every cell is padded by one space on each side.
+	total := func() int {
+		n := 0
+		for i, c := range cols {
+			if visible[i] {
+				n += c.Width + 2
+			}
+		}
+		return n
+	}
+
+	if width > 0 {
+		if over := total() - width; over > 0 {
+			cols[colURL].Width = max(minURLWidth, cols[colURL].Width-over)
+		}
+		for _, c := range listDropOrder {
+			if total() <= width {
+				break
+			}
+			visible[c] = false
+		}
+	}
+
+	var shown []table.Column
+	var idx []int
+	for i, c := range cols {
+		if visible[i] {
+			shown = append(shown, c)
+			idx = append(idx, i)
+		}
 	}
+	return shown, idx
+}
 
+func newListModel(db *storage.Database, c *checker.Checker) listModel {
+	cols, _ := layoutColumns(0)
 	t := table.New(
-		table.WithColumns(columns),
+		table.WithColumns(cols),
 		table.WithFocused(true),
 		table.WithHeight(15),
 	)
@@ -67,8 +157,9 @@ func newListModel(db *storage.Database) listModel {
 	t.SetStyles(s)
 
 	lm := listModel{
-		db:    db,
-		table: t,
+		db:      db,
+		checker: c,
+		table:   t,
 	}
 	lm.loadMonitors()
 	return lm
@@ -78,16 +169,66 @@ func (m *listModel) Init() tea.Cmd {
 	return nil
 }
 
+func (m *listModel) setWidth(width int) {
+	m.width = width
+	m.refreshTable()
+}
+
 func (m *listModel) loadMonitors() {
 	monitors, err := m.db.ListMonitors()
 	if err != nil {
 		return
 	}
 	m.monitors = monitors
+	if latest, err := m.db.GetLatestResultPerMonitor(); err == nil {
+		m.latest = latest
+	}
+	if activity, err := m.db.GetMonitoringActivity(); err == nil {
+		m.activity = activity
+	}
+	m.loadUptimes()
+	m.refreshTable()
+}
+
+// loadUptimes fetches the 24h uptime of all monitors in one batch when the
+// cached values are stale or a monitor is missing from them.
+func (m *listModel) loadUptimes() {
+	stale := time.Since(m.uptimeAt) >= uptimeRefresh
+	ids := make([]uint, len(m.monitors))
+	for i, mon := range m.monitors {
+		ids[i] = mon.ID
+		if _, ok := m.uptimes[mon.ID]; !ok {
+			stale = true
+		}
+	}
+	if !stale {
+		return
+	}
+	uptimes, err := m.db.GetUptimeForMonitors(ids, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return
+	}
+	m.uptimes = uptimes
+	m.uptimeAt = time.Now()
+}
+
+func (m *listModel) refreshTable() {
+	cols, idx := layoutColumns(m.width)
+	urlWidth := listColumns[colURL].Width
+	for j, c := range idx {
+		if c == colURL {
+			urlWidth = cols[j].Width
+		}
+	}
+
+	var running map[uint]checker.MonitorStatus
+	if m.checker != nil {
+		running = m.checker.GetStatus()
+	}
 
 	rows := []table.Row{}
-	for _, mon := range monitors {
-		status := m.formatStatus(mon.CurrentStatus)
+	for i := range m.monitors {
+		mon := &m.monitors[i]
 		lastCheck := "Never"
 		if mon.LastCheckAt != nil {
 			lastCheck = formatTime(*mon.LastCheckAt)
@@ -96,17 +237,82 @@ func (m *listModel) loadMonitors() {
 		if mon.Enabled {
 			enabled = "Yes"
 		}
+		response := "-"
+		if r, ok := m.latest[mon.ID]; ok {
+			response = "failed"
+			if r.Success {
+				response = fmt.Sprintf("%dms", r.ResponseTime)
+				if r.ResponseTime > mon.LatencyThresholds().Slow {
+					response += "!"
+				}
+			}
+		}
+		target := "-"
+		if mon.TargetLatencyMs > 0 {
+			target = fmt.Sprintf("%dms", mon.TargetLatencyMs)
+		}
+		uptime := "-"
+		if u, ok := m.uptimes[mon.ID]; ok && u.Up+u.Down > 0 {
+			uptime = fmt.Sprintf("%.2f%%", u.Percent())
+		}
+
+		cells := []string{
+			colID:        fmt.Sprintf("%d", mon.ID),
+			colName:      textutil.Truncate(mon.Name, listColumns[colName].Width),
+			colURL:       textutil.Truncate(mon.DisplayTarget(), urlWidth),
+			colStatus:    m.formatStatus(mon.DisplayStatus()),
+			colResponse:  response,
+			colTarget:    target,
+			colLastCheck: lastCheck,
+			colNextCheck: nextCheck(mon, running),
+			colUptime:    uptime,
+			colEnabled:   enabled,
+		}
+		row := make(table.Row, len(idx))
+		for j, c := range idx {
+			row[j] = cells[c]
+		}
+		rows = append(rows, row)
+	}
+
+	// A row with more cells than there are columns breaks rendering, so the
+	// rows go first when columns are being hidden.
+	if len(cols) < len(m.table.Columns()) {
+		m.table.SetRows(rows)
+		m.table.SetColumns(cols)
+	} else {
+		m.table.SetColumns(cols)
+		m.table.SetRows(rows)
+	}
+}
 
-		rows = append(rows, table.Row{
-			fmt.Sprintf("%d", mon.ID),
-			mon.Name,
-			mon.URL,
-			status,
-			lastCheck,
-			enabled,
-		})
+// nextCheck says when mon is checked next: from the running checker's
+// schedule if there is one in this process, otherwise from the stored
+// schedule or the last check plus the interval.
+func nextCheck(mon *storage.Monitor, running map[uint]checker.MonitorStatus) string {
+	if !mon.Enabled {
+		return "Paused"
 	}
-	m.table.SetRows(rows)
+
+	var next time.Time
+	if st, ok := running[mon.ID]; ok && !st.NextCheck.IsZero() {
+		next = st.NextCheck
+	} else if mon.NextCheckAt != nil {
+		next = *mon.NextCheckAt
+	} else if mon.LastCheckAt != nil {
+		next = mon.LastCheckAt.Add(time.Duration(mon.CheckInterval) * time.Second)
+	} else {
+		return "-"
+	}
+
+	until := time.Until(next).Round(time.Second)
+	if until <= 0 {
+		if mon.IsHeartbeat() {
+			return "overdue"
+		}
+		return "due"
+	}
+	return "in " + formatDuration(until)
 }
 
 func (m *listModel) formatStatus(status string) string {
@@ -115,6 +321,10 @@ func (m *listModel) formatStatus(status string) string {
 		return "✓ UP"
 	case "down":
 		return "✗ DOWN"
+	case storage.StatusOutOfSchedule:
+		return "◌ OFF HOURS"
+	case storage.StatusPending:
+		return "◷ PENDING"
 	default:
 		return "? UNKNOWN"
 	}
@@ -125,6 +335,22 @@ func (m listModel) Update(msg tea.Msg) (listModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.deleting != nil {
+			monitor := m.deleting
+			m.deleting = nil
+			if msg.String() != "y" {
+				m.message = ""
+				return m, nil
+			}
+			if _, err := m.db.DeleteMonitor(monitor.ID); err != nil {
+				m.message = fmt.Sprintf("Failed to delete monitor: %v", err)
+			} else {
+				m.message = fmt.Sprintf("Moved '%s' to the trash; restore it with 'statping trash restore %d'", monitor.Name, monitor.ID)
+			}
+			m.loadMonitors()
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "a":
 			return m, addMonitor()
@@ -132,11 +358,15 @@ func (m listModel) Update(msg tea.Msg) (listModel, tea.Cmd) {
 			if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
 				return m, editMonitor(&m.monitors[m.table.Cursor()])
 			}
+		case "c":
+			if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
+				return m, cloneMonitor(&m.monitors[m.table.Cursor()])
+			}
 		case "d":
 			if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
-				monitor := &m.monitors[m.table.Cursor()]
-				m.db.DeleteMonitor(monitor.ID)
-				m.loadMonitors()
+				monitor := m.monitors[m.table.Cursor()]
+				m.deleting = &monitor
+				m.message = fmt.Sprintf("Move '%s' to the trash? Its history is kept and 'statping trash restore %d' brings it back. (y/n)", monitor.Name, monitor.ID)
 				return m, nil
 			}
 		case "t":
@@ -150,6 +380,24 @@ func (m listModel) Update(msg tea.Msg) (listModel, tea.Cmd) {
 			if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
 				return m, monitorSelected(&m.monitors[m.table.Cursor()])
 			}
+		case "J", "K":
+			if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
+				cursor := m.table.Cursor()
+				target := cursor + 1
+				if msg.String() == "K" {
+					target = cursor - 1
+				}
+				if target < 0 || target >= len(m.monitors) {
+					return m, nil
+				}
+				if err := m.db.MoveMonitor(m.monitors[cursor].ID, target); err != nil {
+					m.message = fmt.Sprintf("Failed to move monitor: %v", err)
+					return m, nil
+				}
+				m.loadMonitors()
+				m.table.SetCursor(target)
+				return m, nil
+			}
 		case "r":
 			m.loadMonitors()
 			return m, nil
@@ -165,17 +413,90 @@ func (m listModel) View() string {
 
 	b.WriteString(titleStyle.Render("📊 Statping - Website Monitor"))
 	b.WriteString("\n\n")
+	if banner := stallBanner(m.activity); banner != "" {
+		b.WriteString(banner + "\n\n")
+	}
 	b.WriteString(m.table.View())
 	b.WriteString("\n\n")
 
+	if m.message != "" {
+		b.WriteString(statusUnknownStyle.Render(m.message))
+		b.WriteString("\n")
+	} else if len(m.monitors) > 0 && m.table.Cursor() < len(m.monitors) {
+		var running map[uint]checker.MonitorStatus
+		if m.checker != nil {
+			running = m.checker.GetStatus()
+		}
+		if line := scheduleLine(&m.monitors[m.table.Cursor()], running); line != "" {
+			b.WriteString(statusUnknownStyle.Render(line))
+			b.WriteString("\n")
+		}
+	}
+
 	help := lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render(
-		"a: add • e: edit • d: delete • t: toggle • enter: details • r: refresh • q: quit",
+		"a: add • e: edit • c: clone • d: delete • t: toggle • J/K: move • enter: details • r: refresh • q: quit",
 	)
 	b.WriteString(help)
 
 	return b.String()
 }
 
+// scheduleLine describes when the monitor is checked next, and whether its
+// checks are being spaced out because it keeps failing.
+func scheduleLine(mon *storage.Monitor, running map[uint]checker.MonitorStatus) string {
+	if !mon.Enabled {
+		return "Paused"
+	}
+	if mon.DisplayStatus() == storage.StatusPending {
+		// Only a checker in this process knows when the first check runs.
+		st, ok := running[mon.ID]
+		if !ok || st.NextCheck.IsZero() {
+			return "Waiting for the first check"
+		}
+		if until := time.Until(st.NextCheck).Round(time.Second); until > 0 {
+			return fmt.Sprintf("First check in %s", formatDuration(until))
+		}
+		return "First check running"
+	}
+	if mon.NextCheckAt == nil {
+		return ""
+	}
+
+	until := time.Until(*mon.NextCheckAt).Round(time.Second)
+	if mon.IsHeartbeat() {
+		if until < 0 {
+			return fmt.Sprintf("Ping overdue by %s", formatDuration(-until))
+		}
+		return fmt.Sprintf("Next ping due in %s", formatDuration(until))
+	}
+
+	line := "Next check due now"
+	if until > 0 {
+		line = fmt.Sprintf("Next check in %s", formatDuration(until))
+	}
+	if mon.Backoff && mon.ConsecutiveFails >= config.BackoffThreshold && mon.LastCheckAt != nil {
+		every := mon.NextCheckAt.Sub(*mon.LastCheckAt).Round(time.Second)
+		line += fmt.Sprintf(" (backing off to every %s after %d failures)", formatDuration(every), mon.ConsecutiveFails)
+	}
+	return line
+}
+
+var stallStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("231")).
+	Background(lipgloss.Color("160")).
+	Bold(true).
+	Padding(0, 1)
+
+// stallBanner warns that no checks have run for a while, so the statuses
+// shown are stale, or returns "" when monitoring is running.
+func stallBanner(a storage.MonitoringActivity) string {
+	now := time.Now()
+	if !a.Stalled(now) {
+		return ""
+	}
+	return stallStyle.Render(fmt.Sprintf("⚠ Monitoring stalled — last check activity %s ago", formatDuration(a.Silence(now))))
+}
+
 func formatTime(t time.Time) string {
 	return t.Format("Jan 02 15:04:05")
 }
@@ -1,8 +1,10 @@
 package tui
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/ankityadav/statping/internal/checker"
 	"github.com/ankityadav/statping/internal/storage"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -29,11 +31,13 @@ type Model struct {
 
 type tickMsg time.Time
 
-func New(db *storage.Database) Model {
+// New creates the TUI. c is the checker running in this process, if any; the
+// list uses it for live check schedules.
+func New(db *storage.Database, c *checker.Checker) Model {
 	return Model{
 		db:     db,
 		state:  listView,
-		list:   newListModel(db),
+		list:   newListModel(db, c),
 		form:   newFormModel(db),
 		detail: newDetailModel(db),
 	}
@@ -71,6 +75,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.list.setWidth(msg.Width)
+		m.detail.setSize(msg.Width, msg.Height)
 
 	case tickMsg:
 		if m.state == listView {
@@ -90,6 +96,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.form.reset()
 		return m, nil
 
+	case CloneMonitorMsg:
+		m.state = addView
+		if err := m.form.setClone(msg.Monitor); err != nil {
+			m.state = listView
+			m.list.message = fmt.Sprintf("Failed to clone monitor: %v", err)
+		}
+		return m, nil
+
 	case EditMonitorMsg:
 		m.state = editView
 		m.form.setMonitor(msg.Monitor)
@@ -153,6 +167,10 @@ type EditMonitorMsg struct {
 	Monitor *storage.Monitor
 }
 
+type CloneMonitorMsg struct {
+	Monitor *storage.Monitor
+}
+
 type MonitorSavedMsg struct{}
 
 type BackToListMsg struct{}
@@ -175,6 +193,12 @@ func editMonitor(m *storage.Monitor) tea.Cmd {
 	}
 }
 
+func cloneMonitor(m *storage.Monitor) tea.Cmd {
+	return func() tea.Msg {
+		return CloneMonitorMsg{Monitor: m}
+	}
+}
+
 func monitorSaved() tea.Cmd {
 	return func() tea.Msg {
 		return MonitorSavedMsg{}
@@ -0,0 +1,104 @@
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const sessionCookie = "statping_session"
+
+// SetToken enables authentication. Mutating /api/* requests then need either
+// an "Authorization: Bearer <token>" header or a session cookie obtained via
+// /login. With requireLogin, every page and read-only endpoint needs a
+// session too. An empty token disables authentication, which is what the
+// tray's localhost-only settings window uses.
+func (s *Server) SetToken(token string, requireLogin bool) {
+	s.token = token
+	s.requireLogin = requireLogin
+}
+
+func (s *Server) sessionValue() string {
+	sum := sha256.Sum256([]byte("statping-session:" + s.token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		given := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+		if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1 {
+			return true
+		}
+	}
+
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		return subtle.ConstantTimeCompare([]byte(c.Value), []byte(s.sessionValue())) == 1
+	}
+	return false
+}
+
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Badges are meant to be embedded in READMEs, so they never need a
+		// session. Heartbeat pings authenticate with the token in their path.
+		if s.token == "" || r.URL.Path == "/login" || r.URL.Path == "/static/style.css" ||
+			strings.HasPrefix(r.URL.Path, "/badge/") || strings.HasPrefix(r.URL.Path, "/api/heartbeat/") ||
+			s.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			if s.requireLogin || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="statping"`)
+				http.Error(w, "Unauthorized", 401)
+				return
+			}
+		} else if s.requireLogin {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		next = "/"
+	}
+
+	data := map[string]interface{}{
+		"Next": next,
+	}
+
+	code := http.StatusOK
+	if r.Method == "POST" {
+		token := strings.TrimSpace(r.FormValue("token"))
+		if s.token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			http.SetCookie(w, &http.Cookie{
+				Name:     sessionCookie,
+				Value:    s.sessionValue(),
+				Path:     "/",
+				Expires:  time.Now().Add(30 * 24 * time.Hour),
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			})
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+		data["Error"] = "Invalid token"
+		code = http.StatusUnauthorized
+	}
+
+	s.render(w, code, "login.html", data)
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const (
+	badgeGreen  = "#4c1"
+	badgeYellow = "#dfb317"
+	badgeRed    = "#e05d44"
+	badgeGray   = "#9f9f9f"
+)
+
+// handleBadge serves /badge/{id} and /badge/{id}/uptime?period=30d as
+// shields-style SVGs. Errors are rendered as gray badges rather than HTML
+// so that an embedded image never breaks.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/badge/"), "/"), "/")
+
+	kind := "status"
+	if len(parts) == 2 && parts[1] == "uptime" {
+		kind = "uptime"
+	} else if len(parts) != 1 {
+		writeBadge(w, http.StatusNotFound, "status", "not found", badgeGray)
+		return
+	}
+
+	id, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		writeBadge(w, http.StatusNotFound, kind, "not found", badgeGray)
+		return
+	}
+
+	monitor, err := s.db.GetMonitor(uint(id))
+	if err != nil || (s.publicOnly && !(monitor.Public && monitor.Enabled)) {
+		writeBadge(w, http.StatusNotFound, kind, "not found", badgeGray)
+		return
+	}
+
+	if kind == "status" {
+		switch monitor.CurrentStatus {
+		case "up":
+			writeBadge(w, http.StatusOK, "status", "up", badgeGreen)
+		case "down":
+			writeBadge(w, http.StatusOK, "status", "down", badgeRed)
+		case storage.StatusOutOfSchedule:
+			writeBadge(w, http.StatusOK, "status", "out of schedule", badgeGray)
+		default:
+			writeBadge(w, http.StatusOK, "status", "unknown", badgeGray)
+		}
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "30d"
+	}
+	// Cap at a year so a badge can't trigger a scan of the whole history.
+	window, err := storage.ParsePeriod(period)
+	if err != nil || window > 365*24*time.Hour {
+		writeBadge(w, http.StatusBadRequest, "uptime", "bad period", badgeGray)
+		return
+	}
+
+	label := "uptime " + period
+	stats, err := s.db.GetUptime(monitor.ID, time.Now().Add(-window))
+	if err != nil || stats.Up+stats.Down == 0 {
+		writeBadge(w, http.StatusOK, label, "unknown", badgeGray)
+		return
+	}
+
+	uptime := stats.Percent()
+	color := badgeRed
+	switch {
+	case uptime >= 99:
+		color = badgeGreen
+	case uptime >= 95:
+		color = badgeYellow
+	}
+	writeBadge(w, http.StatusOK, label, fmt.Sprintf("%.2f%%", uptime), color)
+}
+
+func writeBadge(w http.ResponseWriter, code int, label, message, color string) {
+	// Verdana 11px averages roughly 7px per character.
+	labelWidth := len(label)*7 + 10
+	messageWidth := len(message)*7 + 10
+	width := labelWidth + messageWidth
+
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-cache, max-age=60")
+	w.Header().Set("Expires", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	w.WriteHeader(code)
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+<title>%s: %s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>
+<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%d" y="14">%s</text>
+</g>
+</svg>
+`,
+		width, label, message,
+		label, message,
+		width,
+		labelWidth, labelWidth, messageWidth, color, width,
+		labelWidth/2, label, labelWidth/2, label,
+		labelWidth+messageWidth/2, message, labelWidth+messageWidth/2, message,
+	)
+}
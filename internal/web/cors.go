@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// corsPaths are the read-only endpoints other sites may fetch when their
+// origin is allowed by the cors-origins setting. Nothing that changes state
+// is listed, so no configuration can open those to other origins.
+var corsPaths = map[string]bool{
+	"/api/summary":           true,
+	"/api/monitors":          true,
+	"/api/monitor/stats":     true,
+	"/api/monitor/checks":    true,
+	"/api/monitor/series":    true,
+	"/api/monitor/timeline":  true,
+	"/api/monitor/incidents": true,
+	"/api/monitor/daily":     true,
+	"/feed/incidents.atom":   true,
+}
+
+// withCORS adds CORS headers to GET and HEAD requests for corsPaths from
+// an allowed origin and answers their preflight requests. Credentials are
+// never allowed, so a widget only sees what an anonymous reader would.
+func (s *Server) withCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !corsPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := s.allowedOrigin(origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			method := r.Header.Get("Access-Control-Request-Method")
+			if allowed == "" || (method != http.MethodGet && method != http.MethodHead) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed != "" && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for origin,
+// or "" if the cors-origins setting doesn't allow it. The setting is read
+// on every cross-origin request so changes apply without a restart.
+func (s *Server) allowedOrigin(origin string) string {
+	origins, err := storage.ParseCORSOrigins(s.db.GetStringSetting(storage.SettingCORSOrigins, ""))
+	if err != nil {
+		return ""
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range origins {
+		if o == "*" {
+			return "*"
+		}
+		if o == origin {
+			return origin
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package web
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const feedEntries = 50
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Link      atomLink    `xml:"link"`
+	Author    atomAuthor  `xml:"author"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// handleIncidentFeed serves recent incidents across all monitors as Atom.
+// An entry keeps its ID when the incident resolves and only its updated
+// timestamp and title change, so readers show the resolution in place.
+func (s *Server) handleIncidentFeed(w http.ResponseWriter, r *http.Request) {
+	var monitors []storage.Monitor
+	var err error
+	if s.publicOnly {
+		monitors, err = s.db.ListPublicMonitors()
+	} else {
+		monitors, err = s.db.ListMonitors()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	byID := make(map[uint]storage.Monitor, len(monitors))
+	for _, m := range monitors {
+		byID[m.ID] = m
+	}
+
+	incidents, err := s.db.GetAllRecentIncidents(feedEntries * 2)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	base := scheme + "://" + r.Host
+
+	feed := atomFeed{
+		Title: "Statping incidents",
+		ID:    base + "/feed/incidents.atom",
+		Links: []atomLink{
+			{Href: base + "/feed/incidents.atom", Rel: "self"},
+			{Href: base + "/status"},
+		},
+	}
+
+	var latest time.Time
+	for _, inc := range incidents {
+		mon, ok := byID[inc.MonitorID]
+		if !ok {
+			continue
+		}
+
+		updated := inc.StartedAt
+		state := "ongoing"
+		if inc.ResolvedAt != nil {
+			updated = *inc.ResolvedAt
+			state = "resolved"
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+
+		link := base + "/status"
+		if !s.publicOnly {
+			link = fmt.Sprintf("%s/site/%d", base, mon.ID)
+		}
+
+		// A heartbeat's URL holds its ping token.
+		target := mon.URL
+		if mon.IsHeartbeat() {
+			target = mon.Name
+		}
+		kind, content := "DOWN", fmt.Sprintf("%s went down at %s.", target, inc.StartedAt.Format(time.RFC1123))
+		if inc.IsPerformance() {
+			kind, content = "SLOW", fmt.Sprintf("%s started responding slowly at %s.", target, inc.StartedAt.Format(time.RFC1123))
+		}
+		if inc.ResolvedAt != nil {
+			content += fmt.Sprintf(" Recovered at %s.", inc.ResolvedAt.Format(time.RFC1123))
+			if inc.Resolution != "" {
+				content += " (" + inc.Resolution + ")"
+			}
+		}
+		if inc.ErrorMessage != "" {
+			content += "\n\nError: " + inc.ErrorMessage
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     fmt.Sprintf("%s %s (%s, %s)", mon.Name, kind, state, formatDurationHuman(inc.Duration())),
+			ID:        fmt.Sprintf("%s/incident/%d", base, inc.ID),
+			Published: inc.StartedAt.UTC().Format(time.RFC3339),
+			Updated:   updated.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: link},
+			Author:    atomAuthor{Name: "statping"},
+			Content:   atomContent{Type: "text", Body: content},
+		})
+		if len(feed.Entries) == feedEntries {
+			break
+		}
+	}
+
+	if latest.IsZero() {
+		latest = time.Now()
+	}
+	feed.Updated = latest.UTC().Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	enc.Encode(feed)
+}
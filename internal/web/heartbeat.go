@@ -0,0 +1,77 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// HeartbeatFunc records a ping for a heartbeat monitor. duration is the run
+// time reported by the job in milliseconds, or 0.
+type HeartbeatFunc func(m *storage.Monitor, duration int64)
+
+// SetHeartbeatHandler enables /api/heartbeat/{token}. Without a handler the
+// endpoint answers 503, since there is no checker to record the ping.
+func (s *Server) SetHeartbeatHandler(fn HeartbeatFunc) {
+	s.onHeartbeat = fn
+}
+
+// handleHeartbeat accepts pings from cron jobs and the like. The token in
+// the path is the only credential, so this endpoint is exempt from auth.
+// An optional duration parameter takes milliseconds or a Go duration such
+// as "1m30s".
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" && r.Method != "HEAD" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, "/api/heartbeat/")
+	if token == "" || strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.onHeartbeat == nil {
+		http.Error(w, "Heartbeats are not accepted by this server", 503)
+		return
+	}
+
+	var duration int64
+	if d := r.URL.Query().Get("duration"); d != "" {
+		var err error
+		duration, err = parseHeartbeatDuration(d)
+		if err != nil {
+			http.Error(w, "Invalid duration", 400)
+			return
+		}
+	}
+
+	monitor, err := s.db.GetMonitorByHeartbeatToken(token)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !monitor.Enabled {
+		w.Write([]byte("Paused\n"))
+		return
+	}
+
+	s.onHeartbeat(monitor, duration)
+	w.Write([]byte("OK\n"))
+}
+
+func parseHeartbeatDuration(s string) (int64, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil && ms >= 0 {
+		return ms, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, strconv.ErrSyntax
+	}
+	return d.Milliseconds(), nil
+}
@@ -0,0 +1,1381 @@
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankityadav/statping/internal/checker"
+	"github.com/ankityadav/statping/internal/storage"
+	"github.com/ankityadav/statping/internal/telemetry"
+)
+
+//go:embed templates/*
+var templatesFS embed.FS
+
+// Server serves the web UI and JSON API. It is mounted by the tray's
+// ephemeral settings window as well as by the long-running `statping serve`.
+type Server struct {
+	db           *storage.Database
+	onUpdate     func()
+	mux          *http.ServeMux
+	token        string
+	requireLogin bool
+	publicOnly   bool
+	onHeartbeat  HeartbeatFunc
+	tester       *checker.Checker
+	templates    map[string]*template.Template
+}
+
+// New creates a Server. onUpdate, if non-nil, is called after every change
+// to the monitor set so the running checker can pick it up.
+func New(db *storage.Database, onUpdate func()) *Server {
+	s := &Server{
+		db:        db,
+		onUpdate:  onUpdate,
+		mux:       http.NewServeMux(),
+		tester:    checker.New(db, nil),
+		templates: parseTemplates(),
+	}
+
+	s.mux.HandleFunc("/", s.handleIndex)
+	s.mux.HandleFunc("/site/", s.handleSiteDetail)
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/status", s.handleStatus)
+	s.mux.HandleFunc("/badge/", s.handleBadge)
+	s.mux.HandleFunc("/feed/incidents.atom", s.handleIncidentFeed)
+	s.mux.HandleFunc("/api/heartbeat/", s.handleHeartbeat)
+	s.mux.HandleFunc("/api/summary", s.handleSummary)
+	s.mux.HandleFunc("/api/monitors", s.handleMonitors)
+	s.mux.HandleFunc("/api/monitors/bulk", s.handleBulkMonitors)
+	s.mux.HandleFunc("/api/monitor/add", s.handleAddMonitor)
+	s.mux.HandleFunc("/api/monitor/test", s.handleTestMonitor)
+	s.mux.HandleFunc("/api/monitor/update", s.handleUpdateMonitor)
+	s.mux.HandleFunc("/api/monitor/delete", s.handleDeleteMonitor)
+	s.mux.HandleFunc("/api/monitor/toggle", s.handleToggleMonitor)
+	s.mux.HandleFunc("/api/monitor/pause", s.handlePauseMonitors)
+	s.mux.HandleFunc("/api/monitor/resume", s.handleResumeMonitors)
+	s.mux.HandleFunc("/api/monitor/stats", s.handleMonitorStats)
+	s.mux.HandleFunc("/api/monitor/checks", s.handleMonitorChecks)
+	s.mux.HandleFunc("/api/monitor/series", s.handleMonitorSeries)
+	s.mux.HandleFunc("/api/monitor/timeline", s.handleStatusTimeline)
+	s.mux.HandleFunc("/api/monitor/incidents", s.handleMonitorIncidents)
+	s.mux.HandleFunc("/api/monitor/export", s.handleExportChecks)
+	s.mux.HandleFunc("/api/monitor/changes", s.handleContentChanges)
+	s.mux.HandleFunc("/api/monitor/daily", s.handleDailyUptime)
+	s.mux.HandleFunc("/static/style.css", s.handleCSS)
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.withCORS(s.withAuth(s.mux))
+}
+
+// NewHTTPServer wraps handler in an http.Server with timeouts suitable for
+// a long-running process exposed beyond localhost.
+func NewHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	monitors, _ := s.db.ListMonitors()
+	summaries, err := s.summarizeMonitors(monitors)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	var stalled time.Duration
+	if activity, err := s.db.GetMonitoringActivity(); err == nil && activity.Stalled(time.Now()) {
+		stalled = activity.Silence(time.Now())
+	}
+	s.render(w, http.StatusOK, "index.html", map[string]interface{}{
+		"Monitors":  summaries,
+		"PingPaths": s.pingPaths(r, monitors),
+		"Stalled":   stalled,
+	})
+}
+
+// handleMetrics serves statping's internal metrics for Prometheus while
+// they are enabled.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !telemetry.Enabled() {
+		http.Error(w, "Internal metrics are disabled; enable them with 'statping config set internal-metrics true' and restart", 404)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	telemetry.WritePrometheus(w)
+}
+
+func (s *Server) handleCSS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/css")
+	data, _ := templatesFS.ReadFile("templates/style.css")
+	w.Write(data)
+}
+
+// handleMonitors lists every monitor. With expand=stats each one also
+// carries its 24h uptime, latest error and open incident.
+func (s *Server) handleMonitors(w http.ResponseWriter, r *http.Request) {
+	expand := r.URL.Query().Get("expand")
+	if expand != "" && expand != "stats" {
+		http.Error(w, "Invalid expand, only stats is supported", 400)
+		return
+	}
+
+	monitors, err := s.db.ListMonitors()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if expand == "" {
+		redacted := make([]storage.Monitor, len(monitors))
+		for i := range monitors {
+			redacted[i] = monitors[i].Redacted()
+		}
+		json.NewEncoder(w).Encode(redacted)
+		return
+	}
+
+	summaries, err := s.summarizeMonitors(monitors)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	json.NewEncoder(w).Encode(summaries)
+}
+
+// monitorRequest is the JSON body accepted by the add and update endpoints.
+type monitorRequest struct {
+	ID            uint    `json:"id"`
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	URL           string  `json:"url"`
+	GracePeriod   int     `json:"grace_period"`
+	Interval      int     `json:"interval"`
+	Timeout       int     `json:"timeout"`
+	ConnTimeout   int     `json:"connect_timeout"`
+	MaxFailures   int     `json:"max_failures"`
+	Cooldown      int     `json:"notification_cooldown"`
+	Channels      string  `json:"channels"`
+	OpsGeniePrio  string  `json:"opsgenie_priority"`
+	ExpectedCodes string  `json:"expected_codes"`
+	Keywords      string  `json:"keywords"`
+	MinBodyBytes  int     `json:"min_body_bytes"`
+	SHA256        string  `json:"expected_sha256"`
+	Banner        string  `json:"expected_banner"`
+	JSONSchema    string  `json:"json_schema"`
+	Tags          string  `json:"tags"`
+	SLATarget     float64 `json:"sla_target"`
+	Public        *bool   `json:"public"`
+	UserAgent     string  `json:"user_agent"`
+	CheckHeader   bool    `json:"check_header"`
+
+	WatchContent   bool   `json:"watch_content"`
+	IgnorePatterns string `json:"ignore_patterns"`
+	Backoff        bool   `json:"backoff"`
+	ActiveHours    string `json:"active_hours"`
+
+	LatencyThreshold int    `json:"latency_threshold"`
+	LatencyWindow    int    `json:"latency_window"`
+	LatencyAgg       string `json:"latency_aggregation"`
+	TargetLatencyMs  int    `json:"target_latency_ms"`
+
+	DisableKeepAlive bool   `json:"disable_keep_alive"`
+	HTTPVersion      string `json:"http_version"`
+	AddressFamily    string `json:"address_family"`
+	RequiredProto    string `json:"required_proto"`
+	SkipTLSVerify    bool   `json:"skip_tls_verify"`
+	Proxy            string `json:"proxy"`
+
+	// OAuth2ClientSecret is never sent back by the API, so an update that
+	// leaves it empty keeps the monitor's current secret.
+	OAuth2TokenURL     string `json:"oauth2_token_url"`
+	OAuth2ClientID     string `json:"oauth2_client_id"`
+	OAuth2ClientSecret string `json:"oauth2_client_secret"`
+	OAuth2Scopes       string `json:"oauth2_scopes"`
+
+	// AllowDuplicate adds a monitor even when one with the same normalized
+	// URL exists.
+	AllowDuplicate bool `json:"allow_duplicate"`
+}
+
+// apply validates the request and copies it onto m, filling in defaults for
+// omitted fields. An omitted type keeps m's type, so only new monitors
+// default to HTTP.
+func (req *monitorRequest) apply(m *storage.Monitor) error {
+	typ := req.Type
+	if typ == "" {
+		typ = m.Type
+	}
+	switch typ {
+	case "", storage.MonitorTypeHTTP:
+		if req.URL == "" {
+			return fmt.Errorf("URL is required")
+		}
+		m.Type = storage.MonitorTypeHTTP
+		m.URL = req.URL
+	case storage.MonitorTypeBanner:
+		target, err := storage.ValidateBannerTarget(req.URL)
+		if err != nil {
+			return err
+		}
+		m.Type = storage.MonitorTypeBanner
+		m.URL = target
+	case storage.MonitorTypeTCP:
+		target, err := storage.ValidateTCPTarget(req.URL)
+		if err != nil {
+			return err
+		}
+		m.Type = storage.MonitorTypeTCP
+		m.URL = target
+	case storage.MonitorTypeHeartbeat:
+		if m.HeartbeatToken == "" {
+			token, err := storage.NewHeartbeatToken()
+			if err != nil {
+				return err
+			}
+			m.HeartbeatToken = token
+		}
+		m.Type = storage.MonitorTypeHeartbeat
+		m.URL = storage.HeartbeatURL(m.HeartbeatToken)
+	default:
+		return fmt.Errorf("unknown monitor type %q", typ)
+	}
+
+	if req.GracePeriod < 0 {
+		return fmt.Errorf("grace period must not be negative")
+	}
+	if req.MaxFailures < 0 {
+		return fmt.Errorf("max failures must not be negative")
+	}
+
+	name := req.Name
+	if name == "" {
+		name = m.URL
+	}
+
+	interval := req.Interval
+	if interval <= 0 {
+		interval = 60
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+
+	codes := req.ExpectedCodes
+	if codes == "" {
+		codes = "200"
+	}
+
+	if req.SLATarget < 0 || req.SLATarget >= 100 {
+		return fmt.Errorf("SLA target must be between 0 and 100")
+	}
+
+	if _, err := storage.ParseIgnorePatterns(req.IgnorePatterns); err != nil {
+		return err
+	}
+
+	httpVersion, err := storage.ValidateHTTPVersion(req.HTTPVersion)
+	if err != nil {
+		return err
+	}
+	family, err := storage.ValidateAddressFamily(req.AddressFamily)
+	if err != nil {
+		return err
+	}
+	requiredProto, err := storage.ValidateRequiredProto(req.RequiredProto, httpVersion, m.Type)
+	if err != nil {
+		return err
+	}
+
+	// The form is filled from the redacted monitor, so getting the redacted
+	// proxy back means it wasn't changed.
+	proxy := strings.TrimSpace(req.Proxy)
+	if proxy != "" && proxy == storage.RedactProxy(m.Proxy) {
+		proxy = m.Proxy
+	}
+	if err := storage.ValidateProxy(proxy); err != nil {
+		return err
+	}
+
+	channels, err := storage.ParseChannels(req.Channels)
+	if err != nil {
+		return err
+	}
+	opsgeniePriority, err := storage.ValidateOpsGeniePriority(req.OpsGeniePrio)
+	if err != nil {
+		return err
+	}
+
+	activeHours, err := storage.ValidateSchedule(req.ActiveHours)
+	if err != nil {
+		return err
+	}
+
+	if req.LatencyThreshold < 0 || req.LatencyWindow < 0 {
+		return fmt.Errorf("latency threshold and window must not be negative")
+	}
+	latencyAgg, err := storage.ValidateLatencyAgg(req.LatencyAgg)
+	if err != nil {
+		return err
+	}
+	if err := storage.ValidateMinBodyBytes(req.MinBodyBytes, m.Type); err != nil {
+		return err
+	}
+	if err := storage.ValidateConnectTimeout(req.ConnTimeout, timeout, m.Type); err != nil {
+		return err
+	}
+	if err := storage.ValidateTargetLatency(req.TargetLatencyMs, m.Type); err != nil {
+		return err
+	}
+	if err := storage.ValidateCooldown(req.Cooldown); err != nil {
+		return err
+	}
+	jsonSchema, err := storage.ValidateJSONSchema(req.JSONSchema, m.Type)
+	if err != nil {
+		return err
+	}
+	expectedSHA256, err := storage.ValidateSHA256(req.SHA256, m.Type)
+	if err != nil {
+		return err
+	}
+	if err := storage.ValidateExpectedBanner(req.Banner, m.Type); err != nil {
+		return err
+	}
+	oauth2Secret := req.OAuth2ClientSecret
+	if oauth2Secret == "" && req.OAuth2TokenURL != "" {
+		oauth2Secret = m.OAuth2ClientSecret
+	}
+	if err := storage.ValidateOAuth2(req.OAuth2TokenURL, req.OAuth2ClientID, oauth2Secret, m.Type); err != nil {
+		return err
+	}
+	if _, err := storage.ParseKeywordRules(req.Keywords); err != nil {
+		return err
+	}
+
+	m.Name = name
+	m.GracePeriod = req.GracePeriod
+	m.CheckInterval = interval
+	m.Timeout = timeout
+	m.ConnectTimeout = req.ConnTimeout
+	m.MaxFailures = req.MaxFailures
+	m.NotificationCooldown = req.Cooldown
+	m.Channels = channels
+	m.OpsGeniePriority = opsgeniePriority
+	m.ExpectedCodes = codes
+	m.Keywords = req.Keywords
+	m.MinBodyBytes = req.MinBodyBytes
+	m.ExpectedSHA256 = expectedSHA256
+	m.ExpectedBanner = req.Banner
+	m.JSONSchema = jsonSchema
+	m.Tags = strings.Join(storage.ParseTags(req.Tags), ",")
+	m.SLATarget = req.SLATarget
+	m.UserAgent = strings.TrimSpace(req.UserAgent)
+	m.CheckHeader = req.CheckHeader
+	m.WatchContent = req.WatchContent
+	m.Backoff = req.Backoff
+	m.ActiveHours = activeHours
+	m.LatencyThreshold = req.LatencyThreshold
+	m.TargetLatencyMs = req.TargetLatencyMs
+	m.LatencyWindow = req.LatencyWindow
+	m.LatencyAgg = latencyAgg
+	m.IgnorePatterns = strings.TrimSpace(req.IgnorePatterns)
+	m.DisableKeepAlive = req.DisableKeepAlive
+	m.HTTPVersion = httpVersion
+	m.AddressFamily = family
+	m.RequiredProto = requiredProto
+	m.SkipTLSVerify = req.SkipTLSVerify
+	m.Proxy = proxy
+	if req.OAuth2TokenURL == "" {
+		m.ClearOAuth2()
+	} else {
+		m.OAuth2TokenURL = req.OAuth2TokenURL
+		m.OAuth2ClientID = req.OAuth2ClientID
+		m.OAuth2ClientSecret = oauth2Secret
+		m.OAuth2Scopes = storage.NormalizeScopes(req.OAuth2Scopes)
+	}
+	if req.Public != nil {
+		m.Public = *req.Public
+	}
+	return nil
+}
+
+func (s *Server) handleAddMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req monitorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	monitor := &storage.Monitor{Enabled: true, Public: true}
+	if err := req.apply(monitor); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	if !monitor.IsHeartbeat() && !req.AllowDuplicate {
+		if existing, err := s.db.GetMonitorByURL(monitor.URL); err == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":         fmt.Sprintf("%s is already monitored by %q (%s)", monitor.URL, existing.Name, existing.URL),
+				"existing_id":   existing.ID,
+				"existing_name": existing.Name,
+				"exact":         existing.URL == monitor.URL,
+			})
+			return
+		}
+	}
+
+	if err := s.db.CreateMonitor(monitor); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	// The monitor JSON never carries a heartbeat's token, so this is where
+	// whoever added it learns where to ping.
+	resp := map[string]interface{}{"success": true, "id": monitor.ID}
+	if monitor.IsHeartbeat() {
+		resp["ping_path"] = "/api/heartbeat/" + monitor.HeartbeatToken
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleTestMonitor runs a one-off check of the monitor described by the
+// request, without saving it, so the form can show the result first.
+func (s *Server) handleTestMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req monitorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	monitor := &storage.Monitor{Enabled: true, Public: true}
+	if req.ID != 0 {
+		existing, err := s.db.GetMonitor(req.ID)
+		if err != nil {
+			http.Error(w, "Monitor not found", 404)
+			return
+		}
+		monitor = existing
+	}
+	if err := req.apply(monitor); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	res := s.tester.Test(monitor)
+
+	type keyword struct {
+		Keyword string `json:"keyword"`
+		Found   bool   `json:"found"`
+		Count   int    `json:"count"`
+	}
+	keywords := make([]keyword, 0, len(res.Keywords))
+	for _, k := range res.Keywords {
+		keywords = append(keywords, keyword{k.Keyword, k.Found, k.Count})
+	}
+	errMsg := ""
+	if res.Err != nil {
+		errMsg = res.Err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":       res.Err == nil,
+		"status_code":   res.StatusCode,
+		"response_time": res.ResponseTime,
+		"keywords":      keywords,
+		"resolved_ip":   res.ResolvedIP,
+		"tls_version":   res.TLSVersion,
+		"protocol":      res.Protocol,
+		"ipv4_time":     res.IPv4Time,
+		"ipv6_time":     res.IPv6Time,
+		"port_results":  res.PortResults,
+		"error":         errMsg,
+	})
+}
+
+func (s *Server) handleUpdateMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" && r.Method != "PUT" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req monitorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	if req.ID == 0 {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	monitor, err := s.db.GetMonitor(req.ID)
+	if err != nil {
+		http.Error(w, "Monitor not found", 404)
+		return
+	}
+
+	if err := req.apply(monitor); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	if err := s.db.UpdateMonitor(monitor); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "id": monitor.ID})
+}
+
+func (s *Server) handleDeleteMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	_, err = s.db.DeleteMonitor(uint(id))
+	if errors.Is(err, storage.ErrMonitorNotFound) {
+		http.Error(w, "Monitor not found", 404)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "trashed": true})
+}
+
+// handleBulkMonitors deletes, enables or disables several monitors at once,
+// chosen by ID or by tag, and reports the outcome for each.
+func (s *Server) handleBulkMonitors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		IDs    []uint `json:"ids"`
+		Tag    string `json:"tag"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	ids := req.IDs
+	switch {
+	case len(ids) > 0 && req.Tag == "":
+	case req.Tag != "" && len(ids) == 0:
+		monitors, err := s.db.ListMonitorsByTag(req.Tag)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if len(monitors) == 0 {
+			http.Error(w, "No monitors with that tag", 404)
+			return
+		}
+		for _, m := range monitors {
+			ids = append(ids, m.ID)
+		}
+	default:
+		http.Error(w, "Give either ids or tag", 400)
+		return
+	}
+
+	switch req.Action {
+	case storage.BulkDelete, storage.BulkEnable, storage.BulkDisable:
+	default:
+		http.Error(w, "Invalid action, use delete, enable or disable", 400)
+		return
+	}
+
+	results, err := s.db.BulkMonitors(req.Action, ids, storage.AuditSourceAPI)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	changed := 0
+	for _, res := range results {
+		if res.Success {
+			changed++
+		}
+	}
+	if changed > 0 && s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": changed == len(results),
+		"action":  req.Action,
+		"changed": changed,
+		"results": results,
+	})
+}
+
+func (s *Server) handleToggleMonitor(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	monitor, err := s.db.GetMonitor(uint(id))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	monitor.Enabled = !monitor.Enabled
+	monitor.PausedUntil = nil
+	if err := s.db.UpdateMonitor(monitor); err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true, "enabled": monitor.Enabled})
+}
+
+func (s *Server) handlePauseMonitors(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorsEnabled(w, r, false)
+}
+
+func (s *Server) handleResumeMonitors(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorsEnabled(w, r, true)
+}
+
+// setMonitorsEnabled pauses or resumes the monitors selected by exactly one
+// of the id, name or tag parameters, for deploy pipelines. A pause with a
+// duration such as 15m expires on its own. reason is kept in the audit log.
+func (s *Server) setMonitorsEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+
+	q := r.URL.Query()
+	var monitors []storage.Monitor
+	switch id, name, tag := q.Get("id"), q.Get("name"), q.Get("tag"); {
+	case id != "" && name == "" && tag == "":
+		n, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			http.Error(w, "Invalid ID", 400)
+			return
+		}
+		monitor, err := s.db.GetMonitor(uint(n))
+		if err != nil {
+			http.Error(w, "Monitor not found", 404)
+			return
+		}
+		monitors = append(monitors, *monitor)
+	case name != "" && id == "" && tag == "":
+		monitor, err := s.db.GetMonitorByName(name)
+		if err != nil {
+			http.Error(w, "Monitor not found", 404)
+			return
+		}
+		monitors = append(monitors, *monitor)
+	case tag != "" && id == "" && name == "":
+		var err error
+		monitors, err = s.db.ListMonitorsByTag(tag)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if len(monitors) == 0 {
+			http.Error(w, "No monitors with that tag", 404)
+			return
+		}
+	default:
+		http.Error(w, "Give exactly one of id, name or tag", 400)
+		return
+	}
+
+	var until *time.Time
+	if d := q.Get("duration"); d != "" {
+		if enabled {
+			http.Error(w, "duration only applies to pause", 400)
+			return
+		}
+		dur, err := time.ParseDuration(d)
+		if err != nil || dur <= 0 {
+			http.Error(w, "Invalid duration, use e.g. 15m", 400)
+			return
+		}
+		t := time.Now().Add(dur)
+		until = &t
+	}
+
+	type result struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	updated := make([]result, 0, len(monitors))
+	for _, m := range monitors {
+		var err error
+		if enabled {
+			err = s.db.ResumeMonitor(m.ID, storage.AuditSourceAPI, q.Get("reason"))
+		} else {
+			err = s.db.PauseMonitor(m.ID, until, storage.AuditSourceAPI, q.Get("reason"))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		updated = append(updated, result{ID: m.ID, Name: m.Name})
+	}
+
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"enabled":  enabled,
+		"monitors": updated,
+		"until":    until,
+	})
+}
+
+func (s *Server) handleSiteDetail(w http.ResponseWriter, r *http.Request) {
+	// Extract ID from /site/123
+	path := r.URL.Path
+	idStr := path[len("/site/"):]
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	monitor, err := s.db.GetMonitor(uint(id))
+	if err != nil {
+		http.Error(w, "Monitor not found", 404)
+		return
+	}
+
+	// A TCP monitor lists how each port fared in its last check.
+	var ports []string
+	if monitor.IsTCP() {
+		if latest, err := s.db.GetRecentCheckResults(monitor.ID, 1); err == nil && len(latest) > 0 && latest[0].PortResults != "" {
+			ports = strings.Split(latest[0].PortResults, "\n")
+		}
+	}
+
+	shown := monitor.Redacted()
+	s.render(w, http.StatusOK, "detail.html", map[string]interface{}{
+		"Monitor":  &shown,
+		"PingPath": s.pingPaths(r, []storage.Monitor{*monitor})[monitor.ID],
+		"Ports":    ports,
+	})
+}
+
+// pingPaths maps the heartbeat monitors among monitors to their ping
+// paths. The token in the path is the only credential a ping needs, so it
+// is only shown to authorized readers.
+func (s *Server) pingPaths(r *http.Request, monitors []storage.Monitor) map[uint]string {
+	paths := make(map[uint]string)
+	if !s.authorized(r) {
+		return paths
+	}
+	for _, m := range monitors {
+		if m.IsHeartbeat() {
+			paths[m.ID] = "/api/heartbeat/" + m.HeartbeatToken
+		}
+	}
+	return paths
+}
+
+func (s *Server) handleMonitorStats(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	var since time.Time
+	switch period {
+	case "7d":
+		since = time.Now().Add(-7 * 24 * time.Hour)
+	case "30d":
+		since = time.Now().Add(-30 * 24 * time.Hour)
+	default:
+		since = time.Now().Add(-24 * time.Hour)
+	}
+
+	stats, err := s.db.GetCheckResultStats(uint(id), since)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	percentiles, err := s.db.GetResponseTimePercentiles(uint(id), since)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	checkRatio := float64(0)
+	if stats.Total > 0 {
+		checkRatio = float64(stats.Successful) / float64(stats.Total) * 100
+	}
+
+	// Response times are null rather than 0 without successful checks.
+	var avgResponseTime, p50, p95, p99 interface{}
+	if stats.HasResponseTime() {
+		avgResponseTime = stats.AvgResponseTime
+	}
+	if percentiles.Count > 0 {
+		p50, p95, p99 = percentiles.P50, percentiles.P95, percentiles.P99
+	}
+
+	incidentStats, err := s.db.GetIncidentStats(uint(id), since)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	failureReasons, err := s.db.GetFailureReasons(uint(id), since, 5)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	var sla interface{}
+	if monitor, err := s.db.GetMonitor(uint(id)); err == nil {
+		report, err := s.db.GetCurrentSLAReport(monitor)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if report != nil {
+			sla = map[string]interface{}{
+				"target":                   report.Target,
+				"uptime":                   report.Uptime,
+				"period_start":             report.PeriodStart.Format(time.RFC3339),
+				"period_end":               report.PeriodEnd.Format(time.RFC3339),
+				"allowed_downtime_minutes": report.AllowedDowntime.Minutes(),
+				"downtime_minutes":         report.Downtime.Minutes(),
+				"budget_remaining_minutes": report.BudgetRemaining().Minutes(),
+				"exhausted":                report.Exhausted(),
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"total_checks":      stats.Total,
+		"successful_checks": stats.Successful,
+		"failed_checks":     stats.Total - stats.Successful,
+		"uptime":            stats.Uptime.Percent(),
+		"check_ratio":       checkRatio,
+		"unknown_minutes":   stats.Uptime.Unknown.Minutes(),
+		"avg_response_time": avgResponseTime,
+		"p50_response_time": p50,
+		"p95_response_time": p95,
+		"p99_response_time": p99,
+		"incident_count":    incidentStats.Count,
+		"total_downtime":    incidentStats.Downtime.Round(time.Second).String(),
+		"downtime_minutes":  incidentStats.Downtime.Minutes(),
+		"mttr_minutes":      incidentStats.MTTR.Minutes(),
+		"mtbf_minutes":      incidentStats.MTBF.Minutes(),
+		"longest_minutes":   incidentStats.Longest.Minutes(),
+		"failure_reasons":   failureReasons,
+		"sla":               sla,
+	})
+}
+
+const (
+	defaultPageSize = 1000
+	maxPageSize     = 10000
+	maxBuckets      = 5000
+)
+
+// pageParams reads the limit and offset query parameters.
+func pageParams(r *http.Request, defaultLimit int) (limit, offset int, err error) {
+	limit = defaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return 0, 0, fmt.Errorf("invalid limit")
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset")
+		}
+	}
+	return limit, offset, nil
+}
+
+// seriesBuckets is the default bucket width for each chart period.
+var seriesBuckets = map[string]time.Duration{
+	"24h": time.Minute,
+	"7d":  15 * time.Minute,
+	"30d": time.Hour,
+}
+
+// handleMonitorSeries returns chart points for a monitor, aggregated in the
+// database so long periods stay cheap to draw. Buckets without checks are
+// included with null response times.
+func (s *Server) handleMonitorSeries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "24h"
+	}
+	width, ok := seriesBuckets[period]
+	if !ok {
+		http.Error(w, "Invalid period, use 24h, 7d or 30d", 400)
+		return
+	}
+	window, _ := storage.ParsePeriod(period)
+
+	if v := r.URL.Query().Get("bucket"); v != "" {
+		width, err = time.ParseDuration(v)
+		if err != nil || width < time.Minute {
+			http.Error(w, "Invalid bucket, must be a duration of at least 1m", 400)
+			return
+		}
+		if window/width > maxBuckets {
+			http.Error(w, "Bucket too small for period", 400)
+			return
+		}
+	}
+
+	now := time.Now()
+	points, err := s.db.GetCheckSeries(uint(id), now.Add(-window), now, width)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period": period,
+		"bucket": width.String(),
+		"points": points,
+	})
+}
+
+// handleMonitorChecks returns a page of raw check results, or with
+// bucket=<duration> the results averaged into fixed-width buckets.
+func (s *Server) handleMonitorChecks(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	// Get period from query params (default 24h)
+	period := r.URL.Query().Get("period")
+	var window time.Duration
+	switch period {
+	case "7d":
+		window = 7 * 24 * time.Hour
+	case "30d":
+		window = 30 * 24 * time.Hour
+	default:
+		window = 24 * time.Hour
+	}
+	since := time.Now().Add(-window)
+
+	var success *bool
+	if v := r.URL.Query().Get("success"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid success filter", 400)
+			return
+		}
+		success = &b
+	}
+
+	if v := r.URL.Query().Get("bucket"); v != "" {
+		width, err := time.ParseDuration(v)
+		if err != nil || width < time.Minute {
+			http.Error(w, "Invalid bucket, must be a duration of at least 1m", 400)
+			return
+		}
+		if window/width > maxBuckets {
+			http.Error(w, "Bucket too small for period", 400)
+			return
+		}
+
+		buckets, err := s.db.GetCheckResultBuckets(uint(id), since, width, success)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		if buckets == nil {
+			buckets = []storage.CheckBucket{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"bucket": width.String(),
+			"points": buckets,
+		})
+		return
+	}
+
+	limit, offset, err := pageParams(r, defaultPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	results, total, err := s.db.QueryCheckResults(storage.CheckResultFilter{
+		MonitorID: uint(id),
+		Since:     since,
+		Success:   success,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	// Convert to JSON-friendly format with timestamps
+	type CheckData struct {
+		Timestamp    string `json:"timestamp"`
+		ResponseTime int64  `json:"response_time"`
+		StatusCode   int    `json:"status_code"`
+		Success      bool   `json:"success"`
+		Error        string `json:"error,omitempty"`
+		ResolvedIP   string `json:"resolved_ip,omitempty"`
+		TLSVersion   string `json:"tls_version,omitempty"`
+		TLSCipher    string `json:"tls_cipher,omitempty"`
+		Protocol     string `json:"protocol,omitempty"`
+		IPv4Time     int64  `json:"ipv4_time,omitempty"`
+		IPv6Time     int64  `json:"ipv6_time,omitempty"`
+
+		ResponseSnippet string `json:"response_snippet,omitempty"`
+		ResponseHeaders string `json:"response_headers,omitempty"`
+		PortResults     string `json:"port_results,omitempty"`
+	}
+
+	// Response snippets of failed checks are only sent when asked for, to
+	// keep the default payload small.
+	includeBody := r.URL.Query().Get("include_body") == "1"
+
+	checks := make([]CheckData, len(results))
+	for i, r := range results {
+		checks[i] = CheckData{
+			Timestamp:    r.CreatedAt.Format(time.RFC3339),
+			ResponseTime: r.ResponseTime,
+			StatusCode:   r.StatusCode,
+			Success:      r.Success,
+			Error:        r.ErrorMessage,
+			ResolvedIP:   r.ResolvedIP,
+			TLSVersion:   r.TLSVersion,
+			TLSCipher:    r.TLSCipher,
+			Protocol:     r.Protocol,
+			IPv4Time:     r.IPv4Time,
+			IPv6Time:     r.IPv6Time,
+			PortResults:  r.PortResults,
+		}
+		if includeBody {
+			checks[i].ResponseSnippet = r.ResponseSnippet
+			checks[i].ResponseHeaders = r.ResponseHeaders
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"checks": checks,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (s *Server) handleMonitorIncidents(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	limit, offset, err := pageParams(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	incidents, total, err := s.db.QueryIncidents(uint(id), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	type IncidentData struct {
+		ID         uint    `json:"id"`
+		Type       string  `json:"type"`
+		StartedAt  string  `json:"started_at"`
+		ResolvedAt *string `json:"resolved_at"`
+		Duration   string  `json:"duration"`
+		Error      string  `json:"error"`
+		Resolved   bool    `json:"resolved"`
+		Resolution string  `json:"resolution,omitempty"`
+	}
+
+	data := make([]IncidentData, len(incidents))
+	for i, inc := range incidents {
+		var resolvedAt *string
+		if inc.ResolvedAt != nil {
+			t := inc.ResolvedAt.Format(time.RFC3339)
+			resolvedAt = &t
+		}
+
+		typ := storage.IncidentAvailability
+		if inc.IsPerformance() {
+			typ = storage.IncidentPerformance
+		}
+		data[i] = IncidentData{
+			ID:         inc.ID,
+			Type:       typ,
+			StartedAt:  inc.StartedAt.Format(time.RFC3339),
+			ResolvedAt: resolvedAt,
+			Duration:   formatDurationHuman(inc.Duration()),
+			Error:      inc.ErrorMessage,
+			Resolved:   inc.ResolvedAt != nil,
+			Resolution: inc.Resolution,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"incidents": data,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
+
+func (s *Server) handleContentChanges(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+
+	changes, err := s.db.GetContentChanges(uint(id), limit)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(changes)
+}
+
+// handleStatusTimeline serves the spans a monitor spent in each status over
+// a period, for drawing its status timeline.
+func (s *Server) handleStatusTimeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "24h"
+	}
+	if _, ok := seriesBuckets[period]; !ok {
+		http.Error(w, "Invalid period, use 24h, 7d or 30d", 400)
+		return
+	}
+	window, _ := storage.ParsePeriod(period)
+
+	now := time.Now()
+	spans, err := s.db.GetStatusTimeline(uint(id), now.Add(-window))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	if spans == nil {
+		spans = []storage.StatusSpan{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period": period,
+		"since":  now.Add(-window),
+		"until":  now,
+		"spans":  spans,
+	})
+}
+
+func (s *Server) handleDailyUptime(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	days := 90
+	if d := r.URL.Query().Get("days"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n <= 0 || n > 365 {
+			http.Error(w, "days must be between 1 and 365", 400)
+			return
+		}
+		days = n
+	}
+
+	daily, err := s.db.GetDailyUptime(uint(id), days)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(daily)
+}
+
+func (s *Server) handleExportChecks(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		http.Error(w, "Invalid ID", 400)
+		return
+	}
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, "Unsupported format, only csv is available", 400)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "30d"
+	}
+	window, err := storage.ParsePeriod(period)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	monitor, err := s.db.GetMonitor(uint(id))
+	if err != nil {
+		http.Error(w, "Monitor not found", 404)
+		return
+	}
+
+	filename := fmt.Sprintf("statping-monitor-%d-%s.csv", monitor.ID, period)
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// Headers are already sent once rows stream out, so a failure midway
+	// can only truncate the file.
+	s.db.WriteCheckResultsCSV(w, monitor.ID, time.Now().Add(-window))
+}
+
+func formatDurationHuman(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	return fmt.Sprintf("%dd %dh", days, hours)
+}
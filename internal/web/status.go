@@ -0,0 +1,151 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+const statusPageDays = 90
+
+type statusDay struct {
+	Class string
+	Title string
+}
+
+type statusMonitor struct {
+	Monitor storage.Monitor
+	Days    []statusDay
+	Uptime  float64
+	HasData bool
+}
+
+type statusIncident struct {
+	Name     string
+	Incident storage.Incident
+}
+
+// NewPublic creates a Server that only exposes the read-only status page
+// and the heartbeat ingest endpoint. None of the management pages or other
+// API endpoints are mounted.
+func NewPublic(db *storage.Database) *Server {
+	s := &Server{
+		db:         db,
+		mux:        http.NewServeMux(),
+		publicOnly: true,
+		templates:  parseTemplates(),
+	}
+
+	s.mux.HandleFunc("/", s.handlePublicRoot)
+	s.mux.HandleFunc("/status", s.handleStatus)
+	s.mux.HandleFunc("/badge/", s.handleBadge)
+	s.mux.HandleFunc("/feed/incidents.atom", s.handleIncidentFeed)
+	s.mux.HandleFunc("/api/heartbeat/", s.handleHeartbeat)
+	s.mux.HandleFunc("/api/summary", s.handleSummary)
+	s.mux.HandleFunc("/static/style.css", s.handleCSS)
+
+	return s
+}
+
+func (s *Server) handlePublicRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	s.handleStatus(w, r)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	monitors, err := s.db.ListPublicMonitors()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	names := make(map[uint]string, len(monitors))
+	rows := make([]statusMonitor, 0, len(monitors))
+	downCount := 0
+	for _, mon := range monitors {
+		names[mon.ID] = mon.Name
+		if mon.CurrentStatus == "down" {
+			downCount++
+		}
+
+		row := statusMonitor{Monitor: mon}
+		days, err := s.db.GetDailyUptime(mon.ID, statusPageDays)
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+
+		var checks, failures int64
+		for _, day := range days {
+			checks += day.Checks
+			failures += day.Failures
+			row.Days = append(row.Days, statusDayFor(day))
+		}
+		if checks > 0 {
+			row.HasData = true
+			row.Uptime = float64(checks-failures) / float64(checks) * 100
+		}
+		rows = append(rows, row)
+	}
+
+	recent, err := s.db.GetAllRecentIncidents(100)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	var incidents []statusIncident
+	slowCount := 0
+	for _, inc := range recent {
+		name, ok := names[inc.MonitorID]
+		if !ok {
+			continue
+		}
+		if inc.IsPerformance() && !inc.IsResolved() {
+			slowCount++
+		}
+		if len(incidents) == 10 {
+			continue
+		}
+		incidents = append(incidents, statusIncident{
+			Name:     name,
+			Incident: inc,
+		})
+	}
+
+	banner, bannerClass := "All Systems Operational", "up"
+	switch {
+	case len(monitors) > 0 && downCount == len(monitors):
+		banner, bannerClass = "Major Outage", "down"
+	case downCount > 0:
+		banner, bannerClass = "Partial Outage", "partial"
+	case slowCount > 0:
+		banner, bannerClass = "Degraded Performance", "partial"
+	}
+
+	s.render(w, http.StatusOK, "status.html", map[string]interface{}{
+		"Banner":      banner,
+		"BannerClass": bannerClass,
+		"Monitors":    rows,
+		"Incidents":   incidents,
+		"Days":        statusPageDays,
+	})
+}
+
+func statusDayFor(day storage.DailyUptime) statusDay {
+	date := day.Date.Format("Jan 2")
+	switch {
+	case day.Checks == 0:
+		return statusDay{Class: "none", Title: date + ": no data"}
+	case day.Failures == 0:
+		return statusDay{Class: "up", Title: fmt.Sprintf("%s: 100%% (%d checks)", date, day.Checks)}
+	case day.Uptime >= 95:
+		return statusDay{Class: "partial", Title: fmt.Sprintf("%s: %.2f%% (%d checks)", date, day.Uptime, day.Checks)}
+	default:
+		return statusDay{Class: "down", Title: fmt.Sprintf("%s: %.2f%% (%d checks)", date, day.Uptime, day.Checks)}
+	}
+}
@@ -0,0 +1,153 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ankityadav/statping/internal/storage"
+)
+
+// monitorSummary is a monitor along with what the index page shows about
+// its recent health. The monitor's own fields stay at the top level of its
+// JSON so /api/monitors?expand=stats is a superset of the plain list.
+type monitorSummary struct {
+	storage.Monitor
+	Uptime24h *float64   `json:"uptime_24h"`
+	LastError string     `json:"last_error,omitempty"`
+	DownSince *time.Time `json:"down_since,omitempty"`
+}
+
+// DownFor is how long the monitor's open incident has lasted, or zero.
+func (m monitorSummary) DownFor() time.Duration {
+	if m.DownSince == nil {
+		return 0
+	}
+	return time.Since(*m.DownSince)
+}
+
+// summarizeMonitors adds 24h uptime, the latest error and any open incident
+// to monitors, with a fixed number of queries however many there are. The
+// monitors are redacted, since summaries are served to anyone who can read
+// the monitor list.
+func (s *Server) summarizeMonitors(monitors []storage.Monitor) ([]monitorSummary, error) {
+	ids := make([]uint, len(monitors))
+	for i, m := range monitors {
+		ids[i] = m.ID
+	}
+
+	uptimes, err := s.db.GetUptimeForMonitors(ids, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.db.GetLatestResultPerMonitor()
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := s.db.ListActiveIncidents()
+	if err != nil {
+		return nil, err
+	}
+	open := make(map[uint]storage.Incident, len(incidents))
+	for _, inc := range incidents {
+		open[inc.MonitorID] = inc
+	}
+
+	summaries := make([]monitorSummary, len(monitors))
+	for i, m := range monitors {
+		sum := monitorSummary{Monitor: m.Redacted()}
+		if u, ok := uptimes[m.ID]; ok && u.Up+u.Down > 0 {
+			pct := u.Percent()
+			sum.Uptime24h = &pct
+		}
+		if r, ok := latest[m.ID]; ok && !r.Success {
+			sum.LastError = r.ErrorMessage
+		}
+		if inc, ok := open[m.ID]; ok {
+			started := inc.StartedAt
+			sum.DownSince = &started
+			if sum.LastError == "" {
+				sum.LastError = inc.ErrorMessage
+			}
+		}
+		summaries[i] = sum
+	}
+	return summaries, nil
+}
+
+// handleSummary serves overall counts and the longest open outage, small
+// enough for a status widget to poll. A public server counts only its
+// public monitors.
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	var monitors []storage.Monitor
+	var err error
+	if s.publicOnly {
+		monitors, err = s.db.ListPublicMonitors()
+	} else {
+		monitors, err = s.db.ListMonitors()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	incidents, err := s.db.ListActiveIncidents()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	type worstIncident struct {
+		MonitorID uint      `json:"monitor_id"`
+		Name      string    `json:"name"`
+		Since     time.Time `json:"since"`
+		Error     string    `json:"error,omitempty"`
+	}
+	var summary struct {
+		Status  string         `json:"status"`
+		Total   int            `json:"total"`
+		Up      int            `json:"up"`
+		Down    int            `json:"down"`
+		Pending int            `json:"pending"`
+		Unknown int            `json:"unknown"`
+		Paused  int            `json:"paused"`
+		Worst   *worstIncident `json:"worst_incident"`
+	}
+
+	names := make(map[uint]string, len(monitors))
+	for _, m := range monitors {
+		names[m.ID] = m.Name
+		summary.Total++
+		switch {
+		case !m.Enabled:
+			summary.Paused++
+		case m.DisplayStatus() == "up":
+			summary.Up++
+		case m.DisplayStatus() == "down":
+			summary.Down++
+		case m.DisplayStatus() == storage.StatusPending:
+			summary.Pending++
+		default:
+			summary.Unknown++
+		}
+	}
+
+	for _, inc := range incidents {
+		name, ok := names[inc.MonitorID]
+		if !ok || (summary.Worst != nil && !inc.StartedAt.Before(summary.Worst.Since)) {
+			continue
+		}
+		summary.Worst = &worstIncident{MonitorID: inc.MonitorID, Name: name, Since: inc.StartedAt, Error: inc.ErrorMessage}
+	}
+
+	switch {
+	case summary.Down > 0 && summary.Down == summary.Total-summary.Paused:
+		summary.Status = "down"
+	case summary.Down > 0:
+		summary.Status = "partial"
+	default:
+		summary.Status = "up"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
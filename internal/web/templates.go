@@ -0,0 +1,70 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pageNames are the templates rendered as whole pages.
+var pageNames = []string{"index.html", "detail.html", "status.html", "login.html"}
+
+// templateFuncs format values the same way across pages.
+var templateFuncs = template.FuncMap{
+	// duration renders a span like "3h 12m".
+	"duration": formatDurationHuman,
+	// seconds renders a setting stored in seconds, like "30s" or "5m".
+	"seconds": func(secs int) string {
+		return formatSeconds(time.Duration(secs) * time.Second)
+	},
+	// datetime renders a local timestamp like "Jan 2, 15:04".
+	"datetime": func(t time.Time) string {
+		return t.Local().Format("Jan 2, 15:04")
+	},
+	// uptime renders a percentage like "99.95%".
+	"uptime": func(pct float64) string {
+		return fmt.Sprintf("%.2f%%", pct)
+	},
+}
+
+// parseTemplates parses every page once. The templates are embedded, so a
+// parse error is a bug in the build and panics when the server is created
+// rather than on every request.
+func parseTemplates() map[string]*template.Template {
+	pages := make(map[string]*template.Template, len(pageNames))
+	for _, name := range pageNames {
+		pages[name] = template.Must(template.New(name).Funcs(templateFuncs).ParseFS(templatesFS, "templates/"+name))
+	}
+	return pages
+}
+
+// render executes the named page into a buffer so that a failure halfway
+// through becomes a 500 instead of a truncated page.
+func (s *Server) render(w http.ResponseWriter, code int, name string, data interface{}) {
+	var buf bytes.Buffer
+	if err := s.templates[name].Execute(&buf, data); err != nil {
+		slog.Error("failed to render page", "template", name, "error", err)
+		http.Error(w, "Failed to render page", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	buf.WriteTo(w)
+}
+
+// formatSeconds renders whole durations compactly, "90s" as "1m30s" and
+// "1h0m0s" as "1h".
+func formatSeconds(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}